@@ -3,28 +3,63 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/jasmaa/misaka-net/internal/debugger"
+	"github.com/jasmaa/misaka-net/internal/logging"
 	"github.com/jasmaa/misaka-net/internal/nodes"
+	"github.com/jasmaa/misaka-net/internal/tis"
 )
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		if err := debugger.Main(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	nodeType := os.Getenv("NODE_TYPE")
 	certFile := os.Getenv("CERT_FILE")
 	keyFile := os.Getenv("KEY_FILE")
+	includeDir := os.Getenv("INCLUDE_DIR")
+	model, err := tis.ParseNumericModel(os.Getenv("NUMERIC_MODEL"))
+	if err != nil {
+		panic(err)
+	}
+	logger, err := newLogger(nodeType)
+	if err != nil {
+		panic(err)
+	}
+	retry, err := newRetryPolicy()
+	if err != nil {
+		panic(err)
+	}
 
 	switch nodeType {
 	case "program":
-		p := nodes.NewProgramNode(os.Getenv("MASTER_URI"), certFile, keyFile)
+		p := nodes.NewProgramNode(os.Getenv("MASTER_URI"), includeDir, model, certFile, keyFile)
+		p.SetLogger(logger)
+		p.SetRetryPolicy(retry)
+		if s := os.Getenv("TRACE_SIZE"); s != "" {
+			size, err := strconv.Atoi(s)
+			if err != nil {
+				panic(fmt.Errorf("invalid trace size"))
+			}
+			p.EnableTrace(size)
+		}
 		err := p.LoadProgram(os.Getenv("PROGRAM"))
 		if err != nil {
-			log.Printf("Could not load default program: %s", err.Error())
+			logger.Warn("could not load default program", "err", err)
 		}
 		p.Start()
 	case "stack":
-		s := nodes.NewStackNode(certFile, keyFile)
+		s := nodes.NewStackNode(model, certFile, keyFile)
+		s.SetLogger(logger)
 		s.Start()
 	case "master":
 		var nodeInfo map[string]nodes.NodeInfo
@@ -32,9 +67,68 @@ func main() {
 		if err != nil {
 			panic(fmt.Errorf("invalid node info"))
 		}
-		m := nodes.NewMasterNode(nodeInfo, certFile, keyFile)
+		m := nodes.NewMasterNode(nodeInfo, includeDir, certFile, keyFile)
+		m.SetLogger(logger)
+		m.SetRetryPolicy(retry)
+		if s := os.Getenv("DEADLOCK_INTERVAL"); s != "" {
+			interval, err := time.ParseDuration(s)
+			if err != nil {
+				panic(fmt.Errorf("invalid deadlock interval"))
+			}
+			m.WatchDeadlocks(interval, os.Getenv("DEADLOCK_PAUSE") == "true")
+		}
 		m.Start()
 	default:
 		panic(fmt.Errorf("'%s' not a valid node type", nodeType))
 	}
 }
+
+// newLogger creates logger from LOG_LEVEL and LOG_FORMAT.
+// Node is named by NODE_NAME or hostname.
+func newLogger(nodeType string) (*logging.Logger, error) {
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return nil, err
+	}
+	format, err := logging.ParseFormat(os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		return nil, err
+	}
+	name := os.Getenv("NODE_NAME")
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	return logging.New(os.Stderr, level, format).With("node", name, "type", nodeType), nil
+}
+
+// newRetryPolicy creates retry policy from RETRY_ATTEMPTS, RETRY_BASE_DELAY, RETRY_MAX_DELAY and CALL_TIMEOUT.
+// Unset values are taken from default policy.
+func newRetryPolicy() (nodes.RetryPolicy, error) {
+	rp := nodes.DefaultRetryPolicy
+	if s := os.Getenv("RETRY_ATTEMPTS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return rp, fmt.Errorf("invalid retry attempts")
+		}
+		rp.Attempts = n
+	}
+	durations := []struct {
+		env  string
+		name string
+		d    *time.Duration
+	}{
+		{"RETRY_BASE_DELAY", "retry base delay", &rp.BaseDelay},
+		{"RETRY_MAX_DELAY", "retry max delay", &rp.MaxDelay},
+		{"CALL_TIMEOUT", "call timeout", &rp.Timeout},
+	}
+	for _, v := range durations {
+		if s := os.Getenv(v.env); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				return rp, fmt.Errorf("invalid %s", v.name)
+			}
+			*v.d = d
+		}
+	}
+	return rp, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+var (
+	write = flag.Bool("w", false, "write result to source file instead of stdout")
+	list  = flag.Bool("l", false, "list files whose formatting differs")
+	check = flag.Bool("check", false, "exit with non-zero status if any file is not formatted")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: tisfmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "tisfmt: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tisfmt: %s\n", err.Error())
+			os.Exit(2)
+		}
+		ok, err := processFile("<standard input>", src)
+		if err != nil {
+			os.Exit(2)
+		}
+		if !ok && *check {
+			os.Exit(1)
+		}
+		return
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tisfmt: %s\n", err.Error())
+			status = 2
+			continue
+		}
+		ok, err := processFile(path, src)
+		if err != nil {
+			status = 2
+		} else if !ok && *check && status == 0 {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// processFile formats a single file and reports whether it was already formatted
+func processFile(path string, src []byte) (bool, error) {
+	res, err := tis.Format(string(src))
+	if err != nil {
+		if diags, ok := tis.AsDiagnostics(err); ok {
+			for _, d := range diags {
+				fmt.Fprintf(os.Stderr, "%s:%v:%v: %s\n", path, d.Line, d.Col, d.Message)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.Error())
+		}
+		return false, err
+	}
+
+	formatted := res == string(src)
+	if !formatted && (*list || *check) {
+		fmt.Println(path)
+	}
+	if *write {
+		if !formatted {
+			if err := ioutil.WriteFile(path, []byte(res), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "tisfmt: %s\n", err.Error())
+				return false, err
+			}
+		}
+	} else if !*list && !*check {
+		fmt.Print(res)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,186 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NodeInfo contains information about a node on the network
+type NodeInfo struct {
+	Type string `json:"type"`
+}
+
+// Register is the state of a network register on a program node
+type Register struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+	Full  bool   `json:"full"`
+}
+
+// State is the debug state of a program node
+type State struct {
+	Ptr         int32      `json:"ptr"`
+	Line        int32      `json:"line"`
+	Instruction string     `json:"instruction"`
+	Acc         int64      `json:"acc"`
+	Bak         int64      `json:"bak"`
+	Registers   []Register `json:"registers"`
+	Running     bool       `json:"running"`
+	Reason      string     `json:"reason"`
+	State       string     `json:"state"`
+	Breakpoints []int32    `json:"breakpoints"`
+	Watches     []string   `json:"watches"`
+}
+
+// Program is the program on a program node with the source line of each instruction
+type Program struct {
+	Instructions []string `json:"instructions"`
+	Lines        []int32  `json:"lines"`
+}
+
+// Stack is the contents of a stack node from bottom to top
+type Stack struct {
+	Values []int64 `json:"values"`
+}
+
+// Client talks to the client endpoints of a master node
+type Client struct {
+	masterURL string
+	http      *http.Client
+}
+
+// NewClient creates a client for master node at masterURL
+func NewClient(masterURL string) *Client {
+	return &Client{
+		masterURL: strings.TrimSuffix(masterURL, "/"),
+		http:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Nodes gets nodes on network
+func (c *Client) Nodes() (map[string]NodeInfo, error) {
+	var nodes map[string]NodeInfo
+	err := c.getJSON("/nodes", nil, &nodes)
+	return nodes, err
+}
+
+// Program gets canonical source of program on node, one instruction per line
+func (c *Client) Program(node string) (*Program, error) {
+	prog := &Program{}
+	err := c.getJSON("/program", url.Values{"targetURI": {node}, "format": {"json"}}, prog)
+	return prog, err
+}
+
+// State gets debug state of program node
+func (c *Client) State(node string) (*State, error) {
+	state := &State{}
+	err := c.getJSON("/debug/state", url.Values{"targetURI": {node}}, state)
+	return state, err
+}
+
+// Stack gets contents of stack node
+func (c *Client) Stack(node string) (*Stack, error) {
+	stack := &Stack{}
+	err := c.getJSON("/debug/stack", url.Values{"targetURI": {node}}, stack)
+	return stack, err
+}
+
+// Step runs count instructions on paused program node
+func (c *Client) Step(node string, count int) (*State, error) {
+	body, err := c.do("POST", "/debug/step", url.Values{"targetURI": {node}, "count": {strconv.Itoa(count)}})
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	return state, json.Unmarshal(body, state)
+}
+
+// Continue resumes program node stopped by debugger
+func (c *Client) Continue(node string) error {
+	_, err := c.do("POST", "/debug/continue", url.Values{"targetURI": {node}})
+	return err
+}
+
+// Run runs whole network
+func (c *Client) Run() error {
+	_, err := c.do("POST", "/run", nil)
+	return err
+}
+
+// Pause pauses whole network
+func (c *Client) Pause() error {
+	_, err := c.do("POST", "/pause", nil)
+	return err
+}
+
+// Reset resets whole network
+func (c *Client) Reset() error {
+	_, err := c.do("POST", "/reset", nil)
+	return err
+}
+
+// SetBreakpoint sets breakpoint on line of program node
+func (c *Client) SetBreakpoint(node string, line int) error {
+	_, err := c.do("POST", "/debug/breakpoint", url.Values{"targetURI": {node}, "line": {strconv.Itoa(line)}})
+	return err
+}
+
+// ClearBreakpoint clears breakpoint on line of program node. Line 0 clears all.
+func (c *Client) ClearBreakpoint(node string, line int) error {
+	_, err := c.do("DELETE", "/debug/breakpoint", url.Values{"targetURI": {node}, "line": {strconv.Itoa(line)}})
+	return err
+}
+
+// SetWatch sets watch on program node
+func (c *Client) SetWatch(node string, condition string) error {
+	_, err := c.do("POST", "/debug/watch", url.Values{"targetURI": {node}, "condition": {condition}})
+	return err
+}
+
+// ClearWatch clears watch on program node. Empty condition clears all.
+func (c *Client) ClearWatch(node string, condition string) error {
+	_, err := c.do("DELETE", "/debug/watch", url.Values{"targetURI": {node}, "condition": {condition}})
+	return err
+}
+
+// getJSON gets path and decodes JSON response into v
+func (c *Client) getJSON(path string, params url.Values, v interface{}) error {
+	body, err := c.do("GET", path, params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// do sends request to master and reads response body.
+// Params go in query string since master does not parse bodies of DELETE requests.
+func (c *Client) do(method string, path string, params url.Values) ([]byte, error) {
+	u := c.masterURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,287 @@
+// Package debugger is an interactive terminal debugger for a running network.
+//
+// It attaches to the client endpoints of a master node and draws every
+// program node's source, registers and stacks after each command.
+package debugger
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const usage = `commands:
+  show                     draw network (same as empty line)
+  nodes                    list nodes on network
+  step [node] [count]      run count instructions on node, or one on every program node
+  continue [node]          resume node, or run whole network
+  pause                    pause whole network
+  reset                    reset whole network
+  break <node> <line>      set breakpoint
+  clear <node> [line]      clear breakpoint, or all breakpoints on node
+  watch <node> <cond>      stop node when condition becomes true, e.g. ACC>10
+  unwatch <node> [cond]    clear watch, or all watches on node
+  help                     show this message
+  quit                     exit debugger`
+
+// Debugger runs commands against a network and draws it
+type Debugger struct {
+	client   *Client
+	renderer *Renderer
+	out      io.Writer
+
+	programs []string
+	stacks   []string
+}
+
+// New creates a debugger attached to master node at masterURL
+func New(masterURL string, renderer *Renderer, out io.Writer) *Debugger {
+	return &Debugger{
+		client:   NewClient(masterURL),
+		renderer: renderer,
+		out:      out,
+	}
+}
+
+// Main parses args and runs debugger on stdin and stdout
+func Main(args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ContinueOnError)
+	masterURL := os.Getenv("MASTER_URL")
+	if masterURL == "" {
+		masterURL = "http://localhost:8000"
+	}
+	fs.StringVar(&masterURL, "master", masterURL, "URL of master node client endpoints")
+	color := fs.Bool("color", isTerminal(os.Stdout), "highlight current instruction")
+	columns := fs.Int("columns", 3, "number of nodes drawn side by side")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	d := New(masterURL, &Renderer{Color: *color, Columns: *columns}, os.Stdout)
+	if err := d.Attach(); err != nil {
+		return fmt.Errorf("cannot attach to %s: %s", masterURL, err.Error())
+	}
+	return d.Run(os.Stdin)
+}
+
+// Attach looks up nodes on network
+func (d *Debugger) Attach() error {
+	nodes, err := d.client.Nodes()
+	if err != nil {
+		return err
+	}
+	d.programs = nil
+	d.stacks = nil
+	for name, info := range nodes {
+		switch info.Type {
+		case "program":
+			d.programs = append(d.programs, name)
+		case "stack":
+			d.stacks = append(d.stacks, name)
+		}
+	}
+	sort.Strings(d.programs)
+	sort.Strings(d.stacks)
+	return nil
+}
+
+// Run reads commands from in until quit or end of input
+func (d *Debugger) Run(in io.Reader) error {
+	d.Show()
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(d.out, "(misaka) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return scanner.Err()
+		}
+		quit, err := d.Exec(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(d.out, "error: %s\n", err.Error())
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Exec runs a single command. Returns whether debugger should exit.
+func (d *Debugger) Exec(line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		d.Show()
+		return false, nil
+	}
+	cmd, args := fields[0], fields[1:]
+
+	switch cmd {
+	case "show", "s":
+		d.Show()
+	case "nodes":
+		for _, name := range d.programs {
+			fmt.Fprintf(d.out, "%s\tprogram\n", name)
+		}
+		for _, name := range d.stacks {
+			fmt.Fprintf(d.out, "%s\tstack\n", name)
+		}
+	case "step", "n":
+		return false, d.step(args)
+	case "continue", "c":
+		if len(args) == 0 {
+			return false, d.client.Run()
+		}
+		if err := d.checkProgram(args[0]); err != nil {
+			return false, err
+		}
+		return false, d.client.Continue(args[0])
+	case "pause", "p":
+		if err := d.client.Pause(); err != nil {
+			return false, err
+		}
+		d.Show()
+	case "reset":
+		if err := d.client.Reset(); err != nil {
+			return false, err
+		}
+		d.Show()
+	case "break", "b":
+		if len(args) != 2 {
+			return false, fmt.Errorf("usage: break <node> <line>")
+		}
+		line, err := strconv.Atoi(args[1])
+		if err != nil {
+			return false, fmt.Errorf("cannot parse line '%s'", args[1])
+		}
+		if err := d.checkProgram(args[0]); err != nil {
+			return false, err
+		}
+		return false, d.client.SetBreakpoint(args[0], line)
+	case "clear":
+		if len(args) < 1 || len(args) > 2 {
+			return false, fmt.Errorf("usage: clear <node> [line]")
+		}
+		line := 0
+		if len(args) == 2 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil {
+				return false, fmt.Errorf("cannot parse line '%s'", args[1])
+			}
+			line = v
+		}
+		if err := d.checkProgram(args[0]); err != nil {
+			return false, err
+		}
+		return false, d.client.ClearBreakpoint(args[0], line)
+	case "watch":
+		if len(args) < 2 {
+			return false, fmt.Errorf("usage: watch <node> <cond>")
+		}
+		if err := d.checkProgram(args[0]); err != nil {
+			return false, err
+		}
+		return false, d.client.SetWatch(args[0], strings.Join(args[1:], ""))
+	case "unwatch":
+		if len(args) < 1 {
+			return false, fmt.Errorf("usage: unwatch <node> [cond]")
+		}
+		if err := d.checkProgram(args[0]); err != nil {
+			return false, err
+		}
+		return false, d.client.ClearWatch(args[0], strings.Join(args[1:], ""))
+	case "help", "h", "?":
+		fmt.Fprintln(d.out, usage)
+	case "quit", "q", "exit":
+		return true, nil
+	default:
+		return false, fmt.Errorf("'%s' not a valid command, try help", cmd)
+	}
+	return false, nil
+}
+
+// step steps one node, or every program node once, and redraws network
+func (d *Debugger) step(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: step [node] [count]")
+	}
+
+	if len(args) == 0 {
+		// Step nodes together so nodes waiting on each other can both finish
+		var wg sync.WaitGroup
+		errs := make([]error, len(d.programs))
+		for i, name := range d.programs {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				_, errs[i] = d.client.Step(name, 1)
+			}(i, name)
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				fmt.Fprintf(d.out, "error stepping %s: %s\n", d.programs[i], err.Error())
+			}
+		}
+		d.Show()
+		return nil
+	}
+
+	node := args[0]
+	if err := d.checkProgram(node); err != nil {
+		return err
+	}
+	count := 1
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			return fmt.Errorf("cannot parse count '%s'", args[1])
+		}
+		count = v
+	}
+	if _, err := d.client.Step(node, count); err != nil {
+		return err
+	}
+	d.Show()
+	return nil
+}
+
+// checkProgram checks that node is a program node on network
+func (d *Debugger) checkProgram(node string) error {
+	for _, name := range d.programs {
+		if name == node {
+			return nil
+		}
+	}
+	return fmt.Errorf("program node %s not valid on this network", node)
+}
+
+// Show fetches state of every node and draws network
+func (d *Debugger) Show() {
+	programs := make([]NodeView, len(d.programs))
+	for i, name := range d.programs {
+		v := NodeView{Name: name}
+		v.Program, v.Err = d.client.Program(name)
+		if v.Err == nil {
+			v.State, v.Err = d.client.State(name)
+		}
+		programs[i] = v
+	}
+	stacks := make([]StackView, len(d.stacks))
+	for i, name := range d.stacks {
+		v := StackView{Name: name}
+		v.Stack, v.Err = d.client.Stack(name)
+		stacks[i] = v
+	}
+	d.renderer.Render(d.out, programs, stacks)
+}
+
+// isTerminal checks if f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
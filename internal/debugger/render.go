@@ -0,0 +1,204 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Widths of columns in a node box
+const (
+	sourceWidth = 24
+	stateWidth  = 11
+)
+
+// Minimum number of source rows in a node box so state column always fits
+const minRows = 8
+
+// ANSI codes for highlighting current instruction
+const (
+	highlightOn  = "\x1b[7m"
+	highlightOff = "\x1b[0m"
+)
+
+// NodeView is everything needed to draw a program node
+type NodeView struct {
+	Name    string
+	Program *Program
+	State   *State
+	Err     error
+}
+
+// StackView is everything needed to draw a stack node
+type StackView struct {
+	Name  string
+	Stack *Stack
+	Err   error
+}
+
+// Renderer draws network state TIS-100 style
+type Renderer struct {
+	// Color highlights current instruction with ANSI reverse video
+	Color bool
+	// Columns is number of node boxes drawn side by side
+	Columns int
+}
+
+// Render draws program nodes as boxes with their source and registers, followed by stacks
+func (r *Renderer) Render(w io.Writer, programs []NodeView, stacks []StackView) {
+	columns := r.Columns
+	if columns < 1 {
+		columns = 1
+	}
+	for i := 0; i < len(programs); i += columns {
+		end := i + columns
+		if end > len(programs) {
+			end = len(programs)
+		}
+		var boxes [][]string
+		for _, v := range programs[i:end] {
+			boxes = append(boxes, r.box(v))
+		}
+		for _, line := range joinBoxes(boxes) {
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	for _, v := range stacks {
+		if v.Err != nil {
+			fmt.Fprintf(w, "stack %s: %s\n", v.Name, v.Err.Error())
+			continue
+		}
+		values := make([]string, len(v.Stack.Values))
+		for i, value := range v.Stack.Values {
+			values[i] = fmt.Sprint(value)
+		}
+		fmt.Fprintf(w, "stack %s: [%s] <- top\n", v.Name, strings.Join(values, " "))
+	}
+}
+
+// box draws a program node as lines of equal width
+func (r *Renderer) box(v NodeView) []string {
+	title := v.Name
+	if v.State != nil {
+		switch {
+		case v.State.Running && v.State.State != "":
+			title += fmt.Sprintf(" (%s)", v.State.State)
+		case v.State.Running:
+			title += " (running)"
+		case v.State.Reason != "":
+			title += fmt.Sprintf(" (%s)", v.State.Reason)
+		}
+	}
+	width := sourceWidth + stateWidth + 1
+	header := "+-" + truncate(title, width-2) + " "
+	header += strings.Repeat("-", width+2-len(header)) + "+"
+	border := "+" + strings.Repeat("-", sourceWidth) + "+" + strings.Repeat("-", stateWidth) + "+"
+
+	lines := []string{header}
+	if v.Err != nil {
+		lines = append(lines, "|"+pad(" "+v.Err.Error(), width)+"|", border)
+		return lines
+	}
+
+	breakpoints := make(map[int]bool)
+	for _, line := range v.State.Breakpoints {
+		breakpoints[int(line)] = true
+	}
+	state := stateColumn(v.State)
+
+	// Rows are instructions, numbered by the source line they came from
+	instrs := v.Program.Instructions
+	lineOf := func(i int) int {
+		if i < len(v.Program.Lines) {
+			return int(v.Program.Lines[i])
+		}
+		return i + 1
+	}
+	rows := len(instrs)
+	if rows < minRows {
+		rows = minRows
+	}
+	for i := 0; i < rows; i++ {
+		src := ""
+		if i < len(instrs) {
+			line := lineOf(i)
+			marker := " "
+			if breakpoints[line] && (i == 0 || lineOf(i-1) != line) {
+				marker = "*"
+			}
+			current := " "
+			if i == int(v.State.Ptr) {
+				current = ">"
+			}
+			src = pad(fmt.Sprintf("%2d%s%s %s", line, marker, current, instrs[i]), sourceWidth)
+			if r.Color && i == int(v.State.Ptr) {
+				src = highlightOn + src + highlightOff
+			}
+		} else {
+			src = pad("", sourceWidth)
+		}
+
+		st := ""
+		if i < len(state) {
+			st = state[i]
+		}
+		lines = append(lines, "|"+src+"|"+pad(st, stateWidth)+"|")
+	}
+	lines = append(lines, border)
+	return lines
+}
+
+// stateColumn lists registers shown to the right of the source
+func stateColumn(s *State) []string {
+	col := []string{
+		fmt.Sprintf(" ACC %6d", s.Acc),
+		fmt.Sprintf(" BAK %6d", s.Bak),
+		"",
+	}
+	for _, reg := range s.Registers {
+		value := "-"
+		if reg.Full {
+			value = fmt.Sprint(reg.Value)
+		}
+		col = append(col, fmt.Sprintf(" %-3s %6s", reg.Name, value))
+	}
+	return col
+}
+
+// joinBoxes places boxes side by side
+func joinBoxes(boxes [][]string) []string {
+	height := 0
+	for _, b := range boxes {
+		if len(b) > height {
+			height = len(b)
+		}
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		var parts []string
+		for _, b := range boxes {
+			if i < len(b) {
+				parts = append(parts, b[i])
+			} else {
+				parts = append(parts, strings.Repeat(" ", sourceWidth+stateWidth+3))
+			}
+		}
+		lines[i] = strings.TrimRight(strings.Join(parts, " "), " ")
+	}
+	return lines
+}
+
+// pad pads or truncates s to width
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+// truncate cuts s down to width
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
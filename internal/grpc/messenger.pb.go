@@ -31,7 +31,8 @@ type LoadMessage struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Program string `protobuf:"bytes,1,opt,name=program,proto3" json:"program,omitempty"`
+	Program string  `protobuf:"bytes,1,opt,name=program,proto3" json:"program,omitempty"`
+	Lines   []int32 `protobuf:"varint,2,rep,packed,name=lines,proto3" json:"lines,omitempty"`
 }
 
 func (x *LoadMessage) Reset() {
@@ -73,86 +74,1419 @@ func (x *LoadMessage) GetProgram() string {
 	return ""
 }
 
+func (x *LoadMessage) GetLines() []int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type BytecodeMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Bytecode []byte `protobuf:"bytes,1,opt,name=bytecode,proto3" json:"bytecode,omitempty"`
+}
+
+func (x *BytecodeMessage) Reset() {
+	*x = BytecodeMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BytecodeMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BytecodeMessage) ProtoMessage() {}
+
+func (x *BytecodeMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BytecodeMessage.ProtoReflect.Descriptor instead.
+func (*BytecodeMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BytecodeMessage) GetBytecode() []byte {
+	if x != nil {
+		return x.Bytecode
+	}
+	return nil
+}
+
 type SendMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value    int32 `protobuf:"zigzag32,1,opt,name=value,proto3" json:"value,omitempty"`
-	Register int32 `protobuf:"varint,2,opt,name=register,proto3" json:"register,omitempty"`
+	Value    int64  `protobuf:"zigzag64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Register int32  `protobuf:"varint,2,opt,name=register,proto3" json:"register,omitempty"`
+	Any      bool   `protobuf:"varint,3,opt,name=any,proto3" json:"any,omitempty"`
+	Cycle    int64  `protobuf:"varint,4,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Session  string `protobuf:"bytes,5,opt,name=session,proto3" json:"session,omitempty"`
+	Seq      uint64 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+}
+
+func (x *SendMessage) Reset() {
+	*x = SendMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SendMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessage) ProtoMessage() {}
+
+func (x *SendMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessage.ProtoReflect.Descriptor instead.
+func (*SendMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SendMessage) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SendMessage) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *SendMessage) GetAny() bool {
+	if x != nil {
+		return x.Any
+	}
+	return false
+}
+
+func (x *SendMessage) GetCycle() int64 {
+	if x != nil {
+		return x.Cycle
+	}
+	return 0
+}
+
+func (x *SendMessage) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *SendMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type PortMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Register int32 `protobuf:"varint,1,opt,name=register,proto3" json:"register,omitempty"`
+}
+
+func (x *PortMessage) Reset() {
+	*x = PortMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PortMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortMessage) ProtoMessage() {}
+
+func (x *PortMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortMessage.ProtoReflect.Descriptor instead.
+func (*PortMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PortMessage) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+type RegisterWrite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Session  string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Seq      uint64 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Register int32  `protobuf:"varint,3,opt,name=register,proto3" json:"register,omitempty"`
+	Any      bool   `protobuf:"varint,4,opt,name=any,proto3" json:"any,omitempty"`
+	Value    int64  `protobuf:"zigzag64,5,opt,name=value,proto3" json:"value,omitempty"`
+	Want     bool   `protobuf:"varint,6,opt,name=want,proto3" json:"want,omitempty"`
+}
+
+func (x *RegisterWrite) Reset() {
+	*x = RegisterWrite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterWrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWrite) ProtoMessage() {}
+
+func (x *RegisterWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWrite.ProtoReflect.Descriptor instead.
+func (*RegisterWrite) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RegisterWrite) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *RegisterWrite) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *RegisterWrite) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *RegisterWrite) GetAny() bool {
+	if x != nil {
+		return x.Any
+	}
+	return false
+}
+
+func (x *RegisterWrite) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RegisterWrite) GetWant() bool {
+	if x != nil {
+		return x.Want
+	}
+	return false
+}
+
+type RegisterCredit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Register int32  `protobuf:"varint,1,opt,name=register,proto3" json:"register,omitempty"`
+	Any      bool   `protobuf:"varint,2,opt,name=any,proto3" json:"any,omitempty"`
+	Credit   bool   `protobuf:"varint,3,opt,name=credit,proto3" json:"credit,omitempty"`
+	Ack      uint64 `protobuf:"varint,4,opt,name=ack,proto3" json:"ack,omitempty"`
+}
+
+func (x *RegisterCredit) Reset() {
+	*x = RegisterCredit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterCredit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterCredit) ProtoMessage() {}
+
+func (x *RegisterCredit) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterCredit.ProtoReflect.Descriptor instead.
+func (*RegisterCredit) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RegisterCredit) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *RegisterCredit) GetAny() bool {
+	if x != nil {
+		return x.Any
+	}
+	return false
+}
+
+func (x *RegisterCredit) GetCredit() bool {
+	if x != nil {
+		return x.Credit
+	}
+	return false
+}
+
+func (x *RegisterCredit) GetAck() uint64 {
+	if x != nil {
+		return x.Ack
+	}
+	return 0
+}
+
+type ValueMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value   int64  `protobuf:"zigzag64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Cycle   int64  `protobuf:"varint,2,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Session string `protobuf:"bytes,3,opt,name=session,proto3" json:"session,omitempty"`
+	Seq     uint64 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+}
+
+func (x *ValueMessage) Reset() {
+	*x = ValueMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValueMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueMessage) ProtoMessage() {}
+
+func (x *ValueMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueMessage.ProtoReflect.Descriptor instead.
+func (*ValueMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValueMessage) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *ValueMessage) GetCycle() int64 {
+	if x != nil {
+		return x.Cycle
+	}
+	return 0
+}
+
+func (x *ValueMessage) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *ValueMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type CycleMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cycle   int64  `protobuf:"varint,1,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Session string `protobuf:"bytes,2,opt,name=session,proto3" json:"session,omitempty"`
+	Seq     uint64 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+}
+
+func (x *CycleMessage) Reset() {
+	*x = CycleMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CycleMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CycleMessage) ProtoMessage() {}
+
+func (x *CycleMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CycleMessage.ProtoReflect.Descriptor instead.
+func (*CycleMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CycleMessage) GetCycle() int64 {
+	if x != nil {
+		return x.Cycle
+	}
+	return 0
+}
+
+func (x *CycleMessage) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *CycleMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type TickReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Blocked bool   `protobuf:"varint,1,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	Fault   string `protobuf:"bytes,2,opt,name=fault,proto3" json:"fault,omitempty"`
+}
+
+func (x *TickReply) Reset() {
+	*x = TickReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TickReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TickReply) ProtoMessage() {}
+
+func (x *TickReply) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TickReply.ProtoReflect.Descriptor instead.
+func (*TickReply) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TickReply) GetBlocked() bool {
+	if x != nil {
+		return x.Blocked
+	}
+	return false
+}
+
+func (x *TickReply) GetFault() string {
+	if x != nil {
+		return x.Fault
+	}
+	return ""
+}
+
+type WaitMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Blocked  bool   `protobuf:"varint,1,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	Kind     int32  `protobuf:"varint,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Node     string `protobuf:"bytes,3,opt,name=node,proto3" json:"node,omitempty"`
+	Register int32  `protobuf:"varint,4,opt,name=register,proto3" json:"register,omitempty"`
+	Duration int64  `protobuf:"varint,5,opt,name=duration,proto3" json:"duration,omitempty"`
+}
+
+func (x *WaitMessage) Reset() {
+	*x = WaitMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WaitMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitMessage) ProtoMessage() {}
+
+func (x *WaitMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitMessage.ProtoReflect.Descriptor instead.
+func (*WaitMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WaitMessage) GetBlocked() bool {
+	if x != nil {
+		return x.Blocked
+	}
+	return false
+}
+
+func (x *WaitMessage) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *WaitMessage) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *WaitMessage) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *WaitMessage) GetDuration() int64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+type OpcodeCount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Op    string `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Count int64  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *OpcodeCount) Reset() {
+	*x = OpcodeCount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OpcodeCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpcodeCount) ProtoMessage() {}
+
+func (x *OpcodeCount) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpcodeCount.ProtoReflect.Descriptor instead.
+func (*OpcodeCount) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OpcodeCount) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *OpcodeCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type StatsMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instructions int64          `protobuf:"varint,1,opt,name=instructions,proto3" json:"instructions,omitempty"`
+	Cycles       int64          `protobuf:"varint,2,opt,name=cycles,proto3" json:"cycles,omitempty"`
+	Blocked      int64          `protobuf:"varint,3,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	WaitTime     int64          `protobuf:"varint,4,opt,name=wait_time,json=waitTime,proto3" json:"wait_time,omitempty"`
+	Opcodes      []*OpcodeCount `protobuf:"bytes,5,rep,name=opcodes,proto3" json:"opcodes,omitempty"`
+	Size         int32          `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	Used         bool           `protobuf:"varint,7,opt,name=used,proto3" json:"used,omitempty"`
+}
+
+func (x *StatsMessage) Reset() {
+	*x = StatsMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatsMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsMessage) ProtoMessage() {}
+
+func (x *StatsMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsMessage.ProtoReflect.Descriptor instead.
+func (*StatsMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatsMessage) GetInstructions() int64 {
+	if x != nil {
+		return x.Instructions
+	}
+	return 0
+}
+
+func (x *StatsMessage) GetCycles() int64 {
+	if x != nil {
+		return x.Cycles
+	}
+	return 0
+}
+
+func (x *StatsMessage) GetBlocked() int64 {
+	if x != nil {
+		return x.Blocked
+	}
+	return 0
+}
+
+func (x *StatsMessage) GetWaitTime() int64 {
+	if x != nil {
+		return x.WaitTime
+	}
+	return 0
+}
+
+func (x *StatsMessage) GetOpcodes() []*OpcodeCount {
+	if x != nil {
+		return x.Opcodes
+	}
+	return nil
+}
+
+func (x *StatsMessage) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *StatsMessage) GetUsed() bool {
+	if x != nil {
+		return x.Used
+	}
+	return false
+}
+
+type FaultMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Faulted bool   `protobuf:"varint,1,opt,name=faulted,proto3" json:"faulted,omitempty"`
+	Reason  string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Line    int32  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+}
+
+func (x *FaultMessage) Reset() {
+	*x = FaultMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FaultMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FaultMessage) ProtoMessage() {}
+
+func (x *FaultMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FaultMessage.ProtoReflect.Descriptor instead.
+func (*FaultMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FaultMessage) GetFaulted() bool {
+	if x != nil {
+		return x.Faulted
+	}
+	return false
+}
+
+func (x *FaultMessage) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *FaultMessage) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+type NodeStateMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	State string `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+}
+
+func (x *NodeStateMessage) Reset() {
+	*x = NodeStateMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeStateMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeStateMessage) ProtoMessage() {}
+
+func (x *NodeStateMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeStateMessage.ProtoReflect.Descriptor instead.
+func (*NodeStateMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NodeStateMessage) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type StackMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Values []int64 `protobuf:"zigzag64,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+}
+
+func (x *StackMessage) Reset() {
+	*x = StackMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StackMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StackMessage) ProtoMessage() {}
+
+func (x *StackMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StackMessage.ProtoReflect.Descriptor instead.
+func (*StackMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StackMessage) GetValues() []int64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type StepMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count int32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *StepMessage) Reset() {
+	*x = StepMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StepMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepMessage) ProtoMessage() {}
+
+func (x *StepMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepMessage.ProtoReflect.Descriptor instead.
+func (*StepMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StepMessage) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type BreakpointMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Line int32 `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+}
+
+func (x *BreakpointMessage) Reset() {
+	*x = BreakpointMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BreakpointMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakpointMessage) ProtoMessage() {}
+
+func (x *BreakpointMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakpointMessage.ProtoReflect.Descriptor instead.
+func (*BreakpointMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BreakpointMessage) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+type WatchMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Condition string `protobuf:"bytes,1,opt,name=condition,proto3" json:"condition,omitempty"`
+}
+
+func (x *WatchMessage) Reset() {
+	*x = WatchMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMessage) ProtoMessage() {}
+
+func (x *WatchMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMessage.ProtoReflect.Descriptor instead.
+func (*WatchMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchMessage) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+type RegisterState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value int64  `protobuf:"zigzag64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Full  bool   `protobuf:"varint,3,opt,name=full,proto3" json:"full,omitempty"`
+}
+
+func (x *RegisterState) Reset() {
+	*x = RegisterState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterState) ProtoMessage() {}
+
+func (x *RegisterState) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterState.ProtoReflect.Descriptor instead.
+func (*RegisterState) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RegisterState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterState) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RegisterState) GetFull() bool {
+	if x != nil {
+		return x.Full
+	}
+	return false
+}
+
+type DebugState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ptr         int32            `protobuf:"varint,1,opt,name=ptr,proto3" json:"ptr,omitempty"`
+	Line        int32            `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Instruction string           `protobuf:"bytes,3,opt,name=instruction,proto3" json:"instruction,omitempty"`
+	Acc         int64            `protobuf:"zigzag64,4,opt,name=acc,proto3" json:"acc,omitempty"`
+	Bak         int64            `protobuf:"zigzag64,5,opt,name=bak,proto3" json:"bak,omitempty"`
+	Registers   []*RegisterState `protobuf:"bytes,6,rep,name=registers,proto3" json:"registers,omitempty"`
+	Running     bool             `protobuf:"varint,7,opt,name=running,proto3" json:"running,omitempty"`
+	Reason      string           `protobuf:"bytes,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	Breakpoints []int32          `protobuf:"varint,9,rep,packed,name=breakpoints,proto3" json:"breakpoints,omitempty"`
+	Watches     []string         `protobuf:"bytes,10,rep,name=watches,proto3" json:"watches,omitempty"`
+	State       string           `protobuf:"bytes,11,opt,name=state,proto3" json:"state,omitempty"`
+}
+
+func (x *DebugState) Reset() {
+	*x = DebugState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DebugState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebugState) ProtoMessage() {}
+
+func (x *DebugState) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebugState.ProtoReflect.Descriptor instead.
+func (*DebugState) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DebugState) GetPtr() int32 {
+	if x != nil {
+		return x.Ptr
+	}
+	return 0
+}
+
+func (x *DebugState) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *DebugState) GetInstruction() string {
+	if x != nil {
+		return x.Instruction
+	}
+	return ""
+}
+
+func (x *DebugState) GetAcc() int64 {
+	if x != nil {
+		return x.Acc
+	}
+	return 0
+}
+
+func (x *DebugState) GetBak() int64 {
+	if x != nil {
+		return x.Bak
+	}
+	return 0
+}
+
+func (x *DebugState) GetRegisters() []*RegisterState {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
+func (x *DebugState) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *DebugState) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DebugState) GetBreakpoints() []int32 {
+	if x != nil {
+		return x.Breakpoints
+	}
+	return nil
+}
+
+func (x *DebugState) GetWatches() []string {
+	if x != nil {
+		return x.Watches
+	}
+	return nil
+}
+
+func (x *DebugState) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type TraceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Step      int64    `protobuf:"varint,1,opt,name=step,proto3" json:"step,omitempty"`
+	Cycle     int64    `protobuf:"varint,2,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Time      int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Duration  int64    `protobuf:"varint,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Ptr       int32    `protobuf:"varint,5,opt,name=ptr,proto3" json:"ptr,omitempty"`
+	Op        string   `protobuf:"bytes,6,opt,name=op,proto3" json:"op,omitempty"`
+	Operands  []string `protobuf:"bytes,7,rep,name=operands,proto3" json:"operands,omitempty"`
+	Acc       int64    `protobuf:"zigzag64,8,opt,name=acc,proto3" json:"acc,omitempty"`
+	Bak       int64    `protobuf:"zigzag64,9,opt,name=bak,proto3" json:"bak,omitempty"`
+	BlockedOn string   `protobuf:"bytes,10,opt,name=blocked_on,json=blockedOn,proto3" json:"blocked_on,omitempty"`
+	Done      bool     `protobuf:"varint,11,opt,name=done,proto3" json:"done,omitempty"`
+	Line      int32    `protobuf:"varint,12,opt,name=line,proto3" json:"line,omitempty"`
+}
+
+func (x *TraceEvent) Reset() {
+	*x = TraceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_grpc_messenger_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceEvent) ProtoMessage() {}
+
+func (x *TraceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceEvent.ProtoReflect.Descriptor instead.
+func (*TraceEvent) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TraceEvent) GetStep() int64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetCycle() int64 {
+	if x != nil {
+		return x.Cycle
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetDuration() int64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetPtr() int32 {
+	if x != nil {
+		return x.Ptr
+	}
+	return 0
 }
 
-func (x *SendMessage) Reset() {
-	*x = SendMessage{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_grpc_messenger_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *TraceEvent) GetOp() string {
+	if x != nil {
+		return x.Op
 	}
+	return ""
 }
 
-func (x *SendMessage) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *TraceEvent) GetOperands() []string {
+	if x != nil {
+		return x.Operands
+	}
+	return nil
 }
 
-func (*SendMessage) ProtoMessage() {}
+func (x *TraceEvent) GetAcc() int64 {
+	if x != nil {
+		return x.Acc
+	}
+	return 0
+}
 
-func (x *SendMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_grpc_messenger_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *TraceEvent) GetBak() int64 {
+	if x != nil {
+		return x.Bak
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use SendMessage.ProtoReflect.Descriptor instead.
-func (*SendMessage) Descriptor() ([]byte, []int) {
-	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{1}
+func (x *TraceEvent) GetBlockedOn() string {
+	if x != nil {
+		return x.BlockedOn
+	}
+	return ""
 }
 
-func (x *SendMessage) GetValue() int32 {
+func (x *TraceEvent) GetDone() bool {
 	if x != nil {
-		return x.Value
+		return x.Done
 	}
-	return 0
+	return false
 }
 
-func (x *SendMessage) GetRegister() int32 {
+func (x *TraceEvent) GetLine() int32 {
 	if x != nil {
-		return x.Register
+		return x.Line
 	}
 	return 0
 }
 
-type ValueMessage struct {
+type TraceMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value int32 `protobuf:"zigzag32,1,opt,name=value,proto3" json:"value,omitempty"`
+	Events  []*TraceEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	Dropped int64         `protobuf:"varint,2,opt,name=dropped,proto3" json:"dropped,omitempty"`
 }
 
-func (x *ValueMessage) Reset() {
-	*x = ValueMessage{}
+func (x *TraceMessage) Reset() {
+	*x = TraceMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_grpc_messenger_proto_msgTypes[2]
+		mi := &file_internal_grpc_messenger_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ValueMessage) String() string {
+func (x *TraceMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ValueMessage) ProtoMessage() {}
+func (*TraceMessage) ProtoMessage() {}
 
-func (x *ValueMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_grpc_messenger_proto_msgTypes[2]
+func (x *TraceMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_messenger_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -163,14 +1497,21 @@ func (x *ValueMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ValueMessage.ProtoReflect.Descriptor instead.
-func (*ValueMessage) Descriptor() ([]byte, []int) {
-	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use TraceMessage.ProtoReflect.Descriptor instead.
+func (*TraceMessage) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_messenger_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *ValueMessage) GetValue() int32 {
+func (x *TraceMessage) GetEvents() []*TraceEvent {
 	if x != nil {
-		return x.Value
+		return x.Events
+	}
+	return nil
+}
+
+func (x *TraceMessage) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
 	}
 	return 0
 }
@@ -182,64 +1523,266 @@ var file_internal_grpc_messenger_proto_rawDesc = []byte{
 	0x6d, 0x65, 0x73, 0x73, 0x65, 0x6e, 0x67, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
 	0x04, 0x67, 0x72, 0x70, 0x63, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0x27, 0x0a, 0x0b, 0x4c, 0x6f, 0x61, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x74, 0x6f, 0x22, 0x3d, 0x0a, 0x0b, 0x4c, 0x6f, 0x61, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
 	0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x22, 0x3f, 0x0a, 0x0b, 0x53,
-	0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x11, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x22, 0x24, 0x0a, 0x0c,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x11, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x32, 0x7e, 0x0a, 0x06, 0x4d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x12, 0x38, 0x0a, 0x08,
-	0x47, 0x65, 0x74, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x0a, 0x53, 0x65, 0x6e, 0x64, 0x4f, 0x75,
-	0x74, 0x70, 0x75, 0x74, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x00, 0x32, 0xa2, 0x02, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x37,
-	0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x69, 0x6e, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x65,
+	0x73, 0x22, 0x2d, 0x0a, 0x0f, 0x42, 0x79, 0x74, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x79, 0x74, 0x65, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x62, 0x79, 0x74, 0x65, 0x63, 0x6f, 0x64, 0x65,
+	0x22, 0x93, 0x01, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x12, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x6e, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x03, 0x61, 0x6e, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x29, 0x0a, 0x0b, 0x50, 0x6f, 0x72, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x22, 0x93, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x61,
+	0x6e, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x61, 0x6e, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x12, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x61, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x77, 0x61, 0x6e, 0x74, 0x22, 0x68, 0x0a, 0x0e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x6e, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x03, 0x61, 0x6e, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x61, 0x63,
+	0x6b, 0x22, 0x66, 0x0a, 0x0c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x12,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x50, 0x0a, 0x0c, 0x43, 0x79, 0x63,
+	0x6c, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x79, 0x63,
+	0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x3b, 0x0a, 0x09, 0x54,
+	0x69, 0x63, 0x6b, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x22, 0x87, 0x01, 0x0a, 0x0b, 0x57, 0x61, 0x69,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x33, 0x0a, 0x0b, 0x4f, 0x70, 0x63, 0x6f, 0x64, 0x65, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f,
+	0x70, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xd6, 0x01, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e, 0x73, 0x74,
+	0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c,
+	0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06,
+	0x63, 0x79, 0x63, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x79,
+	0x63, 0x6c, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x77, 0x61, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x77, 0x61, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x2b, 0x0a, 0x07, 0x6f,
+	0x70, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x4f, 0x70, 0x63, 0x6f, 0x64, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52,
+	0x07, 0x6f, 0x70, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x75, 0x73, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x75, 0x73, 0x65, 0x64,
+	0x22, 0x54, 0x0a, 0x0c, 0x46, 0x61, 0x75, 0x6c, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x28, 0x0a, 0x10, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x22, 0x26, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x63, 0x6b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x12,
+	0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0x23, 0x0a, 0x0b, 0x53, 0x74, 0x65, 0x70,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x27, 0x0a,
+	0x11, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x2c, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x4d, 0x0a, 0x0d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x12, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x66, 0x75, 0x6c, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x66,
+	0x75, 0x6c, 0x6c, 0x22, 0xaf, 0x02, 0x0a, 0x0a, 0x44, 0x65, 0x62, 0x75, 0x67, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x74, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x03, 0x70, 0x74, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6e, 0x73, 0x74,
+	0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69,
+	0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x63,
+	0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x12, 0x52, 0x03, 0x61, 0x63, 0x63, 0x12, 0x10, 0x0a, 0x03,
+	0x62, 0x61, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x12, 0x52, 0x03, 0x62, 0x61, 0x6b, 0x12, 0x31,
+	0x0a, 0x09, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x13, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x09, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0b, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73,
+	0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x8f, 0x02, 0x0a, 0x0a, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x65, 0x70, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x73, 0x74, 0x65, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x79, 0x63, 0x6c,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69,
+	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10,
+	0x0a, 0x03, 0x70, 0x74, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x74, 0x72,
+	0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f, 0x70,
+	0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64, 0x73, 0x18, 0x07, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64, 0x73, 0x12, 0x10, 0x0a, 0x03,
+	0x61, 0x63, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x12, 0x52, 0x03, 0x61, 0x63, 0x63, 0x12, 0x10,
+	0x0a, 0x03, 0x62, 0x61, 0x6b, 0x18, 0x09, 0x20, 0x01, 0x28, 0x12, 0x52, 0x03, 0x62, 0x61, 0x6b,
+	0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x4f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64,
+	0x6f, 0x6e, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x52, 0x0a, 0x0c, 0x54, 0x72, 0x61, 0x63, 0x65,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x28, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x54,
+	0x72, 0x61, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x32, 0x7a, 0x0a, 0x06, 0x4d,
+	0x61, 0x73, 0x74, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x70, 0x75,
+	0x74, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x79, 0x63, 0x6c, 0x65, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x0a, 0x53,
+	0x65, 0x6e, 0x64, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x32, 0xb2, 0x06, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x67,
+	0x72, 0x61, 0x6d, 0x12, 0x37, 0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05,
+	0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e,
 	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x50, 0x61, 0x75, 0x73, 0x65,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74,
 	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
 	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
 	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a,
-	0x04, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x11, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x6f, 0x61,
-	0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x00, 0x12, 0x33, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x11, 0x2e, 0x67, 0x72, 0x70,
-	0x63, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e,
+	0x22, 0x00, 0x12, 0x33, 0x0a, 0x04, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x11, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e,
 	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x32, 0xa1, 0x02, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x63,
-	0x6b, 0x12, 0x37, 0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x0c, 0x4c, 0x6f, 0x61, 0x64, 0x42,
+	0x79, 0x74, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x15, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42,
+	0x79, 0x74, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x50,
+	0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x11,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x22, 0x00, 0x12, 0x2e, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x11, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x11,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x72, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x07, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x13,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x1a, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12,
+	0x2d, 0x0a, 0x04, 0x54, 0x69, 0x63, 0x6b, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43,
+	0x79, 0x63, 0x6c, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0f, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x54, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x36,
+	0x0a, 0x07, 0x47, 0x65, 0x74, 0x57, 0x61, 0x69, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x1a, 0x11, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x57, 0x61, 0x69, 0x74, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00,
+	0x12, 0x38, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x46, 0x61, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x46, 0x61, 0x75, 0x6c,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x0a, 0x43, 0x6c,
+	0x65, 0x61, 0x72, 0x46, 0x61, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
 	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
 	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x50, 0x61,
-	0x75, 0x73, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c, 0x47, 0x65,
+	0x74, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x32, 0xe6, 0x03, 0x0a,
+	0x05, 0x44, 0x65, 0x62, 0x75, 0x67, 0x12, 0x2d, 0x0a, 0x04, 0x53, 0x74, 0x65, 0x70, 0x12, 0x11,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x65, 0x70, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x1a, 0x10, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x44, 0x65, 0x62, 0x75, 0x67, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x42, 0x72, 0x65, 0x61,
+	0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72,
+	0x65, 0x61, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x0f, 0x43, 0x6c, 0x65,
+	0x61, 0x72, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x17, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12,
+	0x38, 0x0a, 0x08, 0x53, 0x65, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x12, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x0a, 0x43, 0x6c, 0x65,
+	0x61, 0x72, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
 	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x16,
+	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x08, 0x43, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x75,
+	0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x00, 0x12, 0x36, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x10, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x44,
+	0x65, 0x62, 0x75, 0x67, 0x53, 0x74, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x08, 0x47,
+	0x65, 0x74, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
+	0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x22, 0x00, 0x32, 0x99, 0x03, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x63, 0x6b, 0x12,
+	0x37, 0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16,
 	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x34, 0x0a, 0x04, 0x50, 0x75, 0x73, 0x68, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x50, 0x61, 0x75, 0x73,
+	0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x16, 0x2e, 0x67,
 	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x03, 0x50, 0x6f, 0x70, 0x12, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61, 0x6c,
-	0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x42, 0x2c, 0x5a, 0x2a, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x61, 0x73, 0x6d, 0x61, 0x61,
-	0x2f, 0x6d, 0x69, 0x73, 0x61, 0x6b, 0x61, 0x2d, 0x6e, 0x65, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65,
-	0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x34,
+	0x0a, 0x04, 0x50, 0x75, 0x73, 0x68, 0x12, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x00, 0x12, 0x2f, 0x0a, 0x03, 0x50, 0x6f, 0x70, 0x12, 0x12, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x43, 0x79, 0x63, 0x6c, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a,
+	0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x63,
+	0x6b, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x53, 0x74, 0x61, 0x63, 0x6b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12,
+	0x40, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x4e,
+	0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22,
+	0x00, 0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6a, 0x61, 0x73, 0x6d, 0x61, 0x61, 0x2f, 0x6d, 0x69, 0x73, 0x61, 0x6b, 0x61, 0x2d, 0x6e, 0x65,
+	0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -254,43 +1797,103 @@ func file_internal_grpc_messenger_proto_rawDescGZIP() []byte {
 	return file_internal_grpc_messenger_proto_rawDescData
 }
 
-var file_internal_grpc_messenger_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_internal_grpc_messenger_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_internal_grpc_messenger_proto_goTypes = []interface{}{
-	(*LoadMessage)(nil),  // 0: grpc.LoadMessage
-	(*SendMessage)(nil),  // 1: grpc.SendMessage
-	(*ValueMessage)(nil), // 2: grpc.ValueMessage
-	(*empty.Empty)(nil),  // 3: google.protobuf.Empty
+	(*LoadMessage)(nil),       // 0: grpc.LoadMessage
+	(*BytecodeMessage)(nil),   // 1: grpc.BytecodeMessage
+	(*SendMessage)(nil),       // 2: grpc.SendMessage
+	(*PortMessage)(nil),       // 3: grpc.PortMessage
+	(*RegisterWrite)(nil),     // 4: grpc.RegisterWrite
+	(*RegisterCredit)(nil),    // 5: grpc.RegisterCredit
+	(*ValueMessage)(nil),      // 6: grpc.ValueMessage
+	(*CycleMessage)(nil),      // 7: grpc.CycleMessage
+	(*TickReply)(nil),         // 8: grpc.TickReply
+	(*WaitMessage)(nil),       // 9: grpc.WaitMessage
+	(*OpcodeCount)(nil),       // 10: grpc.OpcodeCount
+	(*StatsMessage)(nil),      // 11: grpc.StatsMessage
+	(*FaultMessage)(nil),      // 12: grpc.FaultMessage
+	(*NodeStateMessage)(nil),  // 13: grpc.NodeStateMessage
+	(*StackMessage)(nil),      // 14: grpc.StackMessage
+	(*StepMessage)(nil),       // 15: grpc.StepMessage
+	(*BreakpointMessage)(nil), // 16: grpc.BreakpointMessage
+	(*WatchMessage)(nil),      // 17: grpc.WatchMessage
+	(*RegisterState)(nil),     // 18: grpc.RegisterState
+	(*DebugState)(nil),        // 19: grpc.DebugState
+	(*TraceEvent)(nil),        // 20: grpc.TraceEvent
+	(*TraceMessage)(nil),      // 21: grpc.TraceMessage
+	(*empty.Empty)(nil),       // 22: google.protobuf.Empty
 }
 var file_internal_grpc_messenger_proto_depIdxs = []int32{
-	3,  // 0: grpc.Master.GetInput:input_type -> google.protobuf.Empty
-	2,  // 1: grpc.Master.SendOutput:input_type -> grpc.ValueMessage
-	3,  // 2: grpc.Program.Run:input_type -> google.protobuf.Empty
-	3,  // 3: grpc.Program.Pause:input_type -> google.protobuf.Empty
-	3,  // 4: grpc.Program.Reset:input_type -> google.protobuf.Empty
-	0,  // 5: grpc.Program.Load:input_type -> grpc.LoadMessage
-	1,  // 6: grpc.Program.Send:input_type -> grpc.SendMessage
-	3,  // 7: grpc.Stack.Run:input_type -> google.protobuf.Empty
-	3,  // 8: grpc.Stack.Pause:input_type -> google.protobuf.Empty
-	3,  // 9: grpc.Stack.Reset:input_type -> google.protobuf.Empty
-	2,  // 10: grpc.Stack.Push:input_type -> grpc.ValueMessage
-	3,  // 11: grpc.Stack.Pop:input_type -> google.protobuf.Empty
-	2,  // 12: grpc.Master.GetInput:output_type -> grpc.ValueMessage
-	3,  // 13: grpc.Master.SendOutput:output_type -> google.protobuf.Empty
-	3,  // 14: grpc.Program.Run:output_type -> google.protobuf.Empty
-	3,  // 15: grpc.Program.Pause:output_type -> google.protobuf.Empty
-	3,  // 16: grpc.Program.Reset:output_type -> google.protobuf.Empty
-	3,  // 17: grpc.Program.Load:output_type -> google.protobuf.Empty
-	3,  // 18: grpc.Program.Send:output_type -> google.protobuf.Empty
-	3,  // 19: grpc.Stack.Run:output_type -> google.protobuf.Empty
-	3,  // 20: grpc.Stack.Pause:output_type -> google.protobuf.Empty
-	3,  // 21: grpc.Stack.Reset:output_type -> google.protobuf.Empty
-	3,  // 22: grpc.Stack.Push:output_type -> google.protobuf.Empty
-	2,  // 23: grpc.Stack.Pop:output_type -> grpc.ValueMessage
-	12, // [12:24] is the sub-list for method output_type
-	0,  // [0:12] is the sub-list for method input_type
-	0,  // [0:0] is the sub-list for extension type_name
-	0,  // [0:0] is the sub-list for extension extendee
-	0,  // [0:0] is the sub-list for field type_name
+	10, // 0: grpc.StatsMessage.opcodes:type_name -> grpc.OpcodeCount
+	18, // 1: grpc.DebugState.registers:type_name -> grpc.RegisterState
+	20, // 2: grpc.TraceMessage.events:type_name -> grpc.TraceEvent
+	7,  // 3: grpc.Master.GetInput:input_type -> grpc.CycleMessage
+	6,  // 4: grpc.Master.SendOutput:input_type -> grpc.ValueMessage
+	22, // 5: grpc.Program.Run:input_type -> google.protobuf.Empty
+	22, // 6: grpc.Program.Pause:input_type -> google.protobuf.Empty
+	22, // 7: grpc.Program.Reset:input_type -> google.protobuf.Empty
+	0,  // 8: grpc.Program.Load:input_type -> grpc.LoadMessage
+	1,  // 9: grpc.Program.LoadBytecode:input_type -> grpc.BytecodeMessage
+	22, // 10: grpc.Program.GetProgram:input_type -> google.protobuf.Empty
+	2,  // 11: grpc.Program.Send:input_type -> grpc.SendMessage
+	4,  // 12: grpc.Program.Connect:input_type -> grpc.RegisterWrite
+	7,  // 13: grpc.Program.Tick:input_type -> grpc.CycleMessage
+	22, // 14: grpc.Program.GetWait:input_type -> google.protobuf.Empty
+	22, // 15: grpc.Program.GetStats:input_type -> google.protobuf.Empty
+	22, // 16: grpc.Program.GetFault:input_type -> google.protobuf.Empty
+	22, // 17: grpc.Program.ClearFault:input_type -> google.protobuf.Empty
+	22, // 18: grpc.Program.GetNodeState:input_type -> google.protobuf.Empty
+	15, // 19: grpc.Debug.Step:input_type -> grpc.StepMessage
+	16, // 20: grpc.Debug.SetBreakpoint:input_type -> grpc.BreakpointMessage
+	16, // 21: grpc.Debug.ClearBreakpoint:input_type -> grpc.BreakpointMessage
+	17, // 22: grpc.Debug.SetWatch:input_type -> grpc.WatchMessage
+	17, // 23: grpc.Debug.ClearWatch:input_type -> grpc.WatchMessage
+	22, // 24: grpc.Debug.Continue:input_type -> google.protobuf.Empty
+	22, // 25: grpc.Debug.GetState:input_type -> google.protobuf.Empty
+	22, // 26: grpc.Debug.GetTrace:input_type -> google.protobuf.Empty
+	22, // 27: grpc.Stack.Run:input_type -> google.protobuf.Empty
+	22, // 28: grpc.Stack.Pause:input_type -> google.protobuf.Empty
+	22, // 29: grpc.Stack.Reset:input_type -> google.protobuf.Empty
+	6,  // 30: grpc.Stack.Push:input_type -> grpc.ValueMessage
+	7,  // 31: grpc.Stack.Pop:input_type -> grpc.CycleMessage
+	22, // 32: grpc.Stack.GetStack:input_type -> google.protobuf.Empty
+	22, // 33: grpc.Stack.GetNodeState:input_type -> google.protobuf.Empty
+	6,  // 34: grpc.Master.GetInput:output_type -> grpc.ValueMessage
+	22, // 35: grpc.Master.SendOutput:output_type -> google.protobuf.Empty
+	22, // 36: grpc.Program.Run:output_type -> google.protobuf.Empty
+	22, // 37: grpc.Program.Pause:output_type -> google.protobuf.Empty
+	22, // 38: grpc.Program.Reset:output_type -> google.protobuf.Empty
+	22, // 39: grpc.Program.Load:output_type -> google.protobuf.Empty
+	22, // 40: grpc.Program.LoadBytecode:output_type -> google.protobuf.Empty
+	0,  // 41: grpc.Program.GetProgram:output_type -> grpc.LoadMessage
+	3,  // 42: grpc.Program.Send:output_type -> grpc.PortMessage
+	5,  // 43: grpc.Program.Connect:output_type -> grpc.RegisterCredit
+	8,  // 44: grpc.Program.Tick:output_type -> grpc.TickReply
+	9,  // 45: grpc.Program.GetWait:output_type -> grpc.WaitMessage
+	11, // 46: grpc.Program.GetStats:output_type -> grpc.StatsMessage
+	12, // 47: grpc.Program.GetFault:output_type -> grpc.FaultMessage
+	22, // 48: grpc.Program.ClearFault:output_type -> google.protobuf.Empty
+	13, // 49: grpc.Program.GetNodeState:output_type -> grpc.NodeStateMessage
+	19, // 50: grpc.Debug.Step:output_type -> grpc.DebugState
+	22, // 51: grpc.Debug.SetBreakpoint:output_type -> google.protobuf.Empty
+	22, // 52: grpc.Debug.ClearBreakpoint:output_type -> google.protobuf.Empty
+	22, // 53: grpc.Debug.SetWatch:output_type -> google.protobuf.Empty
+	22, // 54: grpc.Debug.ClearWatch:output_type -> google.protobuf.Empty
+	22, // 55: grpc.Debug.Continue:output_type -> google.protobuf.Empty
+	19, // 56: grpc.Debug.GetState:output_type -> grpc.DebugState
+	21, // 57: grpc.Debug.GetTrace:output_type -> grpc.TraceMessage
+	22, // 58: grpc.Stack.Run:output_type -> google.protobuf.Empty
+	22, // 59: grpc.Stack.Pause:output_type -> google.protobuf.Empty
+	22, // 60: grpc.Stack.Reset:output_type -> google.protobuf.Empty
+	22, // 61: grpc.Stack.Push:output_type -> google.protobuf.Empty
+	6,  // 62: grpc.Stack.Pop:output_type -> grpc.ValueMessage
+	14, // 63: grpc.Stack.GetStack:output_type -> grpc.StackMessage
+	13, // 64: grpc.Stack.GetNodeState:output_type -> grpc.NodeStateMessage
+	34, // [34:65] is the sub-list for method output_type
+	3,  // [3:34] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_internal_grpc_messenger_proto_init() }
@@ -312,7 +1915,7 @@ func file_internal_grpc_messenger_proto_init() {
 			}
 		}
 		file_internal_grpc_messenger_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SendMessage); i {
+			switch v := v.(*BytecodeMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -324,6 +1927,54 @@ func file_internal_grpc_messenger_proto_init() {
 			}
 		}
 		file_internal_grpc_messenger_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PortMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterWrite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterCredit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ValueMessage); i {
 			case 0:
 				return &v.state
@@ -335,6 +1986,186 @@ func file_internal_grpc_messenger_proto_init() {
 				return nil
 			}
 		}
+		file_internal_grpc_messenger_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CycleMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TickReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WaitMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OpcodeCount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatsMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FaultMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeStateMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StackMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StepMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BreakpointMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DebugState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraceEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_grpc_messenger_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraceMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -342,9 +2173,9 @@ func file_internal_grpc_messenger_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_internal_grpc_messenger_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   22,
 			NumExtensions: 0,
-			NumServices:   3,
+			NumServices:   4,
 		},
 		GoTypes:           file_internal_grpc_messenger_proto_goTypes,
 		DependencyIndexes: file_internal_grpc_messenger_proto_depIdxs,
@@ -18,7 +18,7 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MasterClient interface {
-	GetInput(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ValueMessage, error)
+	GetInput(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*ValueMessage, error)
 	SendOutput(ctx context.Context, in *ValueMessage, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
@@ -30,7 +30,7 @@ func NewMasterClient(cc grpc.ClientConnInterface) MasterClient {
 	return &masterClient{cc}
 }
 
-func (c *masterClient) GetInput(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ValueMessage, error) {
+func (c *masterClient) GetInput(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*ValueMessage, error) {
 	out := new(ValueMessage)
 	err := c.cc.Invoke(ctx, "/grpc.Master/GetInput", in, out, opts...)
 	if err != nil {
@@ -52,7 +52,7 @@ func (c *masterClient) SendOutput(ctx context.Context, in *ValueMessage, opts ..
 // All implementations must embed UnimplementedMasterServer
 // for forward compatibility
 type MasterServer interface {
-	GetInput(context.Context, *empty.Empty) (*ValueMessage, error)
+	GetInput(context.Context, *CycleMessage) (*ValueMessage, error)
 	SendOutput(context.Context, *ValueMessage) (*empty.Empty, error)
 	mustEmbedUnimplementedMasterServer()
 }
@@ -61,7 +61,7 @@ type MasterServer interface {
 type UnimplementedMasterServer struct {
 }
 
-func (UnimplementedMasterServer) GetInput(context.Context, *empty.Empty) (*ValueMessage, error) {
+func (UnimplementedMasterServer) GetInput(context.Context, *CycleMessage) (*ValueMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetInput not implemented")
 }
 func (UnimplementedMasterServer) SendOutput(context.Context, *ValueMessage) (*empty.Empty, error) {
@@ -81,7 +81,7 @@ func RegisterMasterServer(s grpc.ServiceRegistrar, srv MasterServer) {
 }
 
 func _Master_GetInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(CycleMessage)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -93,7 +93,7 @@ func _Master_GetInput_Handler(srv interface{}, ctx context.Context, dec func(int
 		FullMethod: "/grpc.Master/GetInput",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MasterServer).GetInput(ctx, req.(*empty.Empty))
+		return srv.(MasterServer).GetInput(ctx, req.(*CycleMessage))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -141,7 +141,16 @@ type ProgramClient interface {
 	Pause(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	Reset(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	Load(ctx context.Context, in *LoadMessage, opts ...grpc.CallOption) (*empty.Empty, error)
-	Send(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	LoadBytecode(ctx context.Context, in *BytecodeMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetProgram(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*LoadMessage, error)
+	Send(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*PortMessage, error)
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Program_ConnectClient, error)
+	Tick(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*TickReply, error)
+	GetWait(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*WaitMessage, error)
+	GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StatsMessage, error)
+	GetFault(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*FaultMessage, error)
+	ClearFault(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetNodeState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*NodeStateMessage, error)
 }
 
 type programClient struct {
@@ -188,8 +197,26 @@ func (c *programClient) Load(ctx context.Context, in *LoadMessage, opts ...grpc.
 	return out, nil
 }
 
-func (c *programClient) Send(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
+func (c *programClient) LoadBytecode(ctx context.Context, in *BytecodeMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Program/LoadBytecode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) GetProgram(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*LoadMessage, error) {
+	out := new(LoadMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Program/GetProgram", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) Send(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*PortMessage, error) {
+	out := new(PortMessage)
 	err := c.cc.Invoke(ctx, "/grpc.Program/Send", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -197,6 +224,91 @@ func (c *programClient) Send(ctx context.Context, in *SendMessage, opts ...grpc.
 	return out, nil
 }
 
+func (c *programClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Program_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Program_serviceDesc.Streams[0], "/grpc.Program/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &programConnectClient{stream}
+	return x, nil
+}
+
+type Program_ConnectClient interface {
+	Send(*RegisterWrite) error
+	Recv() (*RegisterCredit, error)
+	grpc.ClientStream
+}
+
+type programConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *programConnectClient) Send(m *RegisterWrite) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *programConnectClient) Recv() (*RegisterCredit, error) {
+	m := new(RegisterCredit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *programClient) Tick(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*TickReply, error) {
+	out := new(TickReply)
+	err := c.cc.Invoke(ctx, "/grpc.Program/Tick", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) GetWait(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*WaitMessage, error) {
+	out := new(WaitMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Program/GetWait", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StatsMessage, error) {
+	out := new(StatsMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Program/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) GetFault(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*FaultMessage, error) {
+	out := new(FaultMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Program/GetFault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) ClearFault(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Program/ClearFault", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *programClient) GetNodeState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*NodeStateMessage, error) {
+	out := new(NodeStateMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Program/GetNodeState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProgramServer is the server API for Program service.
 // All implementations must embed UnimplementedProgramServer
 // for forward compatibility
@@ -205,7 +317,16 @@ type ProgramServer interface {
 	Pause(context.Context, *empty.Empty) (*empty.Empty, error)
 	Reset(context.Context, *empty.Empty) (*empty.Empty, error)
 	Load(context.Context, *LoadMessage) (*empty.Empty, error)
-	Send(context.Context, *SendMessage) (*empty.Empty, error)
+	LoadBytecode(context.Context, *BytecodeMessage) (*empty.Empty, error)
+	GetProgram(context.Context, *empty.Empty) (*LoadMessage, error)
+	Send(context.Context, *SendMessage) (*PortMessage, error)
+	Connect(Program_ConnectServer) error
+	Tick(context.Context, *CycleMessage) (*TickReply, error)
+	GetWait(context.Context, *empty.Empty) (*WaitMessage, error)
+	GetStats(context.Context, *empty.Empty) (*StatsMessage, error)
+	GetFault(context.Context, *empty.Empty) (*FaultMessage, error)
+	ClearFault(context.Context, *empty.Empty) (*empty.Empty, error)
+	GetNodeState(context.Context, *empty.Empty) (*NodeStateMessage, error)
 	mustEmbedUnimplementedProgramServer()
 }
 
@@ -225,9 +346,36 @@ func (UnimplementedProgramServer) Reset(context.Context, *empty.Empty) (*empty.E
 func (UnimplementedProgramServer) Load(context.Context, *LoadMessage) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
 }
-func (UnimplementedProgramServer) Send(context.Context, *SendMessage) (*empty.Empty, error) {
+func (UnimplementedProgramServer) LoadBytecode(context.Context, *BytecodeMessage) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadBytecode not implemented")
+}
+func (UnimplementedProgramServer) GetProgram(context.Context, *empty.Empty) (*LoadMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProgram not implemented")
+}
+func (UnimplementedProgramServer) Send(context.Context, *SendMessage) (*PortMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
 }
+func (UnimplementedProgramServer) Connect(Program_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedProgramServer) Tick(context.Context, *CycleMessage) (*TickReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Tick not implemented")
+}
+func (UnimplementedProgramServer) GetWait(context.Context, *empty.Empty) (*WaitMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWait not implemented")
+}
+func (UnimplementedProgramServer) GetStats(context.Context, *empty.Empty) (*StatsMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedProgramServer) GetFault(context.Context, *empty.Empty) (*FaultMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFault not implemented")
+}
+func (UnimplementedProgramServer) ClearFault(context.Context, *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearFault not implemented")
+}
+func (UnimplementedProgramServer) GetNodeState(context.Context, *empty.Empty) (*NodeStateMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeState not implemented")
+}
 func (UnimplementedProgramServer) mustEmbedUnimplementedProgramServer() {}
 
 // UnsafeProgramServer may be embedded to opt out of forward compatibility for this service.
@@ -313,6 +461,42 @@ func _Program_Load_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Program_LoadBytecode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BytecodeMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).LoadBytecode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/LoadBytecode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).LoadBytecode(ctx, req.(*BytecodeMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_GetProgram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).GetProgram(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/GetProgram",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).GetProgram(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Program_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SendMessage)
 	if err := dec(in); err != nil {
@@ -331,6 +515,140 @@ func _Program_Send_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Program_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProgramServer).Connect(&programConnectServer{stream})
+}
+
+type Program_ConnectServer interface {
+	Send(*RegisterCredit) error
+	Recv() (*RegisterWrite, error)
+	grpc.ServerStream
+}
+
+type programConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *programConnectServer) Send(m *RegisterCredit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *programConnectServer) Recv() (*RegisterWrite, error) {
+	m := new(RegisterWrite)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Program_Tick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CycleMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).Tick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/Tick",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).Tick(ctx, req.(*CycleMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_GetWait_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).GetWait(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/GetWait",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).GetWait(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).GetStats(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_GetFault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).GetFault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/GetFault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).GetFault(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_ClearFault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).ClearFault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/ClearFault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).ClearFault(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Program_GetNodeState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProgramServer).GetNodeState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Program/GetNodeState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProgramServer).GetNodeState(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Program_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "grpc.Program",
 	HandlerType: (*ProgramServer)(nil),
@@ -351,10 +669,384 @@ var _Program_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Load",
 			Handler:    _Program_Load_Handler,
 		},
+		{
+			MethodName: "LoadBytecode",
+			Handler:    _Program_LoadBytecode_Handler,
+		},
+		{
+			MethodName: "GetProgram",
+			Handler:    _Program_GetProgram_Handler,
+		},
 		{
 			MethodName: "Send",
 			Handler:    _Program_Send_Handler,
 		},
+		{
+			MethodName: "Tick",
+			Handler:    _Program_Tick_Handler,
+		},
+		{
+			MethodName: "GetWait",
+			Handler:    _Program_GetWait_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Program_GetStats_Handler,
+		},
+		{
+			MethodName: "GetFault",
+			Handler:    _Program_GetFault_Handler,
+		},
+		{
+			MethodName: "ClearFault",
+			Handler:    _Program_ClearFault_Handler,
+		},
+		{
+			MethodName: "GetNodeState",
+			Handler:    _Program_GetNodeState_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Program_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "internal/grpc/messenger.proto",
+}
+
+// DebugClient is the client API for Debug service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DebugClient interface {
+	Step(ctx context.Context, in *StepMessage, opts ...grpc.CallOption) (*DebugState, error)
+	SetBreakpoint(ctx context.Context, in *BreakpointMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	ClearBreakpoint(ctx context.Context, in *BreakpointMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	SetWatch(ctx context.Context, in *WatchMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	ClearWatch(ctx context.Context, in *WatchMessage, opts ...grpc.CallOption) (*empty.Empty, error)
+	Continue(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DebugState, error)
+	GetTrace(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*TraceMessage, error)
+}
+
+type debugClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDebugClient(cc grpc.ClientConnInterface) DebugClient {
+	return &debugClient{cc}
+}
+
+func (c *debugClient) Step(ctx context.Context, in *StepMessage, opts ...grpc.CallOption) (*DebugState, error) {
+	out := new(DebugState)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/Step", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) SetBreakpoint(ctx context.Context, in *BreakpointMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/SetBreakpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) ClearBreakpoint(ctx context.Context, in *BreakpointMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/ClearBreakpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) SetWatch(ctx context.Context, in *WatchMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/SetWatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) ClearWatch(ctx context.Context, in *WatchMessage, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/ClearWatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) Continue(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/Continue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) GetState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DebugState, error) {
+	out := new(DebugState)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/GetState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debugClient) GetTrace(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*TraceMessage, error) {
+	out := new(TraceMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Debug/GetTrace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DebugServer is the server API for Debug service.
+// All implementations must embed UnimplementedDebugServer
+// for forward compatibility
+type DebugServer interface {
+	Step(context.Context, *StepMessage) (*DebugState, error)
+	SetBreakpoint(context.Context, *BreakpointMessage) (*empty.Empty, error)
+	ClearBreakpoint(context.Context, *BreakpointMessage) (*empty.Empty, error)
+	SetWatch(context.Context, *WatchMessage) (*empty.Empty, error)
+	ClearWatch(context.Context, *WatchMessage) (*empty.Empty, error)
+	Continue(context.Context, *empty.Empty) (*empty.Empty, error)
+	GetState(context.Context, *empty.Empty) (*DebugState, error)
+	GetTrace(context.Context, *empty.Empty) (*TraceMessage, error)
+	mustEmbedUnimplementedDebugServer()
+}
+
+// UnimplementedDebugServer must be embedded to have forward compatible implementations.
+type UnimplementedDebugServer struct {
+}
+
+func (UnimplementedDebugServer) Step(context.Context, *StepMessage) (*DebugState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Step not implemented")
+}
+func (UnimplementedDebugServer) SetBreakpoint(context.Context, *BreakpointMessage) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBreakpoint not implemented")
+}
+func (UnimplementedDebugServer) ClearBreakpoint(context.Context, *BreakpointMessage) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearBreakpoint not implemented")
+}
+func (UnimplementedDebugServer) SetWatch(context.Context, *WatchMessage) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWatch not implemented")
+}
+func (UnimplementedDebugServer) ClearWatch(context.Context, *WatchMessage) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearWatch not implemented")
+}
+func (UnimplementedDebugServer) Continue(context.Context, *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Continue not implemented")
+}
+func (UnimplementedDebugServer) GetState(context.Context, *empty.Empty) (*DebugState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedDebugServer) GetTrace(context.Context, *empty.Empty) (*TraceMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrace not implemented")
+}
+func (UnimplementedDebugServer) mustEmbedUnimplementedDebugServer() {}
+
+// UnsafeDebugServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DebugServer will
+// result in compilation errors.
+type UnsafeDebugServer interface {
+	mustEmbedUnimplementedDebugServer()
+}
+
+func RegisterDebugServer(s grpc.ServiceRegistrar, srv DebugServer) {
+	s.RegisterService(&_Debug_serviceDesc, srv)
+}
+
+func _Debug_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/Step",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).Step(ctx, req.(*StepMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_SetBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakpointMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).SetBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/SetBreakpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).SetBreakpoint(ctx, req.(*BreakpointMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_ClearBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakpointMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).ClearBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/ClearBreakpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).ClearBreakpoint(ctx, req.(*BreakpointMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_SetWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WatchMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).SetWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/SetWatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).SetWatch(ctx, req.(*WatchMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_ClearWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WatchMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).ClearWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/ClearWatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).ClearWatch(ctx, req.(*WatchMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_Continue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).Continue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/Continue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).Continue(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/GetState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).GetState(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Debug_GetTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServer).GetTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Debug/GetTrace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServer).GetTrace(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Debug_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpc.Debug",
+	HandlerType: (*DebugServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Step",
+			Handler:    _Debug_Step_Handler,
+		},
+		{
+			MethodName: "SetBreakpoint",
+			Handler:    _Debug_SetBreakpoint_Handler,
+		},
+		{
+			MethodName: "ClearBreakpoint",
+			Handler:    _Debug_ClearBreakpoint_Handler,
+		},
+		{
+			MethodName: "SetWatch",
+			Handler:    _Debug_SetWatch_Handler,
+		},
+		{
+			MethodName: "ClearWatch",
+			Handler:    _Debug_ClearWatch_Handler,
+		},
+		{
+			MethodName: "Continue",
+			Handler:    _Debug_Continue_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _Debug_GetState_Handler,
+		},
+		{
+			MethodName: "GetTrace",
+			Handler:    _Debug_GetTrace_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/grpc/messenger.proto",
@@ -368,7 +1060,9 @@ type StackClient interface {
 	Pause(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	Reset(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	Push(ctx context.Context, in *ValueMessage, opts ...grpc.CallOption) (*empty.Empty, error)
-	Pop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ValueMessage, error)
+	Pop(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*ValueMessage, error)
+	GetStack(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StackMessage, error)
+	GetNodeState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*NodeStateMessage, error)
 }
 
 type stackClient struct {
@@ -415,7 +1109,7 @@ func (c *stackClient) Push(ctx context.Context, in *ValueMessage, opts ...grpc.C
 	return out, nil
 }
 
-func (c *stackClient) Pop(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ValueMessage, error) {
+func (c *stackClient) Pop(ctx context.Context, in *CycleMessage, opts ...grpc.CallOption) (*ValueMessage, error) {
 	out := new(ValueMessage)
 	err := c.cc.Invoke(ctx, "/grpc.Stack/Pop", in, out, opts...)
 	if err != nil {
@@ -424,6 +1118,24 @@ func (c *stackClient) Pop(ctx context.Context, in *empty.Empty, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *stackClient) GetStack(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StackMessage, error) {
+	out := new(StackMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Stack/GetStack", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stackClient) GetNodeState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*NodeStateMessage, error) {
+	out := new(NodeStateMessage)
+	err := c.cc.Invoke(ctx, "/grpc.Stack/GetNodeState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StackServer is the server API for Stack service.
 // All implementations must embed UnimplementedStackServer
 // for forward compatibility
@@ -432,7 +1144,9 @@ type StackServer interface {
 	Pause(context.Context, *empty.Empty) (*empty.Empty, error)
 	Reset(context.Context, *empty.Empty) (*empty.Empty, error)
 	Push(context.Context, *ValueMessage) (*empty.Empty, error)
-	Pop(context.Context, *empty.Empty) (*ValueMessage, error)
+	Pop(context.Context, *CycleMessage) (*ValueMessage, error)
+	GetStack(context.Context, *empty.Empty) (*StackMessage, error)
+	GetNodeState(context.Context, *empty.Empty) (*NodeStateMessage, error)
 	mustEmbedUnimplementedStackServer()
 }
 
@@ -452,9 +1166,15 @@ func (UnimplementedStackServer) Reset(context.Context, *empty.Empty) (*empty.Emp
 func (UnimplementedStackServer) Push(context.Context, *ValueMessage) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
 }
-func (UnimplementedStackServer) Pop(context.Context, *empty.Empty) (*ValueMessage, error) {
+func (UnimplementedStackServer) Pop(context.Context, *CycleMessage) (*ValueMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Pop not implemented")
 }
+func (UnimplementedStackServer) GetStack(context.Context, *empty.Empty) (*StackMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStack not implemented")
+}
+func (UnimplementedStackServer) GetNodeState(context.Context, *empty.Empty) (*NodeStateMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeState not implemented")
+}
 func (UnimplementedStackServer) mustEmbedUnimplementedStackServer() {}
 
 // UnsafeStackServer may be embedded to opt out of forward compatibility for this service.
@@ -541,7 +1261,7 @@ func _Stack_Push_Handler(srv interface{}, ctx context.Context, dec func(interfac
 }
 
 func _Stack_Pop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(CycleMessage)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -553,7 +1273,43 @@ func _Stack_Pop_Handler(srv interface{}, ctx context.Context, dec func(interface
 		FullMethod: "/grpc.Stack/Pop",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StackServer).Pop(ctx, req.(*empty.Empty))
+		return srv.(StackServer).Pop(ctx, req.(*CycleMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stack_GetStack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StackServer).GetStack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Stack/GetStack",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StackServer).GetStack(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stack_GetNodeState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StackServer).GetNodeState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Stack/GetNodeState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StackServer).GetNodeState(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -582,6 +1338,14 @@ var _Stack_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Pop",
 			Handler:    _Stack_Pop_Handler,
 		},
+		{
+			MethodName: "GetStack",
+			Handler:    _Stack_GetStack_Handler,
+		},
+		{
+			MethodName: "GetNodeState",
+			Handler:    _Stack_GetNodeState_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/grpc/messenger.proto",
@@ -0,0 +1,265 @@
+// Package logging writes leveled, structured logs as logfmt or JSON lines.
+//
+// Loggers carry fields such as node name and request ID that are added to every line:
+//
+//	l := logging.New(os.Stderr, logging.LevelInfo, logging.FormatLogfmt).With("node", "misaka1")
+//	l.Info("node was run", "cmd", "run")
+//
+// writes
+//
+//	time=2020-10-16T12:00:00.000Z level=info msg="node was run" node=misaka1 cmd=run
+package logging
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log line
+type Level int
+
+// Supported levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if s, ok := levelNames[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses level by name. Empty string is info.
+func ParseLevel(s string) (Level, error) {
+	if s == "" {
+		return LevelInfo, nil
+	}
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("'%s' not a valid log level", s)
+}
+
+// Format is how log lines are written
+type Format int
+
+// Supported formats
+const (
+	FormatLogfmt Format = iota
+	FormatJSON
+)
+
+// ParseFormat parses format by name. Empty string is logfmt.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "logfmt":
+		return FormatLogfmt, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return FormatLogfmt, fmt.Errorf("'%s' not a valid log format", s)
+}
+
+// Logger writes log lines at or above its level
+type Logger struct {
+	out    io.Writer
+	mux    *sync.Mutex
+	level  Level
+	format Format
+	fields []interface{}
+}
+
+// New creates a logger writing to out
+func New(out io.Writer, level Level, format Format) *Logger {
+	return &Logger{out: out, mux: &sync.Mutex{}, level: level, format: format}
+}
+
+// Default creates a logger writing info and above to stderr as logfmt
+func Default() *Logger {
+	return New(os.Stderr, LevelInfo, FormatLogfmt)
+}
+
+// With creates a logger that adds key-value pairs to every line
+func (l *Logger) With(kv ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), kv...)
+	return &child
+}
+
+// Enabled checks if lines at level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+// Debug writes line at debug level with key-value pairs
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.write(LevelDebug, msg, kv)
+}
+
+// Info writes line at info level with key-value pairs
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.write(LevelInfo, msg, kv)
+}
+
+// Warn writes line at warn level with key-value pairs
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.write(LevelWarn, msg, kv)
+}
+
+// Error writes line at error level with key-value pairs
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.write(LevelError, msg, kv)
+}
+
+// Fatal writes line at error level and exits
+func (l *Logger) Fatal(msg string, kv ...interface{}) {
+	l.write(LevelError, msg, kv)
+	os.Exit(1)
+}
+
+// write formats and writes line
+func (l *Logger) write(level Level, msg string, kv []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	pairs := []interface{}{
+		"time", time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		"level", level.String(),
+		"msg", msg,
+	}
+	pairs = append(pairs, l.fields...)
+	pairs = append(pairs, kv...)
+	if len(pairs)%2 != 0 {
+		pairs = append(pairs, "(missing)")
+	}
+
+	var b bytes.Buffer
+	switch l.format {
+	case FormatJSON:
+		writeJSON(&b, pairs)
+	default:
+		writeLogfmt(&b, pairs)
+	}
+	b.WriteByte('\n')
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.out.Write(b.Bytes())
+}
+
+// writeLogfmt writes pairs as key=value separated by spaces
+func writeLogfmt(b *bytes.Buffer, pairs []interface{}) {
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(fmt.Sprint(pairs[i]))
+		b.WriteByte('=')
+		s := valueString(pairs[i+1])
+		if s == "" || strings.ContainsAny(s, " =\"\\\n\t") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(s)
+	}
+}
+
+// writeJSON writes pairs as a JSON object in order
+func writeJSON(b *bytes.Buffer, pairs []interface{}) {
+	b.WriteByte('{')
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(fmt.Sprint(pairs[i]))
+		b.Write(key)
+		b.WriteByte(':')
+
+		v := pairs[i+1]
+		switch v.(type) {
+		case error, fmt.Stringer:
+			v = valueString(v)
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(v))
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+}
+
+// valueString formats value for logfmt
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+)
+
+// NewContext creates context carrying logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext gets logger in ctx or fallback if there is none
+func FromContext(ctx context.Context, fallback *Logger) *Logger {
+	if l, ok := ctx.Value(loggerKey).(*Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// NewRequestID creates a random request ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID creates context carrying request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID gets request ID in ctx or empty string if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
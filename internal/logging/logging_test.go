@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lines gets lines written with time field removed
+func lines(b *bytes.Buffer) []string {
+	var res []string
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if i := strings.Index(line, " level="); strings.HasPrefix(line, "time=") && i >= 0 {
+			line = line[i+1:]
+		}
+		res = append(res, line)
+	}
+	return res
+}
+
+func TestLevelFiltering(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  []string
+	}{
+		{LevelDebug, []string{"level=debug msg=d", "level=info msg=i", "level=warn msg=w", "level=error msg=e"}},
+		{LevelInfo, []string{"level=info msg=i", "level=warn msg=w", "level=error msg=e"}},
+		{LevelWarn, []string{"level=warn msg=w", "level=error msg=e"}},
+		{LevelError, []string{"level=error msg=e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var b bytes.Buffer
+			l := New(&b, tt.level, FormatLogfmt)
+			l.Debug("d")
+			l.Info("i")
+			l.Warn("w")
+			l.Error("e")
+			if got := lines(&b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+				if l.Enabled(level) != (level >= tt.level) {
+					t.Errorf("Enabled(%v) = %v", level, l.Enabled(level))
+				}
+			}
+		})
+	}
+}
+
+func TestWithAddsNodeIdentity(t *testing.T) {
+	var b bytes.Buffer
+	node := New(&b, LevelInfo, FormatLogfmt).With("node", "misaka1", "type", "program")
+	req := node.With("request", "abc")
+	node.Info("node was run", "cmd", "run")
+	req.Warn("could not send", "err", errors.New("peer gone"), "target", "misaka 2")
+	node.Info("odd", "key")
+
+	want := []string{
+		`level=info msg="node was run" node=misaka1 type=program cmd=run`,
+		`level=warn msg="could not send" node=misaka1 type=program request=abc err="peer gone" target="misaka 2"`,
+		`level=info msg=odd node=misaka1 type=program key=(missing)`,
+	}
+	if got := lines(&b); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, LevelDebug, FormatJSON).With("node", "stack", "type", "stack")
+	l.Debug("popped", "value", 42, "level2", LevelWarn, "err", errors.New("x"))
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("line %q: %v", b.String(), err)
+	}
+	if _, ok := got["time"]; !ok {
+		t.Error("no time field")
+	}
+	delete(got, "time")
+	want := map[string]interface{}{
+		"level": "debug", "msg": "popped", "node": "stack", "type": "stack",
+		"value": float64(42), "level2": "warn", "err": "x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	// Fields keep the order they were given in
+	if s := b.String(); !(strings.Index(s, `"level"`) < strings.Index(s, `"node"`) && strings.Index(s, `"node"`) < strings.Index(s, `"value"`)) {
+		t.Errorf("fields out of order: %s", s)
+	}
+}
+
+func TestParseLevelAndFormat(t *testing.T) {
+	for s, want := range map[string]Level{"": LevelInfo, "DEBUG": LevelDebug, "warn": LevelWarn, "Error": LevelError} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("trace"); err == nil {
+		t.Error("ParseLevel(trace) succeeded")
+	}
+	for s, want := range map[string]Format{"": FormatLogfmt, "logfmt": FormatLogfmt, "JSON": FormatJSON} {
+		if got, err := ParseFormat(s); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded")
+	}
+}
+
+func TestContext(t *testing.T) {
+	fallback := New(&bytes.Buffer{}, LevelInfo, FormatLogfmt)
+	l := fallback.With("request", "abc")
+	ctx := context.Background()
+	if FromContext(ctx, fallback) != fallback || RequestID(ctx) != "" {
+		t.Error("empty context has logger or request ID")
+	}
+	ctx = WithRequestID(NewContext(ctx, l), "abc")
+	if FromContext(ctx, fallback) != l || RequestID(ctx) != "abc" {
+		t.Error("context lost logger or request ID")
+	}
+	if a, b := NewRequestID(), NewRequestID(); len(a) != 16 || a == b {
+		t.Errorf("NewRequestID() = %q, %q", a, b)
+	}
+}
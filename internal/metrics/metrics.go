@@ -0,0 +1,276 @@
+// Package metrics exposes counters, gauges and histograms in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are histogram buckets in seconds suited to gRPC call latencies
+var DefaultBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5, 10}
+
+// Registry holds metrics and serves them to Prometheus
+type Registry struct {
+	mux     sync.Mutex
+	metrics []metric
+}
+
+// metric is a family of samples with the same name
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds metric to registry
+func (r *Registry) register(m metric) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, other := range r.metrics {
+		if other.name() == m.name() {
+			panic(fmt.Errorf("metric %s already registered", m.name()))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes every metric in text format sorted by name
+func (r *Registry) Write(w io.Writer) {
+	r.mux.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mux.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves metrics to Prometheus
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	default:
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+// desc is the name, help and labels of a metric
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+// writeHeader writes help and type lines
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+// labelKey joins label values into a map key
+func (d *desc) labelKey(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Errorf("metric %s takes %d labels, got %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels formats labels for sample line. Extra label pairs are appended.
+func (d *desc) formatLabels(key string, extra ...string) string {
+	var pairs []string
+	if len(d.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", d.labels[i], escapeLabel(v)))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a value that only goes up, split by label values
+type Counter struct {
+	desc
+	mux    sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter registers a counter
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter", labels}, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Add adds v to counter with label values
+func (c *Counter) Add(v float64, labels ...string) {
+	key := c.labelKey(labels)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.values[key] += v
+}
+
+// Inc adds 1 to counter with label values
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.writeHeader(w)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.formatLabels(key), formatValue(c.values[key]))
+	}
+}
+
+// Func is a counter or gauge whose value is read when scraped
+type Func struct {
+	desc
+	f func() float64
+}
+
+// NewCounterFunc registers a counter read from f. Counter may go back to zero when node resets.
+func (r *Registry) NewCounterFunc(name, help string, f func() float64) *Func {
+	m := &Func{desc: desc{name, help, "counter", nil}, f: f}
+	r.register(m)
+	return m
+}
+
+// NewGaugeFunc registers a gauge read from f
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) *Func {
+	m := &Func{desc: desc{name, help, "gauge", nil}, f: f}
+	r.register(m)
+	return m
+}
+
+func (m *Func) write(w io.Writer) {
+	m.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", m.metricName, formatValue(m.f()))
+}
+
+// Histogram counts observations in buckets, split by label values
+type Histogram struct {
+	desc
+	buckets []float64
+	mux     sync.Mutex
+	values  map[string]*histogramValue
+}
+
+// histogramValue is a histogram for one set of label values
+type histogramValue struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with upper bounds of buckets in increasing order
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe adds v to histogram with label values
+func (h *Histogram) Observe(v float64, labels ...string) {
+	key := h.labelKey(labels)
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+// ObserveSince adds time since start in seconds to histogram with label values
+func (h *Histogram) ObserveSince(start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.writeHeader(w)
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.formatLabels(key, "le", formatValue(bound)), hv.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.formatLabels(key, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.formatLabels(key), formatValue(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.formatLabels(key), hv.count)
+	}
+}
+
+// sortedKeys gets keys of values in order
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue formats sample value
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeHelp escapes help text
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabel escapes label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistryServesTextFormat(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounter("test_calls_total", "Calls made.", "method")
+	calls.Inc("Send")
+	calls.Add(2, "Push")
+	calls.Inc("Send")
+	r.NewGaugeFunc("test_depth", "Values on\nstack.", func() float64 { return 3 })
+	latency := r.NewHistogram("test_duration_seconds", "Call latency.", []float64{.1, 1}, "port")
+	latency.Observe(.05, `a"b`)
+	latency.Observe(.5, `a"b`)
+	latency.Observe(2, `a"b`)
+	r.NewCounterFunc("test_dials_total", "Dials.", func() float64 { return 1.5 })
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Header.Get("Content-Type"); got != "text/plain; version=0.0.4" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	// Metrics are sorted by name and samples by label values
+	want := `# HELP test_calls_total Calls made.
+# TYPE test_calls_total counter
+test_calls_total{method="Push"} 2
+test_calls_total{method="Send"} 2
+# HELP test_depth Values on\nstack.
+# TYPE test_depth gauge
+test_depth 3
+# HELP test_dials_total Dials.
+# TYPE test_dials_total counter
+test_dials_total 1.5
+# HELP test_duration_seconds Call latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{port="a\"b",le="0.1"} 1
+test_duration_seconds_bucket{port="a\"b",le="1"} 2
+test_duration_seconds_bucket{port="a\"b",le="+Inf"} 3
+test_duration_seconds_sum{port="a\"b"} 2.55
+test_duration_seconds_count{port="a\"b"} 3
+`
+	if string(body) != want {
+		t.Errorf("body =\n%s\nwant\n%s", body, want)
+	}
+
+	res, err = http.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", res.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering test_total twice did not panic")
+		}
+	}()
+	r.NewGaugeFunc("test_total", "Test.", func() float64 { return 0 })
+}
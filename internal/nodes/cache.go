@@ -0,0 +1,70 @@
+package nodes
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// maxCachedPrograms bounds compiled programs kept by master
+const maxCachedPrograms = 64
+
+// bytecodeCache keeps most recently used compiled programs, dropping the least recently used
+// once it is full
+type bytecodeCache struct {
+	mux     sync.Mutex
+	size    int
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+// cacheEntry is compiled program in cache
+type cacheEntry struct {
+	key      [sha256.Size]byte
+	bytecode []byte
+}
+
+// newBytecodeCache creates cache holding at most size programs
+func newBytecodeCache(size int) *bytecodeCache {
+	return &bytecodeCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// get gets program cached under key
+func (c *bytecodeCache) get(key [sha256.Size]byte) ([]byte, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).bytecode, true
+}
+
+// put caches program under key
+func (c *bytecodeCache) put(key [sha256.Size]byte, bytecode []byte) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).bytecode = bytecode
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, bytecode: bytecode})
+	for c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*cacheEntry).key)
+	}
+}
+
+// len gets number of cached programs
+func (c *bytecodeCache) len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.order.Len()
+}
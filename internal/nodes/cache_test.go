@@ -0,0 +1,30 @@
+package nodes
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestBytecodeCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newBytecodeCache(2)
+	a, b, d := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("d"))
+	c.put(a, []byte("A"))
+	c.put(b, []byte("B"))
+	if _, ok := c.get(a); !ok {
+		t.Fatal("a not cached")
+	}
+	c.put(d, []byte("D"))
+
+	if c.len() != 2 {
+		t.Errorf("len() = %d, want 2", c.len())
+	}
+	if _, ok := c.get(b); ok {
+		t.Error("b was not evicted")
+	}
+	if v, ok := c.get(a); !ok || string(v) != "A" {
+		t.Errorf("get(a) = %q, %v", v, ok)
+	}
+	if v, ok := c.get(d); !ok || string(v) != "D" {
+		t.Errorf("get(d) = %q, %v", v, ok)
+	}
+}
@@ -0,0 +1,89 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Clock modes
+const (
+	clockFree     = "free"
+	clockLockstep = "lockstep"
+)
+
+// errWouldBlock is returned by lockstep requests that cannot complete this cycle
+var errWouldBlock = status.Error(codes.FailedPrecondition, "would block")
+
+// isWouldBlock checks if request failed because it would block
+func isWouldBlock(err error) bool {
+	return status.Code(err) == codes.FailedPrecondition
+}
+
+// pendingValue is a value written during a lockstep cycle.
+// It becomes visible in the next cycle.
+type pendingValue struct {
+	value int64
+	cycle int64
+	ok    bool
+}
+
+// runClock ticks all program nodes once per cycle until ctx is done,
+// a node faults or cycles have run. Zero cycles runs until stopped.
+// Network faults if a tick fails and halts once cycles have run.
+func (m *MasterNode) runClock(ctx context.Context, cycles int64) {
+	for n := int64(0); cycles == 0 || n < cycles; n++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		cycle := atomic.AddInt64(&m.cycle, 1)
+		if err := m.tick(ctx, cycle); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.life.stop(StateFaulted)
+			m.logger.Warn("clock stopped", "cycle", cycle, "err", err)
+			return
+		}
+	}
+	m.life.stop(StateHalted)
+	m.logger.Info("clock stopped", "cycles", cycles)
+}
+
+// tick runs one cycle on every program node, one node at a time in order of name.
+// Nodes contending for a register, stack or input in the same cycle are served
+// in that order, so lockstep runs are reproducible.
+func (m *MasterNode) tick(ctx context.Context, cycle int64) error {
+	var targets []string
+	for k, v := range m.nodeInfo {
+		if v.Type == "program" {
+			targets = append(targets, k)
+		}
+	}
+	sort.Strings(targets)
+
+	for _, targetURI := range targets {
+		var res *pb.TickReply
+		err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+			var err error
+			res, err = pb.NewProgramClient(conn).Tick(ctx, &pb.CycleMessage{Cycle: cycle})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("node %s: %s", targetURI, err.Error())
+		}
+		if res.Fault != "" {
+			return fmt.Errorf("node %s faulted: %s", targetURI, res.Fault)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package nodes
+
+import "testing"
+
+func TestLockstepContentionFollowsNodeOrder(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t)
+
+	// Ticks arriving in any other order would sometimes hand the top of the stack to peer
+	for round := 0; round < 20; round++ {
+		n.master.resetNode()
+		n.stack.resetNode()
+		for v := 1; v <= 4; v++ {
+			n.stack.stack.Push(v)
+		}
+		for _, p := range []*ProgramNode{n.program, n.peer} {
+			if err := p.LoadProgram("POP stack, ACC"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := n.master.life.set(StateRunning); err != nil {
+			t.Fatal(err)
+		}
+		n.master.setClockMode(clockLockstep)
+		n.master.runClock(ctx, 2)
+		if state := n.master.life.State(); state != StateHalted {
+			t.Fatalf("master is %s after clock stopped, want %s", state, StateHalted)
+		}
+
+		if got := n.program.machineView().acc; got != 2 {
+			t.Fatalf("round %d: %s popped %d last, want 2", round, testProgram, got)
+		}
+		if got := n.peer.machineView().acc; got != 1 {
+			t.Fatalf("round %d: %s popped %d last, want 1", round, testPeer, got)
+		}
+	}
+}
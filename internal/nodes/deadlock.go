@@ -0,0 +1,379 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+)
+
+// Default time a node must wait on the same port before it counts as blocked
+const defaultMinWait = time.Second
+
+// waitState is the port a program node is blocked on
+type waitState struct {
+	mux     sync.Mutex
+	port    tis.Port
+	waiting bool
+	since   time.Time
+
+	// total is time spent waiting on earlier ports
+	total time.Duration
+
+	// observe is told how long node waited on each port
+	observe func(port tis.Port, d time.Duration)
+}
+
+// set marks node as waiting on port. Waiting again on the same port keeps original start time.
+func (w *waitState) set(port tis.Port) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if !w.waiting || w.port != port {
+		now := time.Now()
+		if w.waiting {
+			w.record(now.Sub(w.since))
+		}
+		w.port = port
+		w.since = now
+	}
+	w.waiting = true
+}
+
+// clear marks node as not waiting
+func (w *waitState) clear() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.waiting {
+		w.record(time.Since(w.since))
+	}
+	w.waiting = false
+}
+
+// record adds time spent waiting on current port. Must hold mux.
+func (w *waitState) record(d time.Duration) {
+	w.total += d
+	if w.observe != nil {
+		w.observe(w.port, d)
+	}
+}
+
+// reset marks node as not waiting and forgets time spent waiting
+func (w *waitState) reset() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.waiting = false
+	w.total = 0
+}
+
+// waitTime gets total time spent waiting
+func (w *waitState) waitTime() time.Duration {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.waiting {
+		return w.total + time.Since(w.since)
+	}
+	return w.total
+}
+
+// message converts wait state for response
+func (w *waitState) message() *pb.WaitMessage {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if !w.waiting {
+		return &pb.WaitMessage{}
+	}
+	return &pb.WaitMessage{
+		Blocked:  true,
+		Kind:     int32(w.port.Kind),
+		Node:     w.port.Node,
+		Register: int32(w.port.Register),
+		Duration: int64(time.Since(w.since)),
+	}
+}
+
+// GetWait handles request for port node is blocked on
+func (p *ProgramNode) GetWait(ctx context.Context, in *empty.Empty) (*pb.WaitMessage, error) {
+	return p.waits.message(), nil
+}
+
+// clientWait structures blocked node in deadlock response to client
+type clientWait struct {
+	Node       string   `json:"node"`
+	Port       string   `json:"port"`
+	WaitingFor []string `json:"waitingFor"`
+	BlockedFor int64    `json:"blockedFor"`
+}
+
+// clientDeadlockResponse structures deadlock response to client
+type clientDeadlockResponse struct {
+	Deadlocked bool         `json:"deadlocked"`
+	Nodes      []string     `json:"nodes"`
+	Cycle      []string     `json:"cycle"`
+	Waits      []clientWait `json:"waits"`
+}
+
+// WatchDeadlocks checks for deadlocks every interval while network runs.
+// Deadlocks fail waiting compute requests and pause network if pause is set.
+func (m *MasterNode) WatchDeadlocks(interval time.Duration, pause bool) {
+	go func() {
+		for range time.Tick(interval) {
+			if !m.life.running() {
+				continue
+			}
+			res, err := m.detectDeadlock(context.Background(), defaultMinWait)
+			if err != nil {
+				m.logger.Warn("could not check for deadlock", "err", err)
+				continue
+			}
+			if !res.Deadlocked {
+				continue
+			}
+
+			m.logger.Warn("network deadlocked", "nodes", strings.Join(res.Nodes, ","))
+			m.signalDeadlock(res)
+			if pause {
+				if err := m.broadcastCommand(context.Background(), "pause"); err != nil {
+					m.logger.Error("could not pause deadlocked network", "cmd", "pause", "err", err)
+				}
+				m.life.stop(StatePaused)
+			}
+		}
+	}()
+}
+
+// signalDeadlock records deadlock and wakes requests waiting on network
+func (m *MasterNode) signalDeadlock(res *clientDeadlockResponse) {
+	m.deadlockMux.Lock()
+	defer m.deadlockMux.Unlock()
+	if m.deadlock == nil {
+		m.deadlock = res
+		close(m.deadlockFound)
+	}
+}
+
+// clearDeadlock forgets deadlock once network is restarted
+func (m *MasterNode) clearDeadlock() {
+	m.deadlockMux.Lock()
+	defer m.deadlockMux.Unlock()
+	if m.deadlock != nil {
+		m.deadlock = nil
+		m.deadlockFound = make(chan struct{})
+	}
+}
+
+// deadlockSignal gets channel that is closed when a deadlock is found and the deadlock if any
+func (m *MasterNode) deadlockSignal() (<-chan struct{}, *clientDeadlockResponse) {
+	m.deadlockMux.Lock()
+	defer m.deadlockMux.Unlock()
+	return m.deadlockFound, m.deadlock
+}
+
+// writeDeadlock writes deadlock to client that was waiting on network
+func (m *MasterNode) writeDeadlock(w http.ResponseWriter) {
+	_, res := m.deadlockSignal()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(res)
+}
+
+// handleDeadlock registers client endpoint for deadlock detection
+func (m *MasterNode) handleDeadlock() {
+	http.HandleFunc("/deadlock", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			minWait := defaultMinWait
+			if s := r.FormValue("minWait"); s != "" {
+				v, err := time.ParseDuration(s)
+				if err != nil || v < 0 {
+					http.Error(w, "cannot parse minWait", http.StatusBadRequest)
+					return
+				}
+				minWait = v
+			}
+
+			res, err := m.detectDeadlock(r.Context(), minWait)
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not check for deadlock", "err", err)
+				http.Error(w, fmt.Sprintf("error checking for deadlock: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(res)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// detectDeadlock gets state of every node and finds deadlocked program nodes
+func (m *MasterNode) detectDeadlock(ctx context.Context, minWait time.Duration) (*clientDeadlockResponse, error) {
+	programs, waits, stacks, err := m.collectWaits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findDeadlock(programs, waits, stacks, minWait), nil
+}
+
+// findDeadlock finds program nodes that have waited at least minWait and can never be unblocked.
+// A node popping a stack that has values is about to finish and is not blocked.
+func findDeadlock(programs map[string]*tis.Program, waits map[string]*pb.WaitMessage, stacks map[string]int, minWait time.Duration) *clientDeadlockResponse {
+	blocked := make(map[string]tis.Port)
+	for name, msg := range waits {
+		if !msg.Blocked || time.Duration(msg.Duration) < minWait {
+			continue
+		}
+		port := tis.Port{Kind: tis.PortKind(msg.Kind), Node: msg.Node, Register: tis.Register(msg.Register)}
+		if port.Kind == tis.PopPort && stacks[port.Node] > 0 {
+			continue
+		}
+		blocked[name] = port
+	}
+	deadlocked, waitingFor := tis.FindDeadlock(programs, blocked)
+
+	res := &clientDeadlockResponse{
+		Deadlocked: len(deadlocked) > 0,
+		Nodes:      deadlocked,
+		Cycle:      []string{},
+		Waits:      []clientWait{},
+	}
+	for _, name := range res.Nodes {
+		res.Waits = append(res.Waits, clientWait{
+			Node:       name,
+			Port:       blocked[name].String(),
+			WaitingFor: append([]string{}, waitingFor[name]...),
+			BlockedFor: waits[name].Duration,
+		})
+	}
+	res.Cycle = findCycle(res.Nodes, func(name string) []string {
+		return waitingFor[name]
+	})
+	return res
+}
+
+// collectWaits gets program, wait state and stack sizes from every node
+func (m *MasterNode) collectWaits(ctx context.Context) (map[string]*tis.Program, map[string]*pb.WaitMessage, map[string]int, error) {
+	type result struct {
+		node  string
+		prog  *tis.Program
+		wait  *pb.WaitMessage
+		stack int
+		err   error
+	}
+
+	c := make(chan result)
+	for k, v := range m.nodeInfo {
+		go func(targetURI string, info NodeInfo) {
+			res := result{node: targetURI}
+			defer func() { c <- res }()
+
+			res.err = m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				switch info.Type {
+				case "program":
+					client := pb.NewProgramClient(conn)
+					res.wait, err = client.GetWait(ctx, &empty.Empty{})
+					if err != nil {
+						return err
+					}
+					var loaded *pb.LoadMessage
+					loaded, err = client.GetProgram(ctx, &empty.Empty{})
+					if err != nil {
+						return err
+					}
+					res.prog, err = tis.Assemble(loaded.Program, tis.PreprocessOptions{})
+				case "stack":
+					var stack *pb.StackMessage
+					stack, err = pb.NewStackClient(conn).GetStack(ctx, &empty.Empty{})
+					if err == nil {
+						res.stack = len(stack.Values)
+					}
+				}
+				return err
+			})
+		}(k, v)
+	}
+
+	programs := make(map[string]*tis.Program)
+	waits := make(map[string]*pb.WaitMessage)
+	stacks := make(map[string]int)
+	var waitErr error
+	for range m.nodeInfo {
+		res := <-c
+		if res.err != nil {
+			if waitErr == nil {
+				waitErr = fmt.Errorf("node %s: %s", res.node, res.err.Error())
+			}
+			continue
+		}
+		if m.nodeInfo[res.node].Type == "program" {
+			programs[res.node] = res.prog
+			waits[res.node] = res.wait
+		} else {
+			stacks[res.node] = res.stack
+		}
+	}
+	if waitErr != nil {
+		return nil, nil, nil, waitErr
+	}
+	return programs, waits, stacks, nil
+}
+
+// findCycle finds a cycle among nodes following edges. Returns cycle with first node repeated at end.
+func findCycle(nodes []string, edges func(string) []string) []string {
+	in := make(map[string]bool)
+	for _, name := range nodes {
+		in[name] = true
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, next := range edges(name) {
+			if !in[next] {
+				continue
+			}
+			switch state[next] {
+			case visiting:
+				for i, n := range path {
+					if n == next {
+						return append(append([]string{}, path[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range nodes {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return []string{}
+}
@@ -0,0 +1,187 @@
+package nodes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+)
+
+// readWait is a program node blocked reading r for 2s
+func readWait(r tis.Register) *pb.WaitMessage {
+	return &pb.WaitMessage{Blocked: true, Kind: int32(tis.ReadPort), Register: int32(r), Duration: int64(2 * time.Second)}
+}
+
+func TestFindDeadlock(t *testing.T) {
+	popWait := &pb.WaitMessage{Blocked: true, Kind: int32(tis.PopPort), Node: "s", Duration: int64(2 * time.Second)}
+	tests := []struct {
+		name     string
+		programs map[string]string
+		waits    map[string]*pb.WaitMessage
+		stacks   map[string]int
+		want     *clientDeadlockResponse
+	}{
+		{
+			name: "register cycle",
+			programs: map[string]string{
+				"a": "MOV R0, ACC\nMOV ACC, b:R0",
+				"b": "MOV ANY, ACC\nMOV ACC, a:R0",
+				"c": "MOV 1, a:R1",
+			},
+			waits: map[string]*pb.WaitMessage{"a": readWait(tis.R0), "b": readWait(tis.ANY), "c": {}},
+			want: &clientDeadlockResponse{
+				Deadlocked: true,
+				Nodes:      []string{"a", "b"},
+				Cycle:      []string{"a", "b", "a"},
+				Waits: []clientWait{
+					{Node: "a", Port: "R0", WaitingFor: []string{"b"}, BlockedFor: int64(2 * time.Second)},
+					{Node: "b", Port: "ANY", WaitingFor: []string{"a"}, BlockedFor: int64(2 * time.Second)},
+				},
+			},
+		},
+		{
+			name: "register cycle with running writer",
+			programs: map[string]string{
+				"a": "MOV R0, ACC\nMOV ACC, b:R0",
+				"b": "MOV ANY, ACC\nMOV ACC, a:R0",
+				"c": "MOV 1, b:R3",
+			},
+			waits: map[string]*pb.WaitMessage{"a": readWait(tis.R0), "b": readWait(tis.ANY), "c": {}},
+			want:  &clientDeadlockResponse{Deadlocked: false, Nodes: []string{}, Cycle: []string{}, Waits: []clientWait{}},
+		},
+		{
+			name: "pop from empty stack",
+			programs: map[string]string{
+				"a": "POP s, ACC\nMOV ACC, b:R0",
+				"b": "MOV R0, ACC\nPUSH ACC, s",
+			},
+			waits: map[string]*pb.WaitMessage{"a": popWait, "b": readWait(tis.R0)},
+			want: &clientDeadlockResponse{
+				Deadlocked: true,
+				Nodes:      []string{"a", "b"},
+				Cycle:      []string{"a", "b", "a"},
+				Waits: []clientWait{
+					{Node: "a", Port: "pop s", WaitingFor: []string{"b"}, BlockedFor: int64(2 * time.Second)},
+					{Node: "b", Port: "R0", WaitingFor: []string{"a"}, BlockedFor: int64(2 * time.Second)},
+				},
+			},
+		},
+		{
+			name: "pop from stack with values",
+			programs: map[string]string{
+				"a": "POP s, ACC\nMOV ACC, b:R0",
+				"b": "MOV R0, ACC\nPUSH ACC, s",
+			},
+			waits:  map[string]*pb.WaitMessage{"a": popWait, "b": readWait(tis.R0)},
+			stacks: map[string]int{"s": 1},
+			want:   &clientDeadlockResponse{Deadlocked: false, Nodes: []string{}, Cycle: []string{}, Waits: []clientWait{}},
+		},
+		{
+			name: "writer waits on input",
+			programs: map[string]string{
+				"a": "IN ACC\nMOV ACC, b:R0",
+				"b": "MOV R0, ACC\nOUT ACC",
+			},
+			waits: map[string]*pb.WaitMessage{
+				"a": {Blocked: true, Kind: int32(tis.InPort), Duration: int64(2 * time.Second)},
+				"b": readWait(tis.R0),
+			},
+			want: &clientDeadlockResponse{Deadlocked: false, Nodes: []string{}, Cycle: []string{}, Waits: []clientWait{}},
+		},
+		{
+			name: "not blocked long enough",
+			programs: map[string]string{
+				"a": "MOV R0, ACC\nMOV ACC, b:R0",
+				"b": "MOV R0, ACC\nMOV ACC, a:R0",
+			},
+			waits: map[string]*pb.WaitMessage{
+				"a": readWait(tis.R0),
+				"b": {Blocked: true, Kind: int32(tis.ReadPort), Register: int32(tis.R0), Duration: int64(time.Millisecond)},
+			},
+			want: &clientDeadlockResponse{Deadlocked: false, Nodes: []string{}, Cycle: []string{}, Waits: []clientWait{}},
+		},
+		{
+			name: "reads register nobody writes",
+			programs: map[string]string{
+				"a": "MOV R1, ACC",
+				"b": "MOV 1, a:R0",
+			},
+			waits: map[string]*pb.WaitMessage{"a": readWait(tis.R1), "b": {}},
+			want: &clientDeadlockResponse{
+				Deadlocked: true,
+				Nodes:      []string{"a"},
+				Cycle:      []string{},
+				Waits:      []clientWait{{Node: "a", Port: "R1", WaitingFor: []string{}, BlockedFor: int64(2 * time.Second)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			programs := make(map[string]*tis.Program)
+			for name, src := range tt.programs {
+				prog, err := tis.Assemble(src, tis.PreprocessOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				programs[name] = prog
+			}
+			got := findDeadlock(programs, tt.waits, tt.stacks, time.Second)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findDeadlock() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		edges map[string][]string
+		want  []string
+	}{
+		{"none", []string{"a", "b"}, map[string][]string{"a": {"b"}}, []string{}},
+		{"self", []string{"a"}, map[string][]string{"a": {"a"}}, []string{"a", "a"}},
+		{"pair", []string{"a", "b"}, map[string][]string{"a": {"b"}, "b": {"a"}}, []string{"a", "b", "a"}},
+		{"after path", []string{"a", "b", "c"}, map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}}, []string{"b", "c", "b"}},
+		{"edges leaving nodes", []string{"a", "b"}, map[string][]string{"a": {"x", "b"}, "b": {"y"}, "y": {"a"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCycle(tt.nodes, func(name string) []string { return tt.edges[name] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushMarksNodeWaiting(t *testing.T) {
+	p := newTestProgramNode(t)
+	p.SetRetryPolicy(testRetryPolicy)
+	p.conns.dialOpts = append(p.conns.dialOpts, grpc.WithContextDialer(testDialer))
+	var waited []tis.Port
+	p.waits.observe = func(port tis.Port, d time.Duration) { waited = append(waited, port) }
+	if err := p.LoadProgram("PUSH 1, " + testStack); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing serves the stack so push waits until retries give up
+	p.machineMux.Lock()
+	err := p.update(context.Background(), 0)
+	p.machineMux.Unlock()
+	if err == nil {
+		t.Fatal("update() pushed to stack that is not served")
+	}
+	if want := []tis.Port{{Kind: tis.PushPort, Node: testStack}}; !reflect.DeepEqual(waited, want) {
+		t.Errorf("waited on %v, want %v", waited, want)
+	}
+	if wait, _ := p.GetWait(context.Background(), &empty.Empty{}); wait.Blocked {
+		t.Errorf("GetWait() = %v after push finished", wait)
+	}
+}
@@ -0,0 +1,549 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var watchRe = regexp.MustCompile(`^\s*(\w+)\s*(==|!=|<=|>=|<|>)\s*(-?\d+)\s*$`)
+
+// watch stops a program node when a condition on ACC or BAK becomes true
+type watch struct {
+	register string
+	op       string
+	value    int64
+
+	// Whether condition held after last instruction
+	last bool
+}
+
+// parseWatch parses condition such as "ACC > 10"
+func parseWatch(s string) (*watch, error) {
+	m := watchRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("'%s' not a valid watch; use <ACC/BAK> <OP> <VAL>", s)
+	}
+	r := strings.ToUpper(m[1])
+	if r != "ACC" && r != "BAK" {
+		return nil, fmt.Errorf("cannot watch '%s'; use ACC or BAK", m[1])
+	}
+	v, err := strconv.ParseInt(m[3], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' not a valid number", m[3])
+	}
+	return &watch{register: r, op: m[2], value: v}, nil
+}
+
+func (w *watch) String() string {
+	return fmt.Sprintf("%s %s %v", w.register, w.op, w.value)
+}
+
+// holds checks if condition is true for ACC and BAK
+func (w *watch) holds(acc, bak int64) bool {
+	v := bak
+	if w.register == "ACC" {
+		v = acc
+	}
+	switch w.op {
+	case "==":
+		return v == w.value
+	case "!=":
+		return v != w.value
+	case "<":
+		return v < w.value
+	case "<=":
+		return v <= w.value
+	case ">":
+		return v > w.value
+	case ">=":
+		return v >= w.value
+	}
+	return false
+}
+
+// atBreakpoint checks if node should stop before running current instruction.
+// The first instruction after a run never stops so a node can resume from a breakpoint.
+// Must hold machineMux.
+func (p *ProgramNode) atBreakpoint() bool {
+	p.debugMux.Lock()
+	skip := p.skipBreak
+	p.skipBreak = false
+	p.debugMux.Unlock()
+	return !skip && p.hasBreakpoint()
+}
+
+// hasBreakpoint checks if there is a breakpoint on line of current instruction.
+// Lines expanding to many instructions only stop at the first. Must hold machineMux.
+func (p *ProgramNode) hasBreakpoint() bool {
+	line := p.line()
+	if ptr := p.machine.Ptr; ptr > 0 && p.machine.Program().Instructions[ptr-1].Pos.Line == line {
+		return false
+	}
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	if !p.breakpoints[line] {
+		return false
+	}
+	p.stopReason = fmt.Sprintf("breakpoint at line %v", line)
+	return true
+}
+
+// checkWatches checks if any watch became true after last instruction. Must hold machineMux.
+func (p *ProgramNode) checkWatches() bool {
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	triggered := false
+	for _, w := range p.watches {
+		holds := w.holds(p.machine.Acc, p.machine.Bak)
+		if holds && !w.last && !triggered {
+			p.stopReason = fmt.Sprintf("watch %s", w)
+			triggered = true
+		}
+		w.last = holds
+	}
+	return triggered
+}
+
+// setStopReason records why node stopped
+func (p *ProgramNode) setStopReason(reason string) {
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	p.stopReason = reason
+}
+
+// debugState gets registers and position of node as of last instruction
+func (p *ProgramNode) debugState() *pb.DebugState {
+	m := p.machineView()
+	life := p.life.State()
+	registers := p.regs()
+
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	state := &pb.DebugState{
+		Ptr:         int32(m.ptr),
+		Line:        int32(m.instr.Pos.Line),
+		Instruction: m.instr.String(),
+		Acc:         m.acc,
+		Bak:         m.bak,
+		Running:     life.running(),
+		Reason:      p.stopReason,
+		State:       life.String(),
+	}
+	for i, r := range registers {
+		v, full := r.Peek()
+		state.Registers = append(state.Registers, &pb.RegisterState{
+			Name:  (tis.R0 + tis.Register(i)).String(),
+			Value: v,
+			Full:  full,
+		})
+	}
+	for line := range p.breakpoints {
+		state.Breakpoints = append(state.Breakpoints, int32(line))
+	}
+	sort.Slice(state.Breakpoints, func(i, j int) bool { return state.Breakpoints[i] < state.Breakpoints[j] })
+	for _, w := range p.watches {
+		state.Watches = append(state.Watches, w.String())
+	}
+	return state
+}
+
+// programDebugger serves debug requests for program node
+type programDebugger struct {
+	p *ProgramNode
+
+	pb.UnimplementedDebugServer
+}
+
+// Step handles request to run instructions on paused node.
+// Stops early at breakpoints, watches, faults or if node would block in lockstep mode.
+// Instructions waiting on ports give up once the request ends or the node is reset or loaded.
+func (d *programDebugger) Step(ctx context.Context, in *pb.StepMessage) (*pb.DebugState, error) {
+	p := d.p
+	p.runMux.Lock()
+	switch state := p.life.State(); {
+	case state.running():
+		p.runMux.Unlock()
+		return nil, status.Error(codes.FailedPrecondition, "node is running; pause it first")
+	case state == StateFaulted:
+		p.runMux.Unlock()
+		return nil, errFaulted
+	}
+	if _, err := p.life.set(StatePaused); err != nil {
+		p.runMux.Unlock()
+		return nil, err
+	}
+	stepCtx, cancel := joinContext(ctx, p.life.Context())
+	defer cancel()
+	p.machineMux.Lock()
+	defer p.machineMux.Unlock()
+	// Reset, Run and Load must not wait for an instruction waiting on ports
+	p.runMux.Unlock()
+
+	count := int(in.Count)
+	if count <= 0 {
+		count = 1
+	}
+	p.setStopReason("step")
+	for i := 0; i < count && p.life.State() == StatePaused; i++ {
+		err := p.update(stepCtx, p.cycle)
+		if err == tis.ErrBlocked {
+			p.setStopReason("blocked")
+			break
+		}
+		if isFault(err) {
+			p.setFault(err)
+			break
+		}
+		if err != nil {
+			if stepCtx.Err() != nil {
+				return nil, status.Error(codes.Canceled, "step cancelled")
+			}
+			return nil, err
+		}
+		if p.checkWatches() {
+			break
+		}
+		if p.hasBreakpoint() {
+			break
+		}
+	}
+	return p.debugState(), nil
+}
+
+// SetBreakpoint handles request to stop before running line
+func (d *programDebugger) SetBreakpoint(ctx context.Context, in *pb.BreakpointMessage) (*empty.Empty, error) {
+	p := d.p
+	if !hasLine(p.machineView().prog, int(in.Line)) {
+		return nil, status.Errorf(codes.InvalidArgument, "line %v has no instruction", in.Line)
+	}
+	p.debugMux.Lock()
+	p.breakpoints[int(in.Line)] = true
+	p.debugMux.Unlock()
+	logging.FromContext(ctx, p.logger).Info("breakpoint set", "line", in.Line)
+	return &empty.Empty{}, nil
+}
+
+// ClearBreakpoint handles request to remove breakpoint
+func (d *programDebugger) ClearBreakpoint(ctx context.Context, in *pb.BreakpointMessage) (*empty.Empty, error) {
+	p := d.p
+	p.debugMux.Lock()
+	if in.Line == 0 {
+		p.breakpoints = make(map[int]bool)
+	} else {
+		delete(p.breakpoints, int(in.Line))
+	}
+	p.debugMux.Unlock()
+	return &empty.Empty{}, nil
+}
+
+// hasLine checks if any instruction of prog comes from source line
+func hasLine(prog *tis.Program, line int) bool {
+	for _, instr := range prog.Instructions {
+		if instr.Pos.Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWatch handles request to stop when condition becomes true
+func (d *programDebugger) SetWatch(ctx context.Context, in *pb.WatchMessage) (*empty.Empty, error) {
+	p := d.p
+	w, err := parseWatch(in.Condition)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	m := p.machineView()
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	for _, other := range p.watches {
+		if other.String() == w.String() {
+			return &empty.Empty{}, nil
+		}
+	}
+	w.last = w.holds(m.acc, m.bak)
+	p.watches = append(p.watches, w)
+	logging.FromContext(ctx, p.logger).Info("watch set", "condition", w)
+	return &empty.Empty{}, nil
+}
+
+// ClearWatch handles request to remove watch
+func (d *programDebugger) ClearWatch(ctx context.Context, in *pb.WatchMessage) (*empty.Empty, error) {
+	p := d.p
+	if in.Condition == "" {
+		p.debugMux.Lock()
+		p.watches = nil
+		p.debugMux.Unlock()
+		return &empty.Empty{}, nil
+	}
+
+	w, err := parseWatch(in.Condition)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	for i, other := range p.watches {
+		if other.String() == w.String() {
+			p.watches = append(p.watches[:i], p.watches[i+1:]...)
+			break
+		}
+	}
+	return &empty.Empty{}, nil
+}
+
+// Continue handles request to resume node stopped by debugger
+func (d *programDebugger) Continue(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
+	if d.p.life.running() {
+		return nil, status.Error(codes.FailedPrecondition, "node is already running")
+	}
+	return d.p.Run(ctx, in)
+}
+
+// GetState handles request for registers and position of node
+func (d *programDebugger) GetState(ctx context.Context, in *empty.Empty) (*pb.DebugState, error) {
+	return d.p.debugState(), nil
+}
+
+// clientRegisterState structures network register in debug response to client
+type clientRegisterState struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+	Full  bool   `json:"full"`
+}
+
+// clientDebugState structures debug response to client
+type clientDebugState struct {
+	Ptr         int32                 `json:"ptr"`
+	Line        int32                 `json:"line"`
+	Instruction string                `json:"instruction"`
+	Acc         int64                 `json:"acc"`
+	Bak         int64                 `json:"bak"`
+	Registers   []clientRegisterState `json:"registers"`
+	Running     bool                  `json:"running"`
+	Reason      string                `json:"reason,omitempty"`
+	State       string                `json:"state"`
+	Breakpoints []int32               `json:"breakpoints"`
+	Watches     []string              `json:"watches"`
+}
+
+// clientStackState structures stack debug response to client
+type clientStackState struct {
+	Values []int64 `json:"values"`
+}
+
+// newClientDebugState converts debug state for client
+func newClientDebugState(s *pb.DebugState) clientDebugState {
+	res := clientDebugState{
+		Ptr:         s.Ptr,
+		Line:        s.Line,
+		Instruction: s.Instruction,
+		Acc:         s.Acc,
+		Bak:         s.Bak,
+		Running:     s.Running,
+		Reason:      s.Reason,
+		State:       s.State,
+		Registers:   []clientRegisterState{},
+		Breakpoints: append([]int32{}, s.Breakpoints...),
+		Watches:     append([]string{}, s.Watches...),
+	}
+	for _, r := range s.Registers {
+		res.Registers = append(res.Registers, clientRegisterState{Name: r.Name, Value: r.Value, Full: r.Full})
+	}
+	return res
+}
+
+// handleDebug registers client endpoints that proxy to debug service on program nodes
+func (m *MasterNode) handleDebug() {
+	http.HandleFunc("/debug/state", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			var state *pb.DebugState
+			err := m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				var err error
+				state, err = c.GetState(ctx, &empty.Empty{})
+				return err
+			})
+			m.writeDebugState(w, r, state, err)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/debug/step", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
+			}
+			count := 1
+			if s := r.FormValue("count"); s != "" {
+				v, err := strconv.Atoi(s)
+				if err != nil || v < 1 {
+					http.Error(w, "cannot parse count", http.StatusBadRequest)
+					return
+				}
+				count = v
+			}
+
+			var state *pb.DebugState
+			err := m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				var err error
+				state, err = c.Step(ctx, &pb.StepMessage{Count: int32(count)})
+				return err
+			})
+			m.writeDebugState(w, r, state, err)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/debug/breakpoint", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "cannot parse form", http.StatusBadRequest)
+			return
+		}
+		var line int
+		if s := r.FormValue("line"); s != "" || r.Method == "POST" {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				http.Error(w, "cannot parse line", http.StatusBadRequest)
+				return
+			}
+			line = v
+		}
+
+		var err error
+		switch r.Method {
+		case "POST":
+			err = m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				_, err := c.SetBreakpoint(ctx, &pb.BreakpointMessage{Line: int32(line)})
+				return err
+			})
+		case "DELETE":
+			err = m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				_, err := c.ClearBreakpoint(ctx, &pb.BreakpointMessage{Line: int32(line)})
+				return err
+			})
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		m.writeDebugResult(w, r, err)
+	})
+
+	http.HandleFunc("/debug/watch", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "cannot parse form", http.StatusBadRequest)
+			return
+		}
+		condition := r.FormValue("condition")
+
+		var err error
+		switch r.Method {
+		case "POST":
+			err = m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				_, err := c.SetWatch(ctx, &pb.WatchMessage{Condition: condition})
+				return err
+			})
+		case "DELETE":
+			err = m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				_, err := c.ClearWatch(ctx, &pb.WatchMessage{Condition: condition})
+				return err
+			})
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		m.writeDebugResult(w, r, err)
+	})
+
+	http.HandleFunc("/debug/stack", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			targetURI := r.FormValue("targetURI")
+			if info, ok := m.nodeInfo[targetURI]; !ok || info.Type != "stack" {
+				http.Error(w, fmt.Sprintf("stack node %s not valid on this network", targetURI), http.StatusBadRequest)
+				return
+			}
+
+			var res *pb.StackMessage
+			err := m.call(r.Context(), targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				res, err = pb.NewStackClient(conn).GetStack(ctx, &empty.Empty{})
+				return err
+			})
+			if err != nil {
+				m.writeDebugResult(w, r, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(clientStackState{Values: append([]int64{}, res.Values...)})
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/debug/continue", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
+			}
+			err := m.callDebug(r.Context(), r.FormValue("targetURI"), func(ctx context.Context, c pb.DebugClient) error {
+				_, err := c.Continue(ctx, &empty.Empty{})
+				return err
+			})
+			m.writeDebugResult(w, r, err)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// callDebug calls debug service on program node
+func (m *MasterNode) callDebug(ctx context.Context, targetURI string, call func(ctx context.Context, c pb.DebugClient) error) error {
+	if info, ok := m.nodeInfo[targetURI]; !ok || info.Type != "program" {
+		return fmt.Errorf("program node %s not valid on this network", targetURI)
+	}
+	return m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		return call(ctx, pb.NewDebugClient(conn))
+	})
+}
+
+// writeDebugState writes debug state to client as JSON
+func (m *MasterNode) writeDebugState(w http.ResponseWriter, r *http.Request, state *pb.DebugState, err error) {
+	if err != nil {
+		m.writeDebugResult(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newClientDebugState(state))
+}
+
+// writeDebugResult writes result of debug request to client
+func (m *MasterNode) writeDebugResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		logging.FromContext(r.Context(), m.logger).Error("could not debug node", "err", err)
+		http.Error(w, fmt.Sprintf("error debugging node: %s", status.Convert(err).Message()), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "Success")
+}
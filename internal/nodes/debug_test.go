@@ -0,0 +1,113 @@
+package nodes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBreakpointsUseSourceLines(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProgramNode(t)
+	d := &programDebugger{p: p}
+	err := p.LoadProgram("#macro twice(x)\nADD x\nADD x\n#endmacro\nADD 1\ntwice 2\nADD 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := p.GetProgram(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int32{5, 6, 6, 7}; !reflect.DeepEqual(loaded.Lines, want) {
+		t.Errorf("GetProgram() lines = %v, want %v", loaded.Lines, want)
+	}
+
+	if _, err := d.SetBreakpoint(ctx, &pb.BreakpointMessage{Line: 2}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetBreakpoint(2) error = %v, want InvalidArgument", err)
+	}
+	if _, err := d.SetBreakpoint(ctx, &pb.BreakpointMessage{Line: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stops before first instruction of line 6, then only once per pass
+	for _, want := range []struct{ ptr, acc int64 }{{1, 1}, {1, 9}, {1, 17}} {
+		state, err := d.Step(ctx, &pb.StepMessage{Count: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(state.Ptr) != want.ptr || state.Line != 6 || state.Acc != want.acc {
+			t.Errorf("Step() = ptr %v, line %v, acc %v, want ptr %v, line 6, acc %v", state.Ptr, state.Line, state.Acc, want.ptr, want.acc)
+		}
+	}
+}
+
+func TestFaultLineIsSourceLine(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProgramNode(t)
+	d := &programDebugger{p: p}
+	if err := p.LoadProgram("#define ZERO 0\n\nMOV 1, ACC\nDIV ZERO"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Step(ctx, &pb.StepMessage{Count: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if fault, err := p.GetFault(ctx, &empty.Empty{}); err != nil || fault.Line != 4 {
+		t.Errorf("GetFault() = %v, %v, want line 4", fault, err)
+	}
+}
+
+func TestStepGivesUpWaitingForPort(t *testing.T) {
+	p := newTestProgramNode(t)
+	d := &programDebugger{p: p}
+	if err := p.LoadProgram("MOV R0, ACC"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := d.Step(ctx, &pb.StepMessage{}); status.Code(err) != codes.Canceled {
+		t.Fatalf("Step() error = %v once request ended, want Canceled", err)
+	}
+
+	// Reset ends step waiting on register instead of waiting for it
+	stepped := make(chan error, 1)
+	go func() {
+		_, err := d.Step(context.Background(), &pb.StepMessage{})
+		stepped <- err
+	}()
+	for !p.waits.message().Blocked {
+		time.Sleep(time.Millisecond)
+	}
+	reset := make(chan struct{})
+	go func() {
+		p.Reset(context.Background(), &empty.Empty{})
+		close(reset)
+	}()
+	select {
+	case <-reset:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reset() waited for step")
+	}
+	if err := <-stepped; status.Code(err) != codes.Canceled {
+		t.Fatalf("Step() error = %v after reset, want Canceled", err)
+	}
+
+	// Instruction given up is run again by next step
+	if err := p.regs()[0].Put(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	state, err := d.Step(context.Background(), &pb.StepMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Acc != 5 {
+		t.Errorf("Step() acc = %v, want 5", state.Acc)
+	}
+}
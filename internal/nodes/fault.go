@@ -0,0 +1,200 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errFaulted is returned by requests to run a faulted node
+var errFaulted = status.Error(codes.FailedPrecondition, "node is faulted; clear fault first")
+
+// isFault checks if err from running instruction faults node.
+// Nodes fault on runtime errors and on peers that stay unreachable.
+func isFault(err error) bool {
+	var unreachable *unreachableError
+	if _, ok := err.(*tis.Fault); ok {
+		return true
+	}
+	return errors.As(err, &unreachable)
+}
+
+// setFault stops node and marks it as faulted on current instruction. Must hold machineMux.
+func (p *ProgramNode) setFault(err error) {
+	p.debugMux.Lock()
+	p.fault = err.Error()
+	p.faultLine = p.line()
+	p.stopReason = fmt.Sprintf("fault: %s", p.fault)
+	p.debugMux.Unlock()
+	p.life.set(StateFaulted)
+}
+
+// clearFault forgets why node faulted
+func (p *ProgramNode) clearFault() {
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	if p.fault != "" {
+		p.stopReason = ""
+	}
+	p.fault = ""
+	p.faultLine = 0
+}
+
+// faulted checks if node is faulted
+func (p *ProgramNode) faulted() bool {
+	return p.life.State() == StateFaulted
+}
+
+// faultReason gets why node faulted
+func (p *ProgramNode) faultReason() string {
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	return p.fault
+}
+
+// GetFault handles request for why node faulted
+func (p *ProgramNode) GetFault(ctx context.Context, in *empty.Empty) (*pb.FaultMessage, error) {
+	faulted := p.faulted()
+	p.debugMux.Lock()
+	defer p.debugMux.Unlock()
+	return &pb.FaultMessage{
+		Faulted: faulted,
+		Reason:  p.fault,
+		Line:    int32(p.faultLine),
+	}, nil
+}
+
+// ClearFault handles request to clear fault so node can run again.
+// Node is paused on instruction it faulted on and retries it when run.
+func (p *ProgramNode) ClearFault(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
+	if p.life.swap(StateFaulted, StatePaused) {
+		p.clearFault()
+		logging.FromContext(ctx, p.logger).Info("fault was cleared", "cmd", "clearFault")
+	}
+	return &empty.Empty{}, nil
+}
+
+// clientFault structures fault of a program node sent to client
+type clientFault struct {
+	Reason string `json:"reason"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// handleFaults registers client endpoint for faulted nodes
+func (m *MasterNode) handleFaults() {
+	http.HandleFunc("/faults", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			faults := m.collectFaults(r.Context())
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(faults)
+		case "DELETE":
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
+			}
+			if err := m.clearFaults(r.Context(), r.FormValue("targetURI")); err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not clear faults", "err", err)
+				http.Error(w, fmt.Sprintf("error clearing faults: %s", status.Convert(err).Message()), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, "Success")
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// collectFaults gets faults of program nodes keyed by node.
+// Nodes that cannot be reached are reported as faulted.
+func (m *MasterNode) collectFaults(ctx context.Context) map[string]clientFault {
+	type result struct {
+		node  string
+		fault *pb.FaultMessage
+		err   error
+	}
+
+	var targets []string
+	for k, v := range m.nodeInfo {
+		if v.Type == "program" {
+			targets = append(targets, k)
+		}
+	}
+
+	c := make(chan result)
+	for _, targetURI := range targets {
+		go func(targetURI string) {
+			var fault *pb.FaultMessage
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				fault, err = pb.NewProgramClient(conn).GetFault(ctx, &empty.Empty{})
+				return err
+			})
+			c <- result{node: targetURI, fault: fault, err: err}
+		}(targetURI)
+	}
+
+	faults := make(map[string]clientFault)
+	for range targets {
+		r := <-c
+		switch {
+		case r.err != nil:
+			faults[r.node] = clientFault{Reason: r.err.Error()}
+		case r.fault.Faulted:
+			faults[r.node] = clientFault{Reason: r.fault.Reason, Line: int(r.fault.Line)}
+		}
+	}
+	return faults
+}
+
+// clearFaults clears fault on program node at targetURI, or on every program node and master if empty
+func (m *MasterNode) clearFaults(ctx context.Context, targetURI string) error {
+	var targets []string
+	if targetURI != "" {
+		if info, ok := m.nodeInfo[targetURI]; !ok || info.Type != "program" {
+			return fmt.Errorf("program node %s not valid on this network", targetURI)
+		}
+		targets = append(targets, targetURI)
+	} else {
+		for k, v := range m.nodeInfo {
+			if v.Type == "program" {
+				targets = append(targets, k)
+			}
+		}
+	}
+
+	c := make(chan error)
+	for _, targetURI := range targets {
+		go func(targetURI string) {
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				_, err := pb.NewProgramClient(conn).ClearFault(ctx, &empty.Empty{})
+				return err
+			})
+			if err != nil {
+				err = fmt.Errorf("node %s: %s", targetURI, err.Error())
+			}
+			c <- err
+		}(targetURI)
+	}
+
+	var clearErr error
+	for range targets {
+		if err := <-c; err != nil && clearErr == nil {
+			clearErr = err
+		}
+	}
+	if clearErr == nil && targetURI == "" {
+		m.life.swap(StateFaulted, StatePaused)
+	}
+	return clearErr
+}
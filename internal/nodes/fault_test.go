@@ -0,0 +1,125 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsFault(t *testing.T) {
+	unreachable := &unreachableError{target: "peer:8000", err: &dialError{errors.New("timed out")}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"runtime fault", &tis.Fault{Op: tis.DIV, Message: "division by zero"}, true},
+		{"unreachable peer", unreachable, true},
+		{"wrapped unreachable peer", fmt.Errorf("push: %w", unreachable), true},
+		{"unavailable peer", status.Error(codes.Unavailable, "connection refused"), false},
+		{"blocked", tis.ErrBlocked, false},
+		{"canceled", context.Canceled, false},
+		{"no error", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFault(tt.err); got != tt.want {
+				t.Errorf("isFault(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearFault(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProgramNode(t)
+	p.SetLogger(logging.New(ioutil.Discard, logging.LevelError, logging.FormatLogfmt))
+	if err := p.LoadProgram("MOV 1, ACC\nDIV 0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clearing node that is not faulted does nothing
+	if _, err := p.ClearFault(ctx, &empty.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	if state := p.life.State(); state != StateLoaded {
+		t.Fatalf("state = %v after clearing without fault, want %v", state, StateLoaded)
+	}
+
+	p.machineMux.Lock()
+	p.runInstruction(ctx)
+	p.runInstruction(ctx)
+	p.machineMux.Unlock()
+
+	fault, err := p.GetFault(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fault.Faulted || fault.Line != 2 || !strings.Contains(fault.Reason, "division by zero") {
+		t.Errorf("GetFault() = %v, want faulted on line 2 by division by zero", fault)
+	}
+	if state := p.life.State(); state != StateFaulted {
+		t.Errorf("state = %v, want %v", state, StateFaulted)
+	}
+	if _, err := p.Run(ctx, &empty.Empty{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Run() error = %v while faulted, want FailedPrecondition", err)
+	}
+
+	if _, err := p.ClearFault(ctx, &empty.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	fault, err = p.GetFault(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fault.Faulted || fault.Reason != "" || fault.Line != 0 {
+		t.Errorf("GetFault() = %v after clear, want no fault", fault)
+	}
+	if state := p.life.State(); state != StatePaused {
+		t.Errorf("state = %v after clear, want %v", state, StatePaused)
+	}
+
+	// Node stays on instruction it faulted on
+	p.machineMux.Lock()
+	line := p.line()
+	p.machineMux.Unlock()
+	if line != 2 {
+		t.Errorf("line = %d after clear, want 2", line)
+	}
+}
+
+func TestUnreachablePeerFaultsNode(t *testing.T) {
+	ctx := testContext(t)
+	p := newTestProgramNode(t)
+	p.SetLogger(logging.New(ioutil.Discard, logging.LevelError, logging.FormatLogfmt))
+	p.SetRetryPolicy(testRetryPolicy)
+	p.conns.dialOpts = append(p.conns.dialOpts, grpc.WithContextDialer(testDialer))
+	if err := p.LoadProgram("PUSH 1, nowhere"); err != nil {
+		t.Fatal(err)
+	}
+
+	p.machineMux.Lock()
+	p.runInstruction(ctx)
+	p.machineMux.Unlock()
+
+	fault, err := p.GetFault(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fault.Faulted || fault.Line != 1 || !strings.Contains(fault.Reason, "unreachable") {
+		t.Errorf("GetFault() = %v, want faulted on line 1 by unreachable node", fault)
+	}
+	if state := p.life.State(); state != StateFaulted {
+		t.Errorf("state = %v, want %v", state, StateFaulted)
+	}
+}
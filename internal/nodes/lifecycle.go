@@ -0,0 +1,258 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NodeState is a stage in the lifecycle of a node
+type NodeState int
+
+// Node states. Not every node type uses every state.
+const (
+	// StateIdle has nothing loaded
+	StateIdle NodeState = iota
+	// StateLoaded has a program loaded and has not run since it was reset
+	StateLoaded
+	// StateRunning is running freely
+	StateRunning
+	// StatePaused was stopped and can be run again
+	StatePaused
+	// StateBlocked is running but waiting on a port
+	StateBlocked
+	// StateFaulted was stopped by a fault and cannot run until it is cleared or reset
+	StateFaulted
+	// StateHalted stopped on its own after finishing, like a clock that ran all its cycles
+	StateHalted
+)
+
+var stateNames = [...]string{"idle", "loaded", "running", "paused", "blocked", "faulted", "halted"}
+
+func (s NodeState) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[s]
+}
+
+// transitions are states each state can move to.
+// Moving to the same state is always allowed.
+var transitions = map[NodeState][]NodeState{
+	StateIdle:    {StateLoaded, StateRunning, StatePaused, StateFaulted},
+	StateLoaded:  {StateIdle, StateRunning, StatePaused, StateFaulted},
+	StateRunning: {StateIdle, StateLoaded, StatePaused, StateBlocked, StateFaulted, StateHalted},
+	StateBlocked: {StateIdle, StateLoaded, StateRunning, StatePaused, StateFaulted},
+	StatePaused:  {StateIdle, StateLoaded, StateRunning, StateFaulted},
+	StateFaulted: {StateIdle, StateLoaded, StatePaused},
+	StateHalted:  {StateIdle, StateLoaded, StateRunning, StatePaused},
+}
+
+// running checks if state is running freely
+func (s NodeState) running() bool {
+	return s == StateRunning || s == StateBlocked
+}
+
+// lifecycle is the state of a node, safe to change from any goroutine.
+// Each run has a context that is done once the node stops running, faults or resets.
+type lifecycle struct {
+	mux    sync.Mutex
+	state  NodeState
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// changed is closed and replaced whenever state changes
+	changed chan struct{}
+}
+
+// newLifecycle creates lifecycle starting in state
+func newLifecycle(state NodeState) *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{state: state, ctx: ctx, cancel: cancel, changed: make(chan struct{})}
+}
+
+// State gets current state
+func (l *lifecycle) State() NodeState {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.state
+}
+
+// running checks if node is running freely
+func (l *lifecycle) running() bool {
+	return l.State().running()
+}
+
+// Context gets context of current run.
+// While node is not running it is done once node next stops, faults or resets.
+func (l *lifecycle) Context() context.Context {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.ctx
+}
+
+// joinContext gets context that is done once either ctx or other is done
+func joinContext(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-other.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// set moves node to state if lifecycle allows it. Returns state node was in.
+func (l *lifecycle) set(state NodeState) (NodeState, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	prev := l.state
+	if prev != state && !allowed(prev, state) {
+		return prev, transitionError(prev)
+	}
+	l.move(state)
+	return prev, nil
+}
+
+// swap moves node to state only if it is in from
+func (l *lifecycle) swap(from, state NodeState) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.state != from {
+		return false
+	}
+	l.move(state)
+	return true
+}
+
+// stop moves node to state if it is running. Returns whether it was.
+func (l *lifecycle) stop(state NodeState) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if !l.state.running() {
+		return false
+	}
+	l.move(state)
+	return true
+}
+
+// waitRunning waits until node runs and gets context of run
+func (l *lifecycle) waitRunning() context.Context {
+	for {
+		l.mux.Lock()
+		if l.state.running() {
+			ctx := l.ctx
+			l.mux.Unlock()
+			return ctx
+		}
+		changed := l.changed
+		l.mux.Unlock()
+		<-changed
+	}
+}
+
+// move changes state and ends current run unless node keeps running. Must hold mux.
+func (l *lifecycle) move(state NodeState) {
+	if !state.running() {
+		l.cancel()
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+	if l.state != state {
+		l.state = state
+		close(l.changed)
+		l.changed = make(chan struct{})
+	}
+}
+
+// allowed checks if node in state from can move to state to
+func allowed(from, to NodeState) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionError is returned for moves a node in state cannot make
+func transitionError(state NodeState) error {
+	if state == StateFaulted {
+		return errFaulted
+	}
+	return status.Errorf(codes.FailedPrecondition, "node is %s", state)
+}
+
+// clientStateResponse structures lifecycle state of network sent to client.
+// Nodes that cannot be reached are "unknown".
+type clientStateResponse struct {
+	State string            `json:"state"`
+	Nodes map[string]string `json:"nodes"`
+}
+
+// handleState registers client endpoint for lifecycle state of every node
+func (m *MasterNode) handleState() {
+	http.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(m.collectStates(r.Context()))
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// collectStates gets lifecycle state of master and every node
+func (m *MasterNode) collectStates(ctx context.Context) *clientStateResponse {
+	type result struct {
+		node  string
+		state string
+		err   error
+	}
+
+	c := make(chan result)
+	for k, v := range m.nodeInfo {
+		go func(targetURI string, info NodeInfo) {
+			var res *pb.NodeStateMessage
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				switch info.Type {
+				case "program":
+					res, err = pb.NewProgramClient(conn).GetNodeState(ctx, &empty.Empty{})
+				case "stack":
+					res, err = pb.NewStackClient(conn).GetNodeState(ctx, &empty.Empty{})
+				default:
+					err = fmt.Errorf("invalid node type")
+				}
+				return err
+			})
+			if err != nil {
+				c <- result{node: targetURI, err: err}
+				return
+			}
+			c <- result{node: targetURI, state: res.State}
+		}(k, v)
+	}
+
+	res := &clientStateResponse{State: m.life.State().String(), Nodes: make(map[string]string)}
+	for range m.nodeInfo {
+		r := <-c
+		if r.err != nil {
+			logging.FromContext(ctx, m.logger).Warn("could not get node state", "target", r.node, "err", r.err)
+			r.state = "unknown"
+		}
+		res.Nodes[r.node] = r.state
+	}
+	return res
+}
@@ -0,0 +1,245 @@
+package nodes
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+// testOp is a request made to a test network. Errors are expected and ignored.
+type testOp func(ctx context.Context)
+
+// programOps makes every kind of request to a program node
+func programOps(t *testing.T, p *ProgramNode, program string) []testOp {
+	prog, err := tis.Assemble(program, tis.PreprocessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytecode := tis.Encode(prog)
+	d := &programDebugger{p: p}
+	var cycle int64
+	return []testOp{
+		func(ctx context.Context) { p.Run(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { p.Pause(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { p.Reset(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { p.LoadBytecode(ctx, &pb.BytecodeMessage{Bytecode: bytecode}) },
+		func(ctx context.Context) { d.Step(ctx, &pb.StepMessage{Count: 3}) },
+		func(ctx context.Context) { p.Tick(ctx, &pb.CycleMessage{Cycle: atomic.AddInt64(&cycle, 1)}) },
+		func(ctx context.Context) { d.GetState(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { p.Send(ctx, &pb.SendMessage{Value: 1}) },
+		func(ctx context.Context) { p.Send(ctx, &pb.SendMessage{Value: 1, Any: true}) },
+		func(ctx context.Context) { p.GetNodeState(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { p.GetWait(ctx, &empty.Empty{}) },
+	}
+}
+
+// stackOps makes every kind of request to a stack node
+func stackOps(s *StackNode) []testOp {
+	return []testOp{
+		func(ctx context.Context) { s.Run(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { s.Pause(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { s.Reset(ctx, &empty.Empty{}) },
+		func(ctx context.Context) { s.Push(ctx, &pb.ValueMessage{Value: 1}) },
+		func(ctx context.Context) { s.Pop(ctx, &pb.CycleMessage{}) },
+		func(ctx context.Context) { s.GetStack(ctx, &empty.Empty{}) },
+	}
+}
+
+// masterOps makes every kind of request to a master node and through it to the network
+func masterOps(m *MasterNode) []testOp {
+	return []testOp{
+		func(ctx context.Context) { m.broadcastCommand(ctx, "run") },
+		func(ctx context.Context) { m.broadcastCommand(ctx, "pause") },
+		func(ctx context.Context) { m.broadcastCommand(ctx, "reset") },
+		func(ctx context.Context) { m.life.set(StateRunning) },
+		func(ctx context.Context) { m.life.stop(StatePaused) },
+		func(ctx context.Context) { m.resetNode() },
+		func(ctx context.Context) { m.collectStates(ctx) },
+		func(ctx context.Context) { m.GetInput(ctx, &pb.CycleMessage{}) },
+		func(ctx context.Context) { m.SendOutput(ctx, &pb.ValueMessage{Value: 1}) },
+	}
+}
+
+func TestLifecycleConcurrentRequests(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t)
+
+	// Values flow between every node while requests change their state
+	program := "IN ACC\nMOV ACC, peer:R0\nMOV R1, ACC\nPUSH ACC, stack\nPOP stack, ACC\nOUT ACC"
+	peer := "MOV R0, ACC\nADD 1\nMOV ACC, localhost:R1"
+	if err := n.program.LoadProgram(program); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.peer.LoadProgram(peer); err != nil {
+		t.Fatal(err)
+	}
+	var ops []testOp
+	ops = append(ops, programOps(t, n.program, program)...)
+	ops = append(ops, programOps(t, n.peer, peer)...)
+	ops = append(ops, stackOps(n.stack)...)
+	ops = append(ops, masterOps(n.master)...)
+
+	flowCtx, stopFlow := context.WithCancel(ctx)
+	var flow sync.WaitGroup
+	flow.Add(2)
+	go func() {
+		defer flow.Done()
+		for v := 0; ; v++ {
+			select {
+			case n.master.inChan <- v:
+			case <-flowCtx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer flow.Done()
+		for {
+			select {
+			case <-n.master.outChan:
+			case <-flowCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < 200; i++ {
+				opCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+				ops[r.Intn(len(ops))](opCtx)
+				cancel()
+			}
+		}(int64(w))
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("requests did not finish")
+	}
+	stopFlow()
+	flow.Wait()
+
+	// Network works again once reset
+	if err := n.master.broadcastCommand(ctx, "reset"); err != nil {
+		t.Fatal(err)
+	}
+	n.master.resetNode()
+	states := n.master.collectStates(ctx)
+	want := map[string]string{testProgram: "loaded", testPeer: "loaded", testStack: "idle"}
+	for node, state := range want {
+		if states.Nodes[node] != state {
+			t.Errorf("%s is %s after reset, want %s", node, states.Nodes[node], state)
+		}
+	}
+
+	if err := n.master.broadcastCommand(ctx, "run"); err != nil {
+		t.Fatal(err)
+	}
+	for v := 0; v < 10; v++ {
+		n.master.inChan <- v
+		select {
+		case got := <-n.master.outChan:
+			if got != v+1 {
+				t.Fatalf("got output %d for input %d, want %d", got, v, v+1)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no output for input %d", v)
+		}
+	}
+}
+
+func TestPauseResumesInstructions(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t)
+
+	// Every value is waited on by IN, a MOV to each node, a register read, a PUSH and a POP
+	if err := n.program.LoadProgram("IN ACC\nMOV ACC, peer:R0\nMOV R1, ACC\nPUSH ACC, stack\nPOP stack, ACC\nOUT ACC"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.peer.LoadProgram("MOV R0, ACC\nMOV ACC, localhost:R1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.master.broadcastCommand(ctx, "run"); err != nil {
+		t.Fatal(err)
+	}
+	n.master.life.set(StateRunning)
+
+	// Connect every node before pausing, since dialing only finishes while running
+	n.master.inChan <- 0
+	if got := <-n.master.outChan; got != 0 {
+		t.Fatalf("got output %d, want 0", got)
+	}
+
+	const count = 300
+	toggleCtx, stopToggling := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for v := 1; v <= count; v++ {
+			select {
+			case n.master.inChan <- v:
+			case <-toggleCtx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		r := rand.New(rand.NewSource(1))
+		pause := []func(){
+			func() { n.program.Pause(ctx, &empty.Empty{}) },
+			func() { n.peer.Pause(ctx, &empty.Empty{}) },
+			func() { n.stack.Pause(ctx, &empty.Empty{}) },
+			func() { n.master.life.stop(StatePaused) },
+		}
+		run := []func(){
+			func() { n.program.Run(ctx, &empty.Empty{}) },
+			func() { n.peer.Run(ctx, &empty.Empty{}) },
+			func() { n.stack.Run(ctx, &empty.Empty{}) },
+			func() { n.master.life.set(StateRunning) },
+		}
+		for toggleCtx.Err() == nil {
+			for _, i := range r.Perm(len(pause)) {
+				pause[i]()
+			}
+			time.Sleep(time.Duration(r.Intn(2000)) * time.Microsecond)
+			for _, i := range r.Perm(len(run)) {
+				run[i]()
+			}
+			time.Sleep(time.Duration(r.Intn(2000)) * time.Microsecond)
+		}
+	}()
+
+	for v := 1; v <= count; v++ {
+		select {
+		case got := <-n.master.outChan:
+			if got != v {
+				stopToggling()
+				t.Fatalf("got output %d, want %d", got, v)
+			}
+		case <-ctx.Done():
+			stopToggling()
+			t.Fatalf("no output for input %d", v)
+		}
+	}
+	stopToggling()
+	wg.Wait()
+}
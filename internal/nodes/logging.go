@@ -0,0 +1,72 @@
+package nodes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata and header carrying request ID between nodes
+const (
+	requestIDMetadata = "x-request-id"
+	requestIDHeader   = "X-Request-ID"
+)
+
+// requestIDClientInterceptor passes request ID in ctx on to peers
+func requestIDClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := logging.RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// loggingServerInterceptor gives every request a logger with its request ID and method.
+// Request ID comes from caller if it sent one.
+func loggingServerInterceptor(l *logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(requestIDMetadata)) > 0 {
+			id = md.Get(requestIDMetadata)[0]
+		} else {
+			id = logging.NewRequestID()
+		}
+		ctx = logging.WithRequestID(ctx, id)
+		ctx = logging.NewContext(ctx, l.With("request", id, "method", info.FullMethod))
+		return handler(ctx, req)
+	}
+}
+
+// loggingHandler gives every client request a logger with its request ID and path
+func loggingHandler(l *logging.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		reqLogger := l.With("request", id, "path", r.URL.Path)
+		reqLogger.Debug("handling request", "httpMethod", r.Method)
+		ctx := logging.WithRequestID(r.Context(), id)
+		ctx = logging.NewContext(ctx, reqLogger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetLogger sets logger for program node. Must be called before Start.
+func (p *ProgramNode) SetLogger(l *logging.Logger) {
+	p.logger = l
+}
+
+// SetLogger sets logger for stack node. Must be called before Start.
+func (s *StackNode) SetLogger(l *logging.Logger) {
+	s.logger = l
+}
+
+// SetLogger sets logger for master node. Must be called before Start.
+func (m *MasterNode) SetLogger(l *logging.Logger) {
+	m.logger = l
+}
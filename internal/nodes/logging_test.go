@@ -0,0 +1,73 @@
+package nodes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLoggingHandlerAddsRequestFields(t *testing.T) {
+	var b bytes.Buffer
+	l := logging.New(&b, logging.LevelInfo, logging.FormatLogfmt).With("node", "master", "type", "master")
+	var gotID string
+	h := loggingHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = logging.RequestID(r.Context())
+		logging.FromContext(r.Context(), nil).Info("handled")
+	}))
+
+	// Request ID from client is kept
+	req := httptest.NewRequest("POST", "/run", nil)
+	req.Header.Set(requestIDHeader, "abc")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if gotID != "abc" || w.Header().Get(requestIDHeader) != "abc" {
+		t.Errorf("request ID = %q, header %q, want abc", gotID, w.Header().Get(requestIDHeader))
+	}
+	if want := "node=master type=master request=abc path=/run\n"; !strings.HasSuffix(b.String(), want) {
+		t.Errorf("line = %q, want suffix %q", b.String(), want)
+	}
+
+	// Otherwise one is made
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/stats", nil))
+	if gotID == "" || gotID == "abc" || w.Header().Get(requestIDHeader) != gotID {
+		t.Errorf("request ID = %q, header %q", gotID, w.Header().Get(requestIDHeader))
+	}
+}
+
+func TestLoggingServerInterceptorUsesCallerRequestID(t *testing.T) {
+	var b bytes.Buffer
+	l := logging.New(&b, logging.LevelInfo, logging.FormatLogfmt).With("node", "misaka1", "type", "program")
+	interceptor := loggingServerInterceptor(l)
+	info := &grpc.UnaryServerInfo{FullMethod: "/Program/Run"}
+
+	// Request ID sent by the client interceptor reaches the handler's logger
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	requestIDClientInterceptor(logging.WithRequestID(context.Background(), "abc"), "/Program/Run", nil, nil, nil, invoker)
+	ctx := metadata.NewIncomingContext(context.Background(), outgoing)
+
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if id := logging.RequestID(ctx); id != "abc" {
+			t.Errorf("request ID = %q, want abc", id)
+		}
+		logging.FromContext(ctx, nil).Info("node was run")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "node=misaka1 type=program request=abc method=/Program/Run\n"; !strings.HasSuffix(b.String(), want) {
+		t.Errorf("line = %q, want suffix %q", b.String(), want)
+	}
+}
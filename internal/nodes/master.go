@@ -2,24 +2,36 @@ package nodes
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/metrics"
+	"github.com/jasmaa/misaka-net/internal/tis"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 const (
-	clientPort = ":8000"
-	grpcPort   = ":8001"
+	clientPort  = ":8000"
+	grpcPort    = ":8001"
+	metricsPort = ":9000"
 )
 
+// Input and output buffer size
+const bufferSize = 1
+
 // NodeInfo contains information about nodes
 type NodeInfo struct {
 	Type string `json:"type"`
@@ -27,45 +39,89 @@ type NodeInfo struct {
 
 // MasterNode is a master node
 type MasterNode struct {
-	nodeInfo map[string]NodeInfo
-	inChan   chan int
-	outChan  chan int
+	nodeInfo   map[string]NodeInfo
+	includeDir string
+	inChan     chan int
+	outChan    chan int
+	replays    *replayLog
+
+	life      *lifecycle
+	clockMode string
+	clockMux  sync.Mutex
+	cycle     int64
+
+	bytecodeCache *bytecodeCache
+
+	loaded    map[string][]byte
+	loadedMux sync.Mutex
 
-	ctx       context.Context
-	cancel    context.CancelFunc
-	isRunning bool
+	metrics *metrics.Registry
+	logger  *logging.Logger
+
+	deadlock      *clientDeadlockResponse
+	deadlockFound chan struct{}
+	deadlockMux   sync.Mutex
 
 	certFile, keyFile string
-	dialOpts          []grpc.DialOption
+	conns             *connPool
+	retry             RetryPolicy
 
 	pb.UnimplementedMasterServer
 }
 
 // clientOutResponse structures response to client output request
 type clientOutResponse struct {
-	Value int `json:"value"`
+	Value int                  `json:"value"`
+	Stats *clientStatsResponse `json:"stats,omitempty"`
+}
+
+// clientClockResponse structures response to client clock request
+type clientClockResponse struct {
+	Mode  string `json:"mode"`
+	Cycle int64  `json:"cycle"`
+}
+
+// clientProgram structures program on node sent to client
+// with the source line of each instruction
+type clientProgram struct {
+	Instructions []string `json:"instructions"`
+	Lines        []int32  `json:"lines"`
+}
+
+// clientDiagnosticsResponse structures response to client with program diagnostics
+type clientDiagnosticsResponse struct {
+	Diagnostics tis.Diagnostics `json:"diagnostics"`
 }
 
 // NewMasterNode creates a new master node
-func NewMasterNode(nodeInfo map[string]NodeInfo, certFile, keyFile string) *MasterNode {
-	ctx, cancel := context.WithCancel(context.Background())
+func NewMasterNode(nodeInfo map[string]NodeInfo, includeDir string, certFile, keyFile string) *MasterNode {
 	creds, err := credentials.NewClientTLSFromFile(certFile, "")
 	if err != nil {
 		panic(err)
 	}
-	return &MasterNode{
-		nodeInfo: nodeInfo,
-		inChan:   make(chan int, bufferSize),
-		outChan:  make(chan int, bufferSize),
-		ctx:      ctx,
-		cancel:   cancel,
-		certFile: certFile,
-		keyFile:  keyFile,
-		dialOpts: []grpc.DialOption{
+	m := &MasterNode{
+		nodeInfo:      nodeInfo,
+		includeDir:    includeDir,
+		inChan:        make(chan int, bufferSize),
+		outChan:       make(chan int, bufferSize),
+		replays:       newReplayLog(maxReplaySessions),
+		life:          newLifecycle(StateIdle),
+		clockMode:     clockFree,
+		bytecodeCache: newBytecodeCache(maxCachedPrograms),
+		loaded:        make(map[string][]byte),
+		deadlockFound: make(chan struct{}),
+		certFile:      certFile,
+		keyFile:       keyFile,
+		logger:        logging.Default(),
+		retry:         DefaultRetryPolicy,
+		conns: newConnPool(
 			grpc.WithTransportCredentials(creds),
 			grpc.WithBlock(),
-		},
+			grpc.WithUnaryInterceptor(requestIDClientInterceptor),
+		),
 	}
+	m.metrics = newMasterMetrics(m)
+	return m
 }
 
 // Start starts master node server
@@ -73,29 +129,73 @@ func (m *MasterNode) Start() {
 	go func() {
 		lis, err := net.Listen("tcp", grpcPort)
 		if err != nil {
-			log.Fatalf("failed to listen: %v", err)
+			m.logger.Fatal("failed to listen", "err", err)
 		}
 		creds, err := credentials.NewServerTLSFromFile(m.certFile, m.keyFile)
 		if err != nil {
-			log.Fatalf("failed to get creds: %v", err)
+			m.logger.Fatal("failed to get creds", "err", err)
 		}
-		server := grpc.NewServer(grpc.Creds(creds))
+		server := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(loggingServerInterceptor(m.logger)))
 		pb.RegisterMasterServer(server, m)
-		log.Printf("starting grpc server...")
+		m.logger.Info("starting grpc server", "port", grpcPort)
 		if err := server.Serve(lis); err != nil {
-			log.Fatalf("failed to serve: %v", err)
+			m.logger.Fatal("failed to serve", "err", err)
 		}
 	}()
 
 	http.HandleFunc("/run", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
-			m.isRunning = true
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
+			}
 
-			err := m.broadcastCommand("run")
-			if err != nil {
-				log.Print(err)
-				http.Error(w, fmt.Sprintf("error running network: %s", err.Error()), http.StatusBadRequest)
+			mode := r.FormValue("mode")
+			if mode == "" {
+				mode = clockFree
+			}
+			var cycles int64
+			if s := r.FormValue("cycles"); s != "" {
+				v, err := strconv.ParseInt(s, 10, 64)
+				if err != nil || v < 0 {
+					http.Error(w, "cannot parse cycles", http.StatusBadRequest)
+					return
+				}
+				cycles = v
+			}
+
+			m.clearDeadlock()
+			switch mode {
+			case clockFree:
+				if _, err := m.life.set(StateRunning); err != nil {
+					http.Error(w, fmt.Sprintf("error running network: %s", status.Convert(err).Message()), http.StatusBadRequest)
+					return
+				}
+				m.setClockMode(mode)
+
+				err := m.broadcastCommand(r.Context(), "run")
+				if err != nil {
+					logging.FromContext(r.Context(), m.logger).Error("could not run network", "cmd", "run", "err", err)
+					http.Error(w, fmt.Sprintf("error running network: %s", err.Error()), http.StatusBadRequest)
+					return
+				}
+			case clockLockstep:
+				prev, err := m.life.set(StateRunning)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("error running network: %s", status.Convert(err).Message()), http.StatusBadRequest)
+					return
+				}
+				if prev.running() {
+					http.Error(w, "network is already running", http.StatusBadRequest)
+					return
+				}
+				m.setClockMode(mode)
+
+				// Program nodes only step when ticked
+				go m.runClock(m.life.Context(), cycles)
+			default:
+				http.Error(w, fmt.Sprintf("'%s' not a valid clock mode", mode), http.StatusBadRequest)
 				return
 			}
 
@@ -105,18 +205,39 @@ func (m *MasterNode) Start() {
 		}
 	})
 
+	http.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(m.nodeInfo)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/clock", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			m.clockMux.Lock()
+			mode := m.clockMode
+			m.clockMux.Unlock()
+			json.NewEncoder(w).Encode(clientClockResponse{Mode: mode, Cycle: atomic.LoadInt64(&m.cycle)})
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
 	http.HandleFunc("/pause", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
-			err := m.broadcastCommand("pause")
+			err := m.broadcastCommand(r.Context(), "pause")
 			if err != nil {
-				log.Print(err)
+				logging.FromContext(r.Context(), m.logger).Error("could not pause network", "cmd", "pause", "err", err)
 				http.Error(w, fmt.Sprintf("error pausing network: %s", err.Error()), http.StatusBadRequest)
 				return
 			}
-			if m.isRunning {
-				m.stopNode()
-			}
+			m.life.stop(StatePaused)
 			fmt.Fprintf(w, "Success")
 		default:
 			http.Error(w, "method GET not allowed", http.StatusMethodNotAllowed)
@@ -126,15 +247,12 @@ func (m *MasterNode) Start() {
 	http.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
-			err := m.broadcastCommand("reset")
+			err := m.broadcastCommand(r.Context(), "reset")
 			if err != nil {
-				log.Print(err)
+				logging.FromContext(r.Context(), m.logger).Error("could not reset network", "cmd", "reset", "err", err)
 				http.Error(w, fmt.Sprintf("error resetting network: %s", err.Error()), http.StatusBadRequest)
 				return
 			}
-			if m.isRunning {
-				m.stopNode()
-			}
 			m.resetNode()
 			fmt.Fprintf(w, "Success")
 		default:
@@ -157,47 +275,163 @@ func (m *MasterNode) Start() {
 			// Check if master knows target uri
 			if _, ok := m.nodeInfo[targetURI]; !ok {
 				err := fmt.Errorf("node %s not valid on this network", targetURI)
-				log.Print(err)
+				logging.FromContext(r.Context(), m.logger).Warn("could not load program", "target", targetURI, "err", err)
 				http.Error(w, fmt.Sprintf("error loading program on node %s: %s", targetURI, err.Error()), http.StatusBadRequest)
 				return
 			}
 
+			// Check program before touching network
+			bytecode, err := m.compileProgram(program)
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Warn("program has errors", "target", targetURI, "err", err)
+				writeDiagnostics(w, err)
+				return
+			}
+
 			// Reset network
-			err := m.broadcastCommand("reset")
+			err = m.broadcastCommand(r.Context(), "reset")
 			if err != nil {
-				log.Print(err)
+				logging.FromContext(r.Context(), m.logger).Error("could not reset network", "cmd", "reset", "err", err)
 				http.Error(w, fmt.Sprintf("error resetting network: %s", err.Error()), http.StatusBadRequest)
 				return
 			}
-			if m.isRunning {
-				m.stopNode()
-			}
 			m.resetNode()
 
 			// Send load command to target node
-			conn, err := grpc.Dial(fmt.Sprintf("%s:8000", targetURI), m.dialOpts...)
+			err = m.loadBytecode(targetURI, bytecode)
 			if err != nil {
-				log.Fatalf("did not connect: %v", err)
+				logging.FromContext(r.Context(), m.logger).Error("could not load program", "target", targetURI, "err", err)
+				http.Error(w, fmt.Sprintf("error loading program on node %s: %s", targetURI, err.Error()), http.StatusBadRequest)
+				return
+			}
+			m.life.set(StateLoaded)
+			logging.FromContext(r.Context(), m.logger).Info("successfully loaded program", "target", targetURI)
+			fmt.Fprintf(w, "Success")
+		default:
+			http.Error(w, "method GET not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/loadBundle", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
 			}
-			defer conn.Close()
-			c := pb.NewProgramClient(conn)
-			_, err = c.Load(m.ctx, &pb.LoadMessage{Program: program})
+
+			// Check whole bundle before touching network
+			bundle, err := tis.ParseBundle(r.FormValue("bundle"), tis.PreprocessOptions{Include: includer(m.includeDir)})
 			if err != nil {
-				log.Print(err)
-				http.Error(w, fmt.Sprintf("error loading program on node %s: %s", targetURI, err.Error()), http.StatusBadRequest)
+				logging.FromContext(r.Context(), m.logger).Warn("bundle has errors", "err", err)
+				writeDiagnostics(w, err)
 				return
 			}
-			log.Printf("successfully loaded program")
+			if diags := bundle.Check(m.topology()); diags.HasErrors() {
+				logging.FromContext(r.Context(), m.logger).Warn("bundle has errors", "err", diags)
+				writeDiagnostics(w, diags)
+				return
+			}
+
+			// Every node must be reachable before network is reset
+			previous, err := m.snapshotPrograms(r.Context(), bundle)
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not reach network", "err", err)
+				http.Error(w, fmt.Sprintf("error reaching network: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			// Reset network
+			err = m.broadcastCommand(r.Context(), "reset")
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not reset network", "cmd", "reset", "err", err)
+				http.Error(w, fmt.Sprintf("error resetting network: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+			m.resetNode()
+
+			err = m.loadBundle(bundle, previous)
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not load bundle", "err", err)
+				http.Error(w, fmt.Sprintf("error loading bundle: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+			m.life.set(StateLoaded)
+			logging.FromContext(r.Context(), m.logger).Info("successfully loaded bundle", "sections", len(bundle.Sections))
 			fmt.Fprintf(w, "Success")
 		default:
 			http.Error(w, "method GET not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
+	http.HandleFunc("/program", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			targetURI := r.FormValue("targetURI")
+			if info, ok := m.nodeInfo[targetURI]; !ok || info.Type != "program" {
+				http.Error(w, fmt.Sprintf("program node %s not valid on this network", targetURI), http.StatusBadRequest)
+				return
+			}
+			format := r.FormValue("format")
+			if format == "" {
+				format = "text"
+			}
+			if format != "text" && format != "json" {
+				http.Error(w, fmt.Sprintf("'%s' not a valid program format", format), http.StatusBadRequest)
+				return
+			}
+
+			var res *pb.LoadMessage
+			err := m.call(r.Context(), targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				res, err = pb.NewProgramClient(conn).GetProgram(ctx, &empty.Empty{})
+				return err
+			})
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not get program", "target", targetURI, "err", err)
+				http.Error(w, fmt.Sprintf("error getting program on node %s: %s", targetURI, err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			switch format {
+			case "text":
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprint(w, res.Program)
+			case "json":
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(clientProgram{Instructions: strings.Split(res.Program, "\n"), Lines: res.Lines})
+			}
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/compile", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "cannot parse form", http.StatusBadRequest)
+				return
+			}
+
+			bytecode, err := m.compileProgram(r.FormValue("program"))
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Warn("program has errors", "err", err)
+				writeDiagnostics(w, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(bytecode)
+		default:
+			http.Error(w, "method GET not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	http.HandleFunc("/compute", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
-			if !m.isRunning {
+			if !m.life.running() {
 				http.Error(w, "network is not running", http.StatusBadRequest)
 				return
 			}
@@ -213,60 +447,354 @@ func (m *MasterNode) Start() {
 				return
 			}
 
-			m.inChan <- v
+			var before *clientStatsResponse
+			if r.FormValue("stats") == "true" {
+				before, err = m.collectStats(r.Context())
+				if err != nil {
+					logging.FromContext(r.Context(), m.logger).Error("could not get stats", "err", err)
+					http.Error(w, fmt.Sprintf("error getting stats: %s", err.Error()), http.StatusBadRequest)
+					return
+				}
+			}
 
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(clientOutResponse{Value: <-m.outChan})
-			log.Printf("Value outputted")
+			// Give up instead of waiting forever if network deadlocks
+			deadlockFound, _ := m.deadlockSignal()
+			select {
+			case m.inChan <- v:
+			case <-deadlockFound:
+				m.writeDeadlock(w)
+				return
+			case <-r.Context().Done():
+				return
+			}
+
+			select {
+			case out := <-m.outChan:
+				res := clientOutResponse{Value: out}
+				if before != nil {
+					after, err := m.collectStats(r.Context())
+					if err != nil {
+						logging.FromContext(r.Context(), m.logger).Error("could not get stats", "err", err)
+						http.Error(w, fmt.Sprintf("error getting stats: %s", err.Error()), http.StatusBadRequest)
+						return
+					}
+					res.Stats = after.sub(before)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(res)
+				logging.FromContext(r.Context(), m.logger).Info("value outputted", "input", v, "output", out)
+			case <-deadlockFound:
+				m.writeDeadlock(w)
+			case <-r.Context().Done():
+			}
 		default:
 			http.Error(w, "method GET not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
-	log.Printf("starting http server...")
-	if err := http.ListenAndServe(clientPort, nil); err != nil {
-		log.Fatal(err)
+	m.handleDebug()
+	m.handleTrace()
+	m.handleDeadlock()
+	m.handleStats()
+	m.handleFaults()
+	m.handleState()
+	http.Handle("/metrics", m.metrics)
+
+	m.logger.Info("starting http server", "port", clientPort)
+	if err := http.ListenAndServe(clientPort, loggingHandler(m.logger, http.DefaultServeMux)); err != nil {
+		m.logger.Fatal("failed to serve", "err", err)
 	}
 }
 
-// GetInput handles request to get input from master node
-func (m *MasterNode) GetInput(ctx context.Context, in *empty.Empty) (*pb.ValueMessage, error) {
+// GetInput handles request to get input from master node.
+// Requests sent again get the value taken the first time.
+func (m *MasterNode) GetInput(ctx context.Context, in *pb.CycleMessage) (*pb.ValueMessage, error) {
+	v, err := m.replays.once(ctx, in.Session, in.Seq, func() (int64, error) {
+		return m.takeInput(ctx, in.Cycle)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ValueMessage{Value: v, Cycle: in.Cycle}, nil
+}
+
+// takeInput takes value from input, waiting for one unless cycle is lockstep cycle.
+// Gives up once master stops or resets, or ctx of request is done.
+func (m *MasterNode) takeInput(ctx context.Context, cycle int64) (int64, error) {
+	if cycle > 0 {
+		select {
+		case v := <-m.inChan:
+			logging.FromContext(ctx, m.logger).Debug("sent input value", "value", v, "cycle", cycle)
+			return int64(v), nil
+		default:
+			return 0, errWouldBlock
+		}
+	}
+
 	select {
 	case v := <-m.inChan:
-		log.Printf("sent input value")
-		return &pb.ValueMessage{Value: int32(v)}, nil
-	case <-m.ctx.Done():
-		log.Printf("input retrieval cancelled")
-		return nil, fmt.Errorf("input retrieval cancelled")
+		logging.FromContext(ctx, m.logger).Debug("sent input value", "value", v)
+		return int64(v), nil
+	case <-m.life.Context().Done():
+	case <-ctx.Done():
 	}
+	logging.FromContext(ctx, m.logger).Debug("input retrieval cancelled")
+	return 0, fmt.Errorf("input retrieval cancelled")
 }
 
-// SendOutput handles request to send output to master node
+// SendOutput handles request to send output to master node.
+// Requests sent again are only output once.
 func (m *MasterNode) SendOutput(ctx context.Context, in *pb.ValueMessage) (*empty.Empty, error) {
-	m.outChan <- int(in.Value)
-	log.Printf("received output value")
+	_, err := m.replays.once(ctx, in.Session, in.Seq, func() (int64, error) {
+		return in.Value, m.putOutput(ctx, in.Value, in.Cycle)
+	})
+	if err != nil {
+		return nil, err
+	}
 	return &empty.Empty{}, nil
 }
 
-// stopNode stops master node
-func (m *MasterNode) stopNode() {
-	m.cancel()
-	m.isRunning = false
+// putOutput puts value into output, waiting for room unless cycle is lockstep cycle.
+// Gives up once master stops or resets, or ctx of request is done.
+func (m *MasterNode) putOutput(ctx context.Context, v int64, cycle int64) error {
+	if cycle > 0 {
+		select {
+		case m.outChan <- int(v):
+		default:
+			return errWouldBlock
+		}
+		logging.FromContext(ctx, m.logger).Debug("received output value", "value", v, "cycle", cycle)
+		return nil
+	}
+
+	select {
+	case m.outChan <- int(v):
+		logging.FromContext(ctx, m.logger).Debug("received output value", "value", v)
+		return nil
+	case <-m.life.Context().Done():
+	case <-ctx.Done():
+	}
+	logging.FromContext(ctx, m.logger).Debug("output cancelled")
+	return fmt.Errorf("output cancelled")
+}
 
-	// Re-create context
-	nodeCtx, cancel := context.WithCancel(context.Background())
-	m.ctx = nodeCtx
-	m.cancel = cancel
+// setClockMode sets how network is run
+func (m *MasterNode) setClockMode(mode string) {
+	m.clockMux.Lock()
+	defer m.clockMux.Unlock()
+	m.clockMode = mode
 }
 
-// resetNode resets master node
+// resetNode stops and resets master node, emptying input and output
 func (m *MasterNode) resetNode() {
-	m.inChan = make(chan int, bufferSize)
-	m.outChan = make(chan int, bufferSize)
+	state := StateIdle
+	m.loadedMux.Lock()
+	if len(m.loaded) > 0 {
+		state = StateLoaded
+	}
+	m.loadedMux.Unlock()
+	// Always allowed. Ends current run so requests waiting on input or output give up.
+	m.life.set(state)
+
+	for len(m.inChan) > 0 || len(m.outChan) > 0 {
+		select {
+		case <-m.inChan:
+		case <-m.outChan:
+		default:
+		}
+	}
+	m.replays.reset()
+	atomic.StoreInt64(&m.cycle, 0)
+	m.clearDeadlock()
+}
+
+// compileProgram checks program against network and compiles it to bytecode.
+// Most recently compiled programs are cached by preprocessed source
+// so editing an included file compiles the program again.
+func (m *MasterNode) compileProgram(program string) ([]byte, error) {
+	source, err := tis.Preprocess(program, tis.PreprocessOptions{Include: includer(m.includeDir)})
+	if err != nil {
+		return nil, err
+	}
+	key := sourceKey(source)
+
+	bytecode, ok := m.bytecodeCache.get(key)
+	if ok {
+		return bytecode, nil
+	}
+
+	prog, err := source.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if diags := tis.Check(prog, m.topology()); diags.HasErrors() {
+		return nil, diags
+	}
+	bytecode = tis.Encode(prog)
+
+	m.bytecodeCache.put(key, bytecode)
+	return bytecode, nil
+}
+
+// sourceKey hashes preprocessed source and the lines it came from
+func sourceKey(source *tis.Source) [sha256.Size]byte {
+	h := sha256.New()
+	io.WriteString(h, source.Text)
+	for _, o := range source.Origins {
+		fmt.Fprintf(h, "\x00%s:%d", o.File, o.Line)
+	}
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// loadBytecode loads compiled program onto program node
+func (m *MasterNode) loadBytecode(targetURI string, bytecode []byte) error {
+	err := m.call(m.life.Context(), targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := pb.NewProgramClient(conn).LoadBytecode(ctx, &pb.BytecodeMessage{Bytecode: bytecode})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	m.loadedMux.Lock()
+	m.loaded[targetURI] = bytecode
+	m.loadedMux.Unlock()
+	return nil
+}
+
+// loadBundle loads every section of bundle onto its node.
+// If any section fails, nodes that were loaded get their previous program back from previous.
+func (m *MasterNode) loadBundle(bundle *tis.Bundle, previous map[string][]byte) error {
+	type result struct {
+		targetURI string
+		err       error
+	}
+	c := make(chan result)
+	for _, s := range bundle.Sections {
+		go func(targetURI string, bytecode []byte) {
+			c <- result{targetURI, m.loadBytecode(targetURI, bytecode)}
+		}(s.Node, tis.Encode(s.Program))
+	}
+
+	var loaded []string
+	var loadErr error
+	for range bundle.Sections {
+		res := <-c
+		if res.err != nil {
+			if loadErr == nil {
+				loadErr = fmt.Errorf("node %s: %s", res.targetURI, res.err.Error())
+			}
+			continue
+		}
+		loaded = append(loaded, res.targetURI)
+	}
+	if loadErr == nil {
+		return nil
+	}
+
+	// Roll back
+	for _, targetURI := range loaded {
+		if err := m.loadBytecode(targetURI, previous[targetURI]); err != nil {
+			m.logger.Error("could not roll back node", "target", targetURI, "err", err)
+		}
+	}
+	return loadErr
+}
+
+// snapshotPrograms gets bytecode for the program each section of bundle replaces,
+// failing unless every node in network can be reached
+func (m *MasterNode) snapshotPrograms(ctx context.Context, bundle *tis.Bundle) (map[string][]byte, error) {
+	type result struct {
+		targetURI string
+		bytecode  []byte
+		err       error
+	}
+
+	sections := make(map[string]bool)
+	for _, s := range bundle.Sections {
+		sections[s.Node] = true
+	}
+
+	c := make(chan result)
+	for k, v := range m.nodeInfo {
+		go func(targetURI string, info NodeInfo) {
+			var bytecode []byte
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				switch {
+				case info.Type == "program" && sections[targetURI]:
+					loaded, err := pb.NewProgramClient(conn).GetProgram(ctx, &empty.Empty{})
+					if err != nil {
+						return err
+					}
+					bytecode, err = loadedBytecode(loaded)
+					return err
+				case info.Type == "program":
+					_, err := pb.NewProgramClient(conn).GetNodeState(ctx, &empty.Empty{})
+					return err
+				case info.Type == "stack":
+					_, err := pb.NewStackClient(conn).GetNodeState(ctx, &empty.Empty{})
+					return err
+				default:
+					return fmt.Errorf("invalid node type")
+				}
+			})
+			c <- result{targetURI, bytecode, err}
+		}(k, v)
+	}
+
+	previous := make(map[string][]byte)
+	var snapshotErr error
+	for range m.nodeInfo {
+		res := <-c
+		if res.err != nil {
+			if snapshotErr == nil {
+				snapshotErr = fmt.Errorf("node %s: %s", res.targetURI, status.Convert(res.err).Message())
+			}
+			continue
+		}
+		if res.bytecode != nil {
+			previous[res.targetURI] = res.bytecode
+		}
+	}
+	return previous, snapshotErr
+}
+
+// loadedBytecode compiles program got from node back to bytecode, keeping its source lines
+func loadedBytecode(loaded *pb.LoadMessage) ([]byte, error) {
+	prog, err := tis.Parse(loaded.Program)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prog.Instructions {
+		if i < len(loaded.Lines) {
+			prog.Instructions[i].Pos.Line = int(loaded.Lines[i])
+		}
+	}
+	return tis.Encode(prog), nil
+}
+
+// includer reads included program files from dir if set
+func includer(dir string) tis.IncludeFunc {
+	if dir == "" {
+		return nil
+	}
+	return tis.DirIncluder(dir)
+}
+
+// topology gets node types of all known nodes in network
+func (m *MasterNode) topology() tis.Topology {
+	topo := make(tis.Topology)
+	for k, v := range m.nodeInfo {
+		topo[k] = v.Type
+	}
+	return topo
 }
 
 // broadcastCommand broadcasts specified command to all known nodes in network
-func (m *MasterNode) broadcastCommand(cmd string) error {
+func (m *MasterNode) broadcastCommand(ctx context.Context, cmd string) error {
 
 	c := make(chan error)
 
@@ -275,9 +803,9 @@ func (m *MasterNode) broadcastCommand(cmd string) error {
 		go func(cmd string, targetURI string, info NodeInfo) {
 			switch info.Type {
 			case "program":
-				c <- m.broadcastCommandProgram(cmd, targetURI)
+				c <- m.broadcastCommandProgram(ctx, cmd, targetURI)
 			case "stack":
-				c <- m.broadcastCommandStack(cmd, targetURI)
+				c <- m.broadcastCommandStack(ctx, cmd, targetURI)
 			default:
 				c <- fmt.Errorf("invalid node type")
 			}
@@ -295,57 +823,49 @@ func (m *MasterNode) broadcastCommand(cmd string) error {
 }
 
 // broadcastCommandProgram broadcasts command to program nodes
-func (m *MasterNode) broadcastCommandProgram(cmd string, targetURI string) error {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", targetURI, grpcPort), m.dialOpts...)
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := pb.NewProgramClient(conn)
-	switch cmd {
-	case "run":
-		_, err = c.Run(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
-		}
-	case "pause":
-		_, err = c.Pause(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
+func (m *MasterNode) broadcastCommandProgram(ctx context.Context, cmd string, targetURI string) error {
+	return m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		c := pb.NewProgramClient(conn)
+		var err error
+		switch cmd {
+		case "run":
+			_, err = c.Run(ctx, &empty.Empty{})
+		case "pause":
+			_, err = c.Pause(ctx, &empty.Empty{})
+		case "reset":
+			_, err = c.Reset(ctx, &empty.Empty{})
 		}
-	case "reset":
-		_, err = c.Reset(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+		return err
+	})
 }
 
 // broadcastCommandStack broadcasts command to stack nodes
-func (m *MasterNode) broadcastCommandStack(cmd string, targetURI string) error {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", targetURI, grpcPort), m.dialOpts...)
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := pb.NewStackClient(conn)
-	switch cmd {
-	case "run":
-		_, err = c.Run(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
-		}
-	case "pause":
-		_, err = c.Pause(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
-		}
-	case "reset":
-		_, err = c.Reset(m.ctx, &empty.Empty{})
-		if err != nil {
-			return err
+func (m *MasterNode) broadcastCommandStack(ctx context.Context, cmd string, targetURI string) error {
+	return m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		c := pb.NewStackClient(conn)
+		var err error
+		switch cmd {
+		case "run":
+			_, err = c.Run(ctx, &empty.Empty{})
+		case "pause":
+			_, err = c.Pause(ctx, &empty.Empty{})
+		case "reset":
+			_, err = c.Reset(ctx, &empty.Empty{})
 		}
+		return err
+	})
+}
+
+// writeDiagnostics writes program errors to client as JSON diagnostics
+func writeDiagnostics(w http.ResponseWriter, err error) {
+	diags, ok := tis.AsDiagnostics(err)
+	if !ok {
+		http.Error(w, fmt.Sprintf("error parsing program: %s", err.Error()), http.StatusBadRequest)
+		return
 	}
-	return nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(clientDiagnosticsResponse{Diagnostics: diags})
 }
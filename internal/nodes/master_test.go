@@ -0,0 +1,116 @@
+package nodes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+func TestCompileProgramRecompilesEditedInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lib := filepath.Join(dir, "lib.tis")
+
+	m := &MasterNode{includeDir: dir, bytecodeCache: newBytecodeCache(maxCachedPrograms)}
+	program := "#include \"lib.tis\"\nOUT ACC"
+
+	if err := ioutil.WriteFile(lib, []byte("ADD 1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.compileProgram(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(lib, []byte("ADD 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.compileProgram(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("got cached bytecode after included file changed")
+	}
+
+	again, err := m.compileProgram(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(second, again) {
+		t.Error("got different bytecode for unchanged program")
+	}
+	if m.bytecodeCache.len() != 2 {
+		t.Errorf("got %d cached programs, want 2", m.bytecodeCache.len())
+	}
+}
+
+func TestLoadBundleRollsBackToProgramOnNode(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t, "nowhere")
+
+	// Loaded directly on node, as from PROGRAM, so master never saw it
+	if err := n.program.LoadProgram("#define N 5\nADD N\nOUT ACC"); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := tis.ParseBundle("@node localhost\nSUB 1\n@node nowhere\nNOP", tis.PreprocessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.master.snapshotPrograms(ctx, bundle); err == nil {
+		t.Fatal("snapshotPrograms() succeeded with unreachable node")
+	}
+
+	loaded, err := n.program.GetProgram(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytecode, err := loadedBytecode(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.master.loadBundle(bundle, map[string][]byte{testProgram: bytecode}); err == nil {
+		t.Fatal("loadBundle() succeeded with unreachable node")
+	}
+
+	got, err := n.program.GetProgram(ctx, &empty.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Program != "ADD 5\nOUT ACC" || !reflect.DeepEqual(got.Lines, []int32{2, 3}) {
+		t.Errorf("GetProgram() = %q, %v after rollback, want previous program", got.Program, got.Lines)
+	}
+}
+
+func TestSnapshotProgramsGetsSectionPrograms(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t)
+	if err := n.program.LoadProgram("ADD 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := tis.ParseBundle("@node localhost\nNOP", tis.PreprocessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := n.master.snapshotPrograms(ctx, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := tis.Decode(previous[testProgram])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tis.Disassemble(prog); got != "ADD 1" || len(previous) != 1 {
+		t.Errorf("snapshotPrograms() = %v with %q, want only %s with ADD 1", previous, got, testProgram)
+	}
+}
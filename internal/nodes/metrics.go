@@ -0,0 +1,108 @@
+package nodes
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/metrics"
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+// programMetrics are metrics updated while program node runs
+type programMetrics struct {
+	registry    *metrics.Registry
+	blockedTime *metrics.Counter
+	callLatency *metrics.Histogram
+}
+
+// newProgramMetrics registers metrics for program node
+func newProgramMetrics(p *ProgramNode) *programMetrics {
+	r := metrics.NewRegistry()
+	r.NewCounterFunc("misaka_program_instructions_total", "Instructions finished since node was reset.", func() float64 {
+		instructions, _ := p.stats.counts()
+		return float64(instructions)
+	})
+	r.NewCounterFunc("misaka_program_blocked_cycles_total", "Lockstep cycles node was blocked since node was reset.", func() float64 {
+		_, blocked := p.stats.counts()
+		return float64(blocked)
+	})
+	r.NewGaugeFunc("misaka_program_ptr", "Index of instruction that runs next.", func() float64 {
+		return float64(p.machineView().ptr)
+	})
+	r.NewGaugeFunc("misaka_program_running", "Whether node is running freely.", func() float64 {
+		return boolValue(p.life.running())
+	})
+	r.NewGaugeFunc("misaka_program_faulted", "Whether node is faulted.", func() float64 {
+		return boolValue(p.faulted())
+	})
+	r.NewCounterFunc("misaka_grpc_dials_total", "Connections dialed to other nodes.", func() float64 {
+		return float64(p.conns.dialCount())
+	})
+	return &programMetrics{
+		registry: r,
+		blockedTime: r.NewCounter("misaka_program_blocked_seconds_total",
+			"Time spent waiting on each port.", "port"),
+		callLatency: r.NewHistogram("misaka_grpc_client_duration_seconds",
+			"Latency of gRPC calls made by node, including time spent waiting on peers.", metrics.DefaultBuckets, "method"),
+	}
+}
+
+// observeWait records time spent waiting on port
+func (pm *programMetrics) observeWait(port tis.Port, d time.Duration) {
+	pm.blockedTime.Add(d.Seconds(), port.String())
+}
+
+// newStackMetrics registers metrics for stack node
+func newStackMetrics(s *StackNode) *metrics.Registry {
+	r := metrics.NewRegistry()
+	r.NewGaugeFunc("misaka_stack_depth", "Number of values on stack.", func() float64 {
+		return float64(s.stack.Len())
+	})
+	r.NewGaugeFunc("misaka_stack_pending", "Values pushed in lockstep mode that are not on stack yet.", func() float64 {
+		s.pendingMux.Lock()
+		defer s.pendingMux.Unlock()
+		return float64(len(s.pending))
+	})
+	return r
+}
+
+// newMasterMetrics registers metrics for master node
+func newMasterMetrics(m *MasterNode) *metrics.Registry {
+	r := metrics.NewRegistry()
+	r.NewGaugeFunc("misaka_master_input_queue_depth", "Values waiting in master input.", func() float64 {
+		return float64(len(m.inChan))
+	})
+	r.NewGaugeFunc("misaka_master_output_queue_depth", "Values waiting in master output.", func() float64 {
+		return float64(len(m.outChan))
+	})
+	r.NewGaugeFunc("misaka_master_cycle", "Current lockstep cycle.", func() float64 {
+		return float64(atomic.LoadInt64(&m.cycle))
+	})
+	r.NewGaugeFunc("misaka_master_running", "Whether network is running.", func() float64 {
+		return boolValue(m.life.running())
+	})
+	r.NewCounterFunc("misaka_grpc_dials_total", "Connections dialed to other nodes.", func() float64 {
+		return float64(m.conns.dialCount())
+	})
+	return r
+}
+
+// serveMetrics serves metrics over HTTP on metrics port
+func serveMetrics(r *metrics.Registry, l *logging.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	l.Info("starting metrics server", "port", metricsPort)
+	if err := http.ListenAndServe(metricsPort, mux); err != nil {
+		l.Fatal("failed to serve metrics", "err", err)
+	}
+}
+
+// boolValue converts b to 0 or 1
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
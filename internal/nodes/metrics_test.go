@@ -0,0 +1,107 @@
+package nodes
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+// scrapeMetrics gets samples served at /metrics by sample name with labels
+func scrapeMetrics(t *testing.T, h http.Handler) map[string]string {
+	t.Helper()
+	server := httptest.NewServer(h)
+	defer server.Close()
+	res, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+func TestProgramMetrics(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProgramNode(t)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", p.metrics.registry)
+	if err := p.LoadProgram("ADD 1\nADD 2\nMOV R0, ACC\nSUB 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two instructions finish and the read is blocked for two cycles in lockstep
+	p.machineMux.Lock()
+	for cycle := int64(1); cycle <= 4; cycle++ {
+		p.update(ctx, cycle)
+	}
+	p.machineMux.Unlock()
+
+	samples := scrapeMetrics(t, mux)
+	for name, want := range map[string]string{
+		"misaka_program_instructions_total":   "2",
+		"misaka_program_blocked_cycles_total": "2",
+		"misaka_program_ptr":                  "2",
+		"misaka_program_running":              "0",
+		"misaka_program_faulted":              "0",
+		"misaka_grpc_dials_total":             "0",
+	} {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := samples[`misaka_program_blocked_seconds_total{port="R0"}`]; ok {
+		t.Error("blocked time recorded before node stopped waiting")
+	}
+
+	// Time spent waiting is recorded once read finishes
+	p.regs()[0].TryPut(5)
+	p.machineMux.Lock()
+	p.update(ctx, 5)
+	p.machineMux.Unlock()
+	samples = scrapeMetrics(t, mux)
+	if got := samples["misaka_program_instructions_total"]; got != "3" {
+		t.Errorf("misaka_program_instructions_total = %q, want 3", got)
+	}
+	if got := samples["misaka_program_ptr"]; got != "3" {
+		t.Errorf("misaka_program_ptr = %q, want 3", got)
+	}
+	if _, ok := samples[`misaka_program_blocked_seconds_total{port="R0"}`]; !ok {
+		t.Errorf("no blocked time for R0 in %v", samples)
+	}
+}
+
+func TestStackMetrics(t *testing.T) {
+	s := NewStackNode(tis.NumericTIS, "", "")
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics)
+	s.push(1, 0)
+	s.push(2, 0)
+	s.push(3, 4)
+
+	samples := scrapeMetrics(t, mux)
+	if samples["misaka_stack_depth"] != "2" || samples["misaka_stack_pending"] != "1" {
+		t.Errorf("depth, pending = %q, %q, want 2, 1", samples["misaka_stack_depth"], samples["misaka_stack_pending"])
+	}
+	s.resetNode()
+	samples = scrapeMetrics(t, mux)
+	if samples["misaka_stack_depth"] != "0" || samples["misaka_stack_pending"] != "0" {
+		t.Errorf("depth, pending = %q, %q after reset, want 0, 0", samples["misaka_stack_depth"], samples["misaka_stack_pending"])
+	}
+}
@@ -0,0 +1,158 @@
+package nodes
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Names of nodes in test network. The master, program and stack nodes share one server,
+// so each name there has one node type. Names other than localhost are resolved by testDialer.
+const (
+	testProgram = "localhost"
+	testPeer    = "peer"
+	testStack   = "stack"
+)
+
+// testAddrs are addresses of test servers by node name
+var testAddrs = map[string]string{
+	testProgram: "127.0.0.1" + grpcPort,
+	testPeer:    "127.0.0.2" + grpcPort,
+	testStack:   "127.0.0.1" + grpcPort,
+}
+
+// testDialer dials test nodes by name
+func testDialer(ctx context.Context, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := testAddrs[host]; ok {
+		addr = a
+	}
+	return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+}
+
+// testRetryPolicy gives up quickly on nodes that are not there
+var testRetryPolicy = RetryPolicy{
+	Attempts:  2,
+	BaseDelay: 10 * time.Millisecond,
+	MaxDelay:  50 * time.Millisecond,
+	Timeout:   500 * time.Millisecond,
+}
+
+// testNetwork is a master, program node and stack node served by one gRPC server
+// and a second program node served by another
+type testNetwork struct {
+	master  *MasterNode
+	program *ProgramNode
+	peer    *ProgramNode
+	stack   *StackNode
+
+	peerServer *grpc.Server
+}
+
+// newTestNetwork serves a test network until test ends.
+// Extra names are added to the network as program nodes that cannot be reached.
+func newTestNetwork(t testing.TB, missing ...string) *testNetwork {
+	t.Helper()
+	certFile, keyFile := testCerts(t)
+	nodeInfo := map[string]NodeInfo{
+		testProgram: {Type: "program"},
+		testPeer:    {Type: "program"},
+		testStack:   {Type: "stack"},
+	}
+	for _, name := range missing {
+		nodeInfo[name] = NodeInfo{Type: "program"}
+	}
+	n := &testNetwork{
+		master:  NewMasterNode(nodeInfo, "", certFile, keyFile),
+		program: NewProgramNode(testProgram, "", tis.NumericInt64, certFile, keyFile),
+		peer:    NewProgramNode(testProgram, "", tis.NumericInt64, certFile, keyFile),
+		stack:   NewStackNode(tis.NumericInt64, certFile, keyFile),
+	}
+	quiet := logging.New(ioutil.Discard, logging.LevelError, logging.FormatLogfmt)
+	n.master.SetLogger(quiet)
+	n.program.SetLogger(quiet)
+	n.peer.SetLogger(quiet)
+	n.stack.SetLogger(quiet)
+	for _, conns := range []*connPool{n.master.conns, n.program.conns, n.peer.conns} {
+		conns.dialOpts = append(conns.dialOpts, grpc.WithContextDialer(testDialer))
+	}
+	n.master.SetRetryPolicy(testRetryPolicy)
+	n.program.SetRetryPolicy(testRetryPolicy)
+	n.peer.SetRetryPolicy(testRetryPolicy)
+
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.Creds(creds))
+	pb.RegisterMasterServer(server, n.master)
+	pb.RegisterProgramServer(server, n.program)
+	pb.RegisterDebugServer(server, &programDebugger{p: n.program})
+	pb.RegisterStackServer(server, n.stack)
+	serveTest(t, server, testAddrs[testProgram])
+
+	n.peerServer = grpc.NewServer(grpc.Creds(creds))
+	pb.RegisterProgramServer(n.peerServer, n.peer)
+	pb.RegisterDebugServer(n.peerServer, &programDebugger{p: n.peer})
+	serveTest(t, n.peerServer, testAddrs[testPeer])
+
+	done := make(chan struct{})
+	go runTestProgram(n.program, done)
+	go runTestProgram(n.peer, done)
+
+	t.Cleanup(func() {
+		close(done)
+		n.program.resetNode(nil)
+		n.peer.resetNode(nil)
+		n.stack.resetNode()
+		n.master.resetNode()
+		server.Stop()
+		n.peerServer.Stop()
+	})
+	return n
+}
+
+// serveTest serves server on addr
+func serveTest(t testing.TB, server *grpc.Server, addr string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+}
+
+// runTestProgram runs instructions on p while it is running until done is closed, as in Start
+func runTestProgram(p *ProgramNode, done chan struct{}) {
+	for {
+		ctx := p.life.waitRunning()
+		select {
+		case <-done:
+			return
+		default:
+		}
+		p.machineMux.Lock()
+		if ctx.Err() == nil {
+			p.runInstruction(ctx)
+		}
+		p.machineMux.Unlock()
+	}
+}
+
+// testContext gets context that ends with a timeout so hung tests fail
+func testContext(t testing.TB) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
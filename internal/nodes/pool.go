@@ -0,0 +1,81 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connPool shares one gRPC connection per node between calls.
+// Connections are dialed on first use and dialed again once they fail or shut down.
+type connPool struct {
+	dials    int64
+	dialOpts []grpc.DialOption
+	mux      sync.Mutex
+	conns    map[string]*poolConn
+}
+
+// poolConn is a pooled connection to one node.
+// mux is held while dialing so concurrent calls to the node share one dial.
+type poolConn struct {
+	mux  sync.Mutex
+	conn *grpc.ClientConn
+}
+
+// newConnPool creates an empty pool that dials with dialOpts
+func newConnPool(dialOpts ...grpc.DialOption) *connPool {
+	return &connPool{
+		dialOpts: dialOpts,
+		conns:    make(map[string]*poolConn),
+	}
+}
+
+// get gets connection to node at targetURI, dialing it if there is no healthy one.
+// Dialing waits until node is reachable or ctx is done.
+func (c *connPool) get(ctx context.Context, targetURI string) (*grpc.ClientConn, error) {
+	c.mux.Lock()
+	pc, ok := c.conns[targetURI]
+	if !ok {
+		pc = &poolConn{}
+		c.conns[targetURI] = pc
+	}
+	c.mux.Unlock()
+
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+	if pc.conn != nil {
+		if healthy(pc.conn) {
+			return pc.conn, nil
+		}
+		// Evict and dial again, waiting until node is back
+		pc.conn.Close()
+		pc.conn = nil
+	}
+
+	atomic.AddInt64(&c.dials, 1)
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s%s", targetURI, grpcPort), c.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	pc.conn = conn
+	return conn, nil
+}
+
+// dialCount counts dials made by pool
+func (c *connPool) dialCount() int64 {
+	return atomic.LoadInt64(&c.dials)
+}
+
+// healthy checks if conn can still be used.
+// Idle and connecting connections recover on their own.
+func healthy(conn *grpc.ClientConn) bool {
+	switch conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return false
+	}
+	return true
+}
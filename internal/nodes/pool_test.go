@@ -0,0 +1,86 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"google.golang.org/grpc"
+)
+
+// benchmarkCall compares dialing a connection for every call, as nodes did before
+// connections were pooled, with calling over a pooled connection
+func benchmarkCall(b *testing.B, conns *connPool, targetURI string, call func(ctx context.Context, conn *grpc.ClientConn) error) {
+	ctx := testContext(b)
+	// Warm up node before timing
+	conn, err := conns.get(ctx, targetURI)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := call(ctx, conn); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("dial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			conn, err := grpc.DialContext(ctx, targetURI+grpcPort, conns.dialOpts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			err = call(ctx, conn)
+			conn.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			conn, err := conns.get(ctx, targetURI)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := call(ctx, conn); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSend(b *testing.B) {
+	n := newTestNetwork(b)
+
+	// Empty register as values arrive so sends never wait
+	ctx, cancel := context.WithCancel(testContext(b))
+	defer cancel()
+	go func() {
+		for {
+			if _, err := n.program.regs()[0].Take(ctx); err != nil {
+				return
+			}
+		}
+	}()
+
+	benchmarkCall(b, n.master.conns, testProgram, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := pb.NewProgramClient(conn).Send(ctx, &pb.SendMessage{Value: 1})
+		return err
+	})
+}
+
+func BenchmarkPush(b *testing.B) {
+	n := newTestNetwork(b)
+	benchmarkCall(b, n.master.conns, testStack, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := pb.NewStackClient(conn).Push(ctx, &pb.ValueMessage{Value: 1})
+		return err
+	})
+}
+
+func BenchmarkPop(b *testing.B) {
+	n := newTestNetwork(b)
+	benchmarkCall(b, n.master.conns, testStack, func(ctx context.Context, conn *grpc.ClientConn) error {
+		// Push first so Pop never waits on an empty stack
+		n.stack.stack.Push(1)
+		_, err := pb.NewStackClient(conn).Pop(ctx, &pb.CycleMessage{})
+		return err
+	})
+}
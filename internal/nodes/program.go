@@ -3,152 +3,224 @@ package nodes
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
-	"regexp"
-	"strconv"
-	"strings"
+	"sync"
+	"time"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
 	"github.com/jasmaa/misaka-net/internal/tis"
-	"github.com/jasmaa/misaka-net/internal/utils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
-// Register buffer size
-const bufferSize = 1
-
 // ProgramNode is a program node that interprets TIS-100 asm
 type ProgramNode struct {
-	masterURI string
-
-	acc int
-	bak int
-	r0  chan int
-	r1  chan int
-	r2  chan int
-	r3  chan int
+	masterURI  string
+	includeDir string
+
+	registers [4]*register
+	regsMux   sync.RWMutex
+
+	// machine, cycle, ctx, session and seq belong to whoever holds machineMux.
+	// Ctx is context of instruction being run. Port requests to peers carry session
+	// and seq, which only moves on once a request succeeds.
+	machine    *tis.Machine
+	cycle      int64
+	ctx        context.Context
+	session    string
+	seq        uint64
+	machineMux sync.Mutex
+
+	// view is copy of machine for readers that cannot wait for an instruction to finish
+	view    machineView
+	viewMux sync.Mutex
+
+	// Lockstep state
+	pending [4]pendingValue
+	takenAt [4]int64
+	regMux  sync.Mutex
+
+	// Debugger state. Breakpoints are source lines.
+	breakpoints map[int]bool
+	watches     []*watch
+	skipBreak   bool
+	stopReason  string
+	debugMux    sync.Mutex
+
+	// fault is why node faulted, empty unless it has. Guarded by debugMux.
+	fault     string
+	faultLine int
+
+	// tracer is nil unless tracing is enabled
+	tracer *tracer
+
+	// waits is port node is blocked on
+	waits   waitState
+	stats   nodeStats
+	metrics *programMetrics
+	logger  *logging.Logger
+
+	life *lifecycle
+	// runMux keeps node from starting to run freely while it resets or steps.
+	// Taken before machineMux.
+	runMux sync.Mutex
 
-	ptr      int
-	asm      [][]string
-	labelMap map[string]int
+	certFile, keyFile string
+	conns             *connPool
+	retry             RetryPolicy
 
-	ctx       context.Context
-	cancel    context.CancelFunc
-	isRunning bool
-	runSignal chan interface{}
+	// replays is last request served for each peer
+	replays *replayLog
 
-	certFile, keyFile string
-	dialOpts          []grpc.DialOption
+	// Register writes to and from peers while running freely
+	credits  *creditor
+	peers    map[string]*peerStream
+	peersMux sync.Mutex
 
 	pb.UnimplementedProgramServer
 }
 
 // NewProgramNode creates a new program node
-func NewProgramNode(masterURI string, certFile, keyFile string) *ProgramNode {
-	ctx, cancel := context.WithCancel(context.Background())
+func NewProgramNode(masterURI string, includeDir string, model tis.NumericModel, certFile, keyFile string) *ProgramNode {
 	creds, err := credentials.NewClientTLSFromFile(certFile, "")
 	if err != nil {
 		panic(err)
 	}
-	return &ProgramNode{
-		masterURI: masterURI,
-		acc:       0,
-		bak:       0,
-		r0:        make(chan int, bufferSize),
-		r1:        make(chan int, bufferSize),
-		r2:        make(chan int, bufferSize),
-		r3:        make(chan int, bufferSize),
-		asm:       [][]string{[]string{"NOP"}},
-		ctx:       ctx,
-		cancel:    cancel,
-		runSignal: make(chan interface{}),
-		certFile:  certFile,
-		keyFile:   keyFile,
-		dialOpts: []grpc.DialOption{
+	p := &ProgramNode{
+		masterURI:   masterURI,
+		includeDir:  includeDir,
+		registers:   newRegisters(),
+		breakpoints: make(map[int]bool),
+		logger:      logging.Default(),
+		life:        newLifecycle(StateIdle),
+		session:     newSession(),
+		replays:     newReplayLog(maxReplaySessions),
+		certFile:    certFile,
+		keyFile:     keyFile,
+		retry:       DefaultRetryPolicy,
+		credits:     newCreditor(),
+		peers:       make(map[string]*peerStream),
+		conns: newConnPool(
 			grpc.WithTransportCredentials(creds),
 			grpc.WithBlock(),
-		},
+			grpc.WithUnaryInterceptor(requestIDClientInterceptor),
+		),
 	}
+	p.machine = tis.NewMachine(tis.NewEmptyProgram(), model, nodePorts{p})
+	p.publish()
+	p.metrics = newProgramMetrics(p)
+	p.waits.observe = p.metrics.observeWait
+	return p
 }
 
 // Start starts program loop and server
 func (p *ProgramNode) Start() {
+	go serveMetrics(p.metrics.registry, p.logger)
+
 	// Run program loop
 	go func() {
 		for {
-			if p.isRunning {
-				err := p.update()
-				if err != nil {
-					log.Print(err)
-				}
-			} else {
-				// Sleep until run occurs
-				<-p.runSignal
+			ctx := p.life.waitRunning()
+			p.machineMux.Lock()
+			if ctx.Err() == nil {
+				p.runInstruction(ctx)
 			}
+			p.machineMux.Unlock()
 		}
 	}()
 
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		p.logger.Fatal("failed to listen", "err", err)
 	}
 	creds, err := credentials.NewServerTLSFromFile(p.certFile, p.keyFile)
 	if err != nil {
-		log.Fatalf("failed to get creds: %v", err)
+		p.logger.Fatal("failed to get creds", "err", err)
 	}
-	server := grpc.NewServer(grpc.Creds(creds))
+	server := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(loggingServerInterceptor(p.logger)))
 	pb.RegisterProgramServer(server, p)
-	log.Printf("starting grpc server...")
+	pb.RegisterDebugServer(server, &programDebugger{p: p})
+	p.logger.Info("starting grpc server", "port", grpcPort)
 	if err := server.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		p.logger.Fatal("failed to serve", "err", err)
+	}
+}
+
+// runInstruction runs current instruction while node runs freely,
+// stopping node at breakpoints, watches and faults. Must hold machineMux.
+func (p *ProgramNode) runInstruction(ctx context.Context) {
+	if p.atBreakpoint() {
+		p.life.stop(StatePaused)
+		p.logger.Info("node stopped at breakpoint", "line", p.line())
+		return
+	}
+	err := p.update(ctx, 0)
+	if isFault(err) {
+		p.setFault(err)
+		p.logger.Error("node faulted", "line", p.line(), "err", err)
+	} else if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Warn("instruction failed", "line", p.line(), "err", err)
+		}
+	} else if p.checkWatches() {
+		p.life.stop(StatePaused)
+		p.logger.Info("node stopped by watch")
 	}
 }
 
 // Run handles request to start asm execution
 func (p *ProgramNode) Run(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if !p.isRunning {
-		p.isRunning = true
-
-		// Signal run with non-blocking send
-		select {
-		case p.runSignal <- nil:
-		default:
-		}
-
-		log.Printf("node was run")
+	p.runMux.Lock()
+	defer p.runMux.Unlock()
+
+	// Set up debugger before loop can see node running
+	p.debugMux.Lock()
+	prevBreak, prevReason := p.skipBreak, p.stopReason
+	p.skipBreak = true
+	p.stopReason = ""
+	p.debugMux.Unlock()
+
+	prev, err := p.life.set(StateRunning)
+	if err != nil || prev.running() {
+		p.debugMux.Lock()
+		p.skipBreak, p.stopReason = prevBreak, prevReason
+		p.debugMux.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if prev.running() {
+		logging.FromContext(ctx, p.logger).Info("node is already running", "cmd", "run")
 	} else {
-		log.Printf("node is already running")
+		logging.FromContext(ctx, p.logger).Info("node was run", "cmd", "run")
 	}
 	return &empty.Empty{}, nil
 }
 
 // Pause handles request to pause asm execution
 func (p *ProgramNode) Pause(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if p.isRunning {
-		p.stopNode()
-		log.Printf("node was paused")
+	if p.life.stop(StatePaused) {
+		p.setStopReason("paused")
+		logging.FromContext(ctx, p.logger).Info("node was paused", "cmd", "pause")
 	} else {
-		log.Printf("node is already paused")
+		logging.FromContext(ctx, p.logger).Info("node is already paused", "cmd", "pause")
 	}
 	return &empty.Empty{}, nil
 }
 
 // Reset handles request to reset asm execution and registers
 func (p *ProgramNode) Reset(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if p.isRunning {
-		p.stopNode()
-	}
-	p.resetNode()
-	log.Printf("node was reset")
+	p.resetNode(nil)
+	logging.FromContext(ctx, p.logger).Info("node was reset", "cmd", "reset")
 	return &empty.Empty{}, nil
 }
 
 // Load handles request to reset node and load asm program
 func (p *ProgramNode) Load(ctx context.Context, in *pb.LoadMessage) (*empty.Empty, error) {
-	p.resetNode()
 	err := p.LoadProgram(in.Program)
 	if err != nil {
 		return nil, err
@@ -156,411 +228,484 @@ func (p *ProgramNode) Load(ctx context.Context, in *pb.LoadMessage) (*empty.Empt
 	return &empty.Empty{}, nil
 }
 
-// Send handles request for sending value to node
-func (p *ProgramNode) Send(ctx context.Context, in *pb.SendMessage) (*empty.Empty, error) {
-	switch in.Register {
-	case 0:
-		p.r0 <- int(in.Value)
-	case 1:
-		p.r1 <- int(in.Value)
-	case 2:
-		p.r2 <- int(in.Value)
-	case 3:
-		p.r3 <- int(in.Value)
-	default:
-		return nil, fmt.Errorf("not a valid register")
+// LoadBytecode handles request to reset node and load compiled program
+func (p *ProgramNode) LoadBytecode(ctx context.Context, in *pb.BytecodeMessage) (*empty.Empty, error) {
+	prog, err := tis.Decode(in.Bytecode)
+	if err != nil {
+		return nil, err
 	}
-	log.Printf("received value")
+	p.resetNode(prog)
 	return &empty.Empty{}, nil
 }
 
-// LoadProgram loads program onto node
-func (p *ProgramNode) LoadProgram(s string) error {
-	instrArr := strings.Split(s, "\n")
-	labelMap, err := tis.GenerateLabelMap(instrArr)
+// GetProgram handles request for loaded program in canonical form
+// with the source line of each instruction
+func (p *ProgramNode) GetProgram(ctx context.Context, in *empty.Empty) (*pb.LoadMessage, error) {
+	prog := p.machineView().prog
+	res := &pb.LoadMessage{Program: tis.Disassemble(prog)}
+	for _, instr := range prog.Instructions {
+		res.Lines = append(res.Lines, int32(instr.Pos.Line))
+	}
+	return res, nil
+}
+
+// GetNodeState handles request for lifecycle state of node
+func (p *ProgramNode) GetNodeState(ctx context.Context, in *empty.Empty) (*pb.NodeStateMessage, error) {
+	return &pb.NodeStateMessage{State: p.life.State().String()}, nil
+}
+
+// Send handles request for sending value to node.
+// Values sent to ANY go to whichever register has room first.
+// Requests sent again are only put into a register once.
+func (p *ProgramNode) Send(ctx context.Context, in *pb.SendMessage) (*pb.PortMessage, error) {
+	r, err := p.replays.once(ctx, in.Session, in.Seq, func() (int64, error) {
+		res, err := p.receiveValue(ctx, in)
+		if err != nil {
+			return 0, err
+		}
+		return int64(res.Register), nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &pb.PortMessage{Register: int32(r)}, nil
+}
 
-	asm, err := tis.Tokenize(instrArr, labelMap)
+// receiveValue puts value sent to node into register
+func (p *ProgramNode) receiveValue(ctx context.Context, in *pb.SendMessage) (*pb.PortMessage, error) {
+	v := in.Value
+	if in.Cycle > 0 {
+		return p.stageValue(v, in)
+	}
+	if in.Any {
+		registers := p.regs()
+		i, err := putAny(ctx, registers[:], v)
+		if err != nil {
+			return nil, fmt.Errorf("send cancelled")
+		}
+		logging.FromContext(ctx, p.logger).Debug("received value", "register", tis.R0+tis.Register(i), "value", v)
+		return &pb.PortMessage{Register: int32(i)}, nil
+	}
+
+	registers := p.regs()
+	if in.Register < 0 || int(in.Register) >= len(registers) {
+		return nil, fmt.Errorf("not a valid register")
+	}
+	if err := registers[in.Register].Put(ctx, v); err != nil {
+		return nil, fmt.Errorf("send cancelled")
+	}
+	logging.FromContext(ctx, p.logger).Debug("received value", "register", tis.R0+tis.Register(in.Register), "value", v)
+	return &pb.PortMessage{Register: in.Register}, nil
+}
+
+// stageValue puts value sent in lockstep mode into a free register.
+// A register is free if it was empty at the start of the cycle and nothing
+// else was written to it this cycle. The value can be read next cycle.
+func (p *ProgramNode) stageValue(v int64, in *pb.SendMessage) (*pb.PortMessage, error) {
+	p.regMux.Lock()
+	defer p.regMux.Unlock()
+
+	registers := p.regs()
+	free := func(i int) bool {
+		_, full := registers[i].Peek()
+		return !full && !p.pending[i].ok && p.takenAt[i] != in.Cycle
+	}
+	i := int(in.Register)
+	if in.Any {
+		i = -1
+		for j := range p.pending {
+			if free(j) {
+				i = j
+				break
+			}
+		}
+	} else if i < 0 || i >= len(p.pending) {
+		return nil, fmt.Errorf("not a valid register")
+	}
+	if i < 0 || !free(i) {
+		return nil, errWouldBlock
+	}
+
+	p.pending[i] = pendingValue{value: v, cycle: in.Cycle, ok: true}
+	p.logger.Debug("received value", "register", tis.R0+tis.Register(i), "value", v, "cycle", in.Cycle)
+	return &pb.PortMessage{Register: int32(i)}, nil
+}
+
+// Tick handles request to run one instruction in lockstep mode
+func (p *ProgramNode) Tick(ctx context.Context, in *pb.CycleMessage) (*pb.TickReply, error) {
+	p.runMux.Lock()
+	defer p.runMux.Unlock()
+	switch state := p.life.State(); {
+	case state.running():
+		return nil, status.Error(codes.FailedPrecondition, "node is running freely")
+	case state == StateFaulted:
+		return &pb.TickReply{Fault: p.faultReason()}, nil
+	}
+	p.machineMux.Lock()
+	defer p.machineMux.Unlock()
+	p.commitPending(in.Cycle)
+	p.stats.tick()
+
+	// Ports do not wait in lockstep mode, so calls only end early if node is reset
+	err := p.update(p.life.Context(), in.Cycle)
+	if err == tis.ErrBlocked {
+		return &pb.TickReply{Blocked: true}, nil
+	}
+	p.waits.clear()
+	if isFault(err) {
+		p.setFault(err)
+		logging.FromContext(ctx, p.logger).Error("node faulted", "line", p.line(), "err", err, "cycle", in.Cycle)
+		return &pb.TickReply{Fault: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TickReply{}, nil
+}
+
+// commitPending makes values written before cycle readable
+func (p *ProgramNode) commitPending(cycle int64) {
+	p.regMux.Lock()
+	defer p.regMux.Unlock()
+	registers := p.regs()
+	for i := range p.pending {
+		if p.pending[i].ok && p.pending[i].cycle < cycle {
+			registers[i].TryPut(p.pending[i].value)
+			p.pending[i] = pendingValue{}
+		}
+	}
+}
+
+// LoadProgram resets node and loads program onto it
+func (p *ProgramNode) LoadProgram(s string) error {
+	prog, err := tis.Assemble(s, tis.PreprocessOptions{Include: includer(p.includeDir)})
 	if err != nil {
 		return err
 	}
 
-	p.asm = asm
-	p.labelMap = labelMap
+	p.resetNode(prog)
 	return nil
 }
 
-// stopNode stops program execution
-func (p *ProgramNode) stopNode() {
-	p.cancel()
-	p.isRunning = false
+// resetNode stops and resets program node, loading prog if it is not nil.
+// Waits for instruction being run to give up.
+func (p *ProgramNode) resetNode(prog *tis.Program) {
+	p.runMux.Lock()
+	defer p.runMux.Unlock()
+	loaded := prog
+	if loaded == nil {
+		loaded = p.machineView().prog
+	}
+	state := StateIdle
+	if size, _ := programSize(loaded); size > 0 {
+		state = StateLoaded
+	}
+	// Always allowed. Ends current run so instruction being run stops waiting on ports.
+	p.life.set(state)
 
-	// Re-create context
-	nodeCtx, cancel := context.WithCancel(context.Background())
-	p.ctx = nodeCtx
-	p.cancel = cancel
+	p.machineMux.Lock()
+	defer p.machineMux.Unlock()
+	if prog != nil {
+		p.machine.Load(prog)
+	}
+	p.machine.Reset()
+	p.clearFault()
+	p.setStopReason("")
+	p.cycle = 0
+	p.session = newSession()
+	p.seq = 0
+	p.replays.reset()
+	p.regMux.Lock()
+	p.pending = [4]pendingValue{}
+	p.takenAt = [4]int64{}
+	p.regMux.Unlock()
+	p.waits.reset()
+	p.stats.reset()
+
+	p.closePeers()
+	p.credits.reset()
+	p.regsMux.Lock()
+	p.registers = newRegisters()
+	p.regsMux.Unlock()
+	p.publish()
 }
 
-// resetNode resets program node
-func (p *ProgramNode) resetNode() {
-	p.acc = 0
-	p.bak = 0
-	p.ptr = 0
+// regs gets network registers. They are replaced when node resets.
+func (p *ProgramNode) regs() [4]*register {
+	p.regsMux.RLock()
+	defer p.regsMux.RUnlock()
+	return p.registers
+}
 
-	p.r0 = make(chan int, bufferSize)
-	p.r1 = make(chan int, bufferSize)
-	p.r2 = make(chan int, bufferSize)
-	p.r3 = make(chan int, bufferSize)
+// line gets source line of current instruction. Must hold machineMux.
+func (p *ProgramNode) line() int {
+	return p.machine.Instruction().Pos.Line
 }
 
-// Update steps through asm
-func (p *ProgramNode) update() error {
-	tokens := p.asm[p.ptr]
+// machineView is copy of machine state taken between instructions
+type machineView struct {
+	prog     *tis.Program
+	ptr      int
+	acc, bak int64
+	instr    tis.Instruction
+}
 
-	// TODO: remove this
-	log.Printf("%v\nACC: %v\nBAK: %v\n", tokens, p.acc, p.bak)
+// publish copies machine state for readers. Must hold machineMux.
+func (p *ProgramNode) publish() {
+	p.viewMux.Lock()
+	defer p.viewMux.Unlock()
+	p.view = machineView{
+		prog:  p.machine.Program(),
+		ptr:   p.machine.Ptr,
+		acc:   p.machine.Acc,
+		bak:   p.machine.Bak,
+		instr: p.machine.Instruction(),
+	}
+}
 
-	switch tokens[0] {
-	case "NOP":
-		// no-op
-	case "MOV_VAL_LOCAL":
-		// Moves value locally
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		switch tokens[2] {
-		case "ACC":
-			p.acc = v
-		case "NIL":
-			// no-op
-		default:
-			return fmt.Errorf("'%s' not a valid local register", tokens[2])
-		}
-	case "MOV_VAL_NETWORK":
-		// Moves value across network
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		err = p.sendValue(v, tokens[2])
-		if err != nil {
-			return err
-		}
-	case "MOV_SRC_LOCAL":
-		// Moves value from src locally
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		switch tokens[2] {
-		case "ACC":
-			p.acc = v
-		case "NIL":
-			// no-op
-		default:
-			return fmt.Errorf("'%s' not a valid local register", tokens[2])
-		}
-	case "MOV_SRC_NETWORK":
-		// Moves value from src across network
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		err = p.sendValue(v, tokens[2])
-		if err != nil {
-			return err
-		}
-	case "SWP":
-		// Swaps ACC and BAK
-		temp := p.acc
-		p.acc = p.bak
-		p.bak = temp
-	case "SAV":
-		// Saves ACC to BAK
-		p.bak = p.acc
-	case "ADD_VAL":
-		// Adds value to ACC
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.acc += v
-	case "SUB_VAL":
-		// Subs value from ACC
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.acc -= v
-	case "ADD_SRC":
-		// Adds value in src to ACC
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.acc += v
-	case "SUB_SRC":
-		// Subs value in src from ACC
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.acc -= v
-	case "NEG":
-		// Negates ACC
-		p.acc = -p.acc
-	case "JMP":
-		// Jumps unconditionally to label
-		label := tokens[1]
-		p.ptr = p.labelMap[label]
-		return nil
-	case "JEZ":
-		// Jump if ACC equals zero
-		if p.acc == 0 {
-			label := tokens[1]
-			p.ptr = p.labelMap[label]
-			return nil
-		}
-	case "JNZ":
-		// Jump if ACC not zero
-		if p.acc != 0 {
-			label := tokens[1]
-			p.ptr = p.labelMap[label]
-			return nil
-		}
-	case "JGZ":
-		// Jump if ACC greater than zero
-		if p.acc > 0 {
-			label := tokens[1]
-			p.ptr = p.labelMap[label]
-			return nil
-		}
-	case "JLZ":
-		// Jump if ACC less than zero
-		if p.acc < 0 {
-			label := tokens[1]
-			p.ptr = p.labelMap[label]
-			return nil
-		}
-	case "JRO_VAL":
-		// Jumps by value offset
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.ptr = utils.IntClamp(p.ptr+v, 0, len(p.asm)-1)
-		return nil
-	case "JRO_SRC":
-		// Jumps by value offset
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		p.ptr = utils.IntClamp(p.ptr+v, 0, len(p.asm)-1)
-		return nil
-	case "PUSH_VAL":
-		// Moves value across network
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		err = p.pushValue(v, tokens[2])
-		if err != nil {
-			return err
-		}
-	case "PUSH_SRC":
-		// Moves value from src across network
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
-		}
-		err = p.pushValue(v, tokens[2])
-		if err != nil {
-			return err
-		}
-	case "POP":
-		v, err := p.popValue(tokens[1])
-		if err != nil {
-			return err
-		}
-		switch tokens[2] {
-		case "ACC":
-			p.acc = v
-		case "NIL":
-			// no-op
-		}
-	case "IN":
-		v, err := p.inputValue()
-		if err != nil {
-			return err
-		}
-		switch tokens[1] {
-		case "ACC":
-			p.acc = v
-		case "NIL":
-			// no-op
-		}
-	case "OUT_VAL":
-		v, err := strconv.Atoi(tokens[1])
-		if err != nil {
-			return err
-		}
-		err = p.outputValue(v)
-		if err != nil {
-			return err
-		}
-	case "OUT_SRC":
-		v, err := p.getFromSrc(tokens[1])
-		if err != nil {
-			return err
+// machineView gets machine state as of last instruction
+func (p *ProgramNode) machineView() machineView {
+	p.viewMux.Lock()
+	defer p.viewMux.Unlock()
+	return p.view
+}
+
+// update runs current instruction with ctx in lockstep cycle, or 0 if running freely.
+// Must hold machineMux.
+func (p *ProgramNode) update(ctx context.Context, cycle int64) error {
+	p.ctx = ctx
+	p.cycle = cycle
+	defer p.publish()
+
+	instr := p.machine.Instruction()
+	var e *traceEvent
+	if p.tracer != nil {
+		e = p.tracer.begin(p.machine, p.cycle)
+	}
+	err := p.machine.Step()
+	if e != nil {
+		p.tracer.end(e, p.machine, err)
+	}
+	p.stats.record(instr.Op, err)
+	if p.logger.Enabled(logging.LevelDebug) {
+		p.logger.Debug("ran instruction", "line", instr.Pos.Line, "instruction", instr, "acc", p.machine.Acc,
+			"bak", p.machine.Bak, "cycle", p.cycle, "err", err)
+	}
+	return err
+}
+
+// nodePorts connects program node machine to network over gRPC.
+// Ports wait until they complete or node is stopped.
+// In lockstep mode they return tis.ErrBlocked instead of waiting.
+type nodePorts struct {
+	p *ProgramNode
+}
+
+// Read waits for value in network register
+func (n nodePorts) Read(r tis.Register) (int64, error) {
+	n.wait(tis.Port{Kind: tis.ReadPort, Register: r})
+	if n.p.cycle > 0 {
+		v, ok := n.takeNow(r)
+		if !ok {
+			return 0, tis.ErrBlocked
 		}
-		err = p.outputValue(v)
-		if err != nil {
-			return err
+		return v, n.done(nil)
+	}
+
+	registers := n.p.regs()
+	v, err := registers[r.Index()].Take(n.p.ctx)
+	if err != nil {
+		return 0, n.done(fmt.Errorf("register retrieval cancelled"))
+	}
+	n.p.credits.release(registers)
+	return v, n.done(nil)
+}
+
+// ReadAny reads from whichever network register has a value first
+func (n nodePorts) ReadAny(order []tis.Register) (int64, tis.Register, error) {
+	n.wait(tis.Port{Kind: tis.ReadPort, Register: tis.ANY})
+	if n.p.cycle > 0 {
+		for _, r := range order {
+			if v, ok := n.takeNow(r); ok {
+				return v, r, n.done(nil)
+			}
 		}
-	default:
-		return fmt.Errorf("'%v' not a valid instruction", tokens)
+		return 0, tis.NIL, tis.ErrBlocked
 	}
 
-	// Move instruction pointer
-	p.ptr = (p.ptr + 1) % len(p.asm)
+	indices := make([]int, len(order))
+	for i, r := range order {
+		indices[i] = r.Index()
+	}
+	registers := n.p.regs()
+	v, i, err := takeAny(n.p.ctx, registers[:], indices)
+	if err != nil {
+		return 0, tis.NIL, n.done(fmt.Errorf("register retrieval cancelled"))
+	}
+	n.p.credits.release(registers)
+	return v, tis.R0 + tis.Register(i), n.done(nil)
+}
 
-	return nil
+// takeNow takes value from network register in lockstep mode if it has one
+func (n nodePorts) takeNow(r tis.Register) (int64, bool) {
+	n.p.regMux.Lock()
+	defer n.p.regMux.Unlock()
+	v, ok := n.p.regs()[r.Index()].TryTake()
+	if ok {
+		n.p.takenAt[r.Index()] = n.p.cycle
+	}
+	return v, ok
 }
 
-// Gets value from src register
-func (p *ProgramNode) getFromSrc(src string) (int, error) {
-	switch src {
-	case "ACC":
-		return p.acc, nil
-	case "NIL":
-		return 0, nil
-	case "R0":
-		select {
-		case v := <-p.r0:
-			return v, nil
-		case <-p.ctx.Done():
-			return 0, fmt.Errorf("register retrieval cancelled")
-		}
-	case "R1":
-		select {
-		case v := <-p.r1:
-			return v, nil
-		case <-p.ctx.Done():
-			return 0, fmt.Errorf("register retrieval cancelled")
-		}
-	case "R2":
-		select {
-		case v := <-p.r2:
-			return v, nil
-		case <-p.ctx.Done():
-			return 0, fmt.Errorf("register retrieval cancelled")
-		}
-	case "R3":
-		select {
-		case v := <-p.r3:
-			return v, nil
-		case <-p.ctx.Done():
-			return 0, fmt.Errorf("register retrieval cancelled")
-		}
-	default:
-		return 0, fmt.Errorf("'%s' not a valid src", src)
-	}
-}
-
-// sendValue sends value from this node to target in network
-func (p *ProgramNode) sendValue(v int, target string) error {
-	if m := regexp.MustCompile(`^(\w+):(R[0123])$`).FindStringSubmatch(target); len(m) > 0 {
-		targetURI := m[1]
-
-		// TODO: change parser to get ints
-		var register int32
-		switch m[2] {
-		case "R0":
-			register = 0
-		case "R1":
-			register = 1
-		case "R2":
-			register = 2
-		case "R3":
-			register = 3
-		}
+// Write sends value to register on peer
+func (n nodePorts) Write(node string, r tis.Register, v int64) (tis.Register, error) {
+	n.wait(tis.Port{Kind: tis.WritePort, Node: node, Register: r})
+	r, err := n.p.sendValue(v, node, r)
+	return r, n.done(blocked(err))
+}
 
-		conn, err := grpc.Dial(fmt.Sprintf("%s%s", targetURI, grpcPort), p.dialOpts...)
-		if err != nil {
-			log.Fatalf("did not connect: %v", err)
-		}
-		defer conn.Close()
-		c := pb.NewProgramClient(conn)
-		_, err = c.Send(p.ctx, &pb.SendMessage{Register: register, Value: int32(v)})
-		if err != nil {
-			return err
-		}
-		return nil
+// Push pushes value to stack node
+func (n nodePorts) Push(node string, v int64) error {
+	n.wait(tis.Port{Kind: tis.PushPort, Node: node})
+	return n.done(blocked(n.p.pushValue(v, node)))
+}
+
+// Pop pops value from stack node
+func (n nodePorts) Pop(node string) (int64, error) {
+	n.wait(tis.Port{Kind: tis.PopPort, Node: node})
+	v, err := n.p.popValue(node)
+	return v, n.done(blocked(err))
+}
+
+// In gets value from master input
+func (n nodePorts) In() (int64, error) {
+	n.wait(tis.Port{Kind: tis.InPort})
+	v, err := n.p.inputValue()
+	return v, n.done(blocked(err))
+}
+
+// Out sends value to master output
+func (n nodePorts) Out(v int64) error {
+	n.wait(tis.Port{Kind: tis.OutPort})
+	return n.done(blocked(n.p.outputValue(v)))
+}
+
+// wait marks node as waiting on port until request finishes
+func (n nodePorts) wait(port tis.Port) {
+	n.p.waits.set(port)
+	n.p.life.swap(StateRunning, StateBlocked)
+}
+
+// done clears port node is waiting on once request finishes.
+// In lockstep mode a request that would block leaves node waiting until it succeeds.
+func (n nodePorts) done(err error) error {
+	if err != tis.ErrBlocked {
+		n.p.waits.clear()
+		n.p.life.swap(StateBlocked, StateRunning)
+	}
+	return err
+}
+
+// blocked converts would-block errors from peers into tis.ErrBlocked
+func blocked(err error) error {
+	if err != nil && isWouldBlock(err) {
+		return tis.ErrBlocked
 	}
+	return err
+}
 
-	return fmt.Errorf("'%s' not a valid network register", target)
+// newRegisters creates empty network registers R0-R3
+func newRegisters() [4]*register {
+	return [4]*register{newRegister(), newRegister(), newRegister(), newRegister()}
 }
 
-// pushValue pushes value from this node to target in network
-func (p *ProgramNode) pushValue(v int, targetURI string) error {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", targetURI, grpcPort), p.dialOpts...)
+// sendValue sends value from this node to register on target in network.
+// Returns register value was put into.
+// Running freely, values go over stream to target. In lockstep mode each value is its own call.
+func (p *ProgramNode) sendValue(v int64, targetURI string, register tis.Register) (tis.Register, error) {
+	defer p.metrics.callLatency.ObserveSince(time.Now(), "Send")
+	if p.cycle == 0 {
+		return p.peerStream(targetURI).write(p.ctx, register, v)
+	}
+	msg := &pb.SendMessage{Value: v, Cycle: p.cycle, Session: p.session, Seq: p.seq + 1}
+	if register == tis.ANY {
+		msg.Any = true
+	} else {
+		msg.Register = int32(register.Index())
+	}
+	var r *pb.PortMessage
+	err := p.call(targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		r, err = pb.NewProgramClient(conn).Send(ctx, msg)
+		return err
+	})
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return tis.NIL, err
 	}
-	defer conn.Close()
-	c := pb.NewStackClient(conn)
-	_, err = c.Push(p.ctx, &pb.ValueMessage{Value: int32(v)})
+	p.seq++
+	return tis.R0 + tis.Register(r.GetRegister()), nil
+}
+
+// pushValue pushes value from this node to target in network
+func (p *ProgramNode) pushValue(v int64, targetURI string) error {
+	defer p.metrics.callLatency.ObserveSince(time.Now(), "Push")
+	msg := &pb.ValueMessage{Value: v, Cycle: p.cycle, Session: p.session, Seq: p.seq + 1}
+	err := p.call(targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := pb.NewStackClient(conn).Push(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	p.seq++
 	return nil
 }
 
 // popValue pops and retrieves value from source in network
-func (p *ProgramNode) popValue(sourceURI string) (int, error) {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", sourceURI, grpcPort), p.dialOpts...)
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := pb.NewStackClient(conn)
-	r, err := c.Pop(p.ctx, &empty.Empty{})
+func (p *ProgramNode) popValue(sourceURI string) (int64, error) {
+	defer p.metrics.callLatency.ObserveSince(time.Now(), "Pop")
+	msg := &pb.CycleMessage{Cycle: p.cycle, Session: p.session, Seq: p.seq + 1}
+	var r *pb.ValueMessage
+	err := p.call(sourceURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		r, err = pb.NewStackClient(conn).Pop(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return -1, err
 	}
-	return int(r.GetValue()), nil
+	p.seq++
+	return r.GetValue(), nil
 }
 
 // inputValue retrieves an input value from master node
-func (p *ProgramNode) inputValue() (int, error) {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", p.masterURI, grpcPort), p.dialOpts...)
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := pb.NewMasterClient(conn)
-	r, err := c.GetInput(p.ctx, &empty.Empty{})
+func (p *ProgramNode) inputValue() (int64, error) {
+	defer p.metrics.callLatency.ObserveSince(time.Now(), "GetInput")
+	msg := &pb.CycleMessage{Cycle: p.cycle, Session: p.session, Seq: p.seq + 1}
+	var r *pb.ValueMessage
+	err := p.call(p.masterURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		var err error
+		r, err = pb.NewMasterClient(conn).GetInput(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return -1, err
 	}
-	return int(r.GetValue()), nil
+	p.seq++
+	return r.GetValue(), nil
 }
 
 // outputValue outputs value from this node to master node
-func (p *ProgramNode) outputValue(v int) error {
-	conn, err := grpc.Dial(fmt.Sprintf("%s%s", p.masterURI, grpcPort), p.dialOpts...)
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := pb.NewMasterClient(conn)
-	_, err = c.SendOutput(p.ctx, &pb.ValueMessage{Value: int32(v)})
+func (p *ProgramNode) outputValue(v int64) error {
+	defer p.metrics.callLatency.ObserveSince(time.Now(), "SendOutput")
+	msg := &pb.ValueMessage{Value: v, Cycle: p.cycle, Session: p.session, Seq: p.seq + 1}
+	err := p.call(p.masterURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+		_, err := pb.NewMasterClient(conn).SendOutput(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	p.seq++
 	return nil
 }
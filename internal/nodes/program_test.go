@@ -0,0 +1,42 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+// newTestProgramNode creates a program node that is not serving
+func newTestProgramNode(t testing.TB) *ProgramNode {
+	certFile, keyFile := testCerts(t)
+	return NewProgramNode("localhost", "", tis.NumericInt64, certFile, keyFile)
+}
+
+func TestReadAnyDoesNotStarveRegisters(t *testing.T) {
+	p := newTestProgramNode(t)
+	if err := p.LoadProgram("MOV ANY, ACC"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refill every register before each read so all of them always hold a value
+	const reads = 2000
+	counts := make(map[tis.Register]int)
+	p.machineMux.Lock()
+	for i := 0; i < reads; i++ {
+		for v, r := range p.regs() {
+			r.TryPut(int64(v))
+		}
+		if err := p.update(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+		counts[p.machine.LastIn.Register]++
+	}
+	p.machineMux.Unlock()
+
+	for _, r := range []tis.Register{tis.R0, tis.R1, tis.R2, tis.R3} {
+		if counts[r] != reads/4 {
+			t.Errorf("%s read %d of %d times: %v", r, counts[r], reads, counts)
+		}
+	}
+}
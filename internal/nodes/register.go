@@ -0,0 +1,154 @@
+package nodes
+
+import (
+	"context"
+	"sync"
+)
+
+// register is a network register on a program node holding at most one value
+type register struct {
+	mux   sync.Mutex
+	full  bool
+	value int64
+
+	// changed is closed and replaced whenever register is filled or emptied
+	changed chan struct{}
+}
+
+// newRegister creates an empty register
+func newRegister() *register {
+	return &register{changed: make(chan struct{})}
+}
+
+// notify wakes everyone waiting on register. Must hold mux.
+func (r *register) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// TryPut puts value into register if it is empty
+func (r *register) TryPut(v int64) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.full {
+		return false
+	}
+	r.full = true
+	r.value = v
+	r.notify()
+	return true
+}
+
+// TryTake takes value from register if it has one
+func (r *register) TryTake() (int64, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.full {
+		return 0, false
+	}
+	r.full = false
+	r.notify()
+	return r.value, true
+}
+
+// Peek gets value in register without taking it
+func (r *register) Peek() (int64, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.value, r.full
+}
+
+// Changed gets channel that is closed on next change to register
+func (r *register) Changed() <-chan struct{} {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.changed
+}
+
+// Put waits until register is empty and puts value into it
+func (r *register) Put(ctx context.Context, v int64) error {
+	for {
+		changed := r.Changed()
+		if r.TryPut(v) {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Take waits until register has a value and takes it
+func (r *register) Take(ctx context.Context) (int64, error) {
+	for {
+		changed := r.Changed()
+		if v, ok := r.TryTake(); ok {
+			return v, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// putAny waits until any of registers is empty and puts value into first empty one.
+// Returns index of register used.
+func putAny(ctx context.Context, registers []*register, v int64) (int, error) {
+	for {
+		changed := make([]<-chan struct{}, len(registers))
+		for i, r := range registers {
+			changed[i] = r.Changed()
+			if r.TryPut(v) {
+				return i, nil
+			}
+		}
+		if err := waitAny(ctx, changed); err != nil {
+			return -1, err
+		}
+	}
+}
+
+// takeAny waits until any of registers has a value and takes it. Returns index of register used.
+// Registers at indices are tried in order without waiting, so callers rotate order to take turns.
+// If none has a value, waits for whichever changes first, chosen at random if several change
+// together, then tries them in order again.
+func takeAny(ctx context.Context, registers []*register, order []int) (int64, int, error) {
+	for {
+		changed := make([]<-chan struct{}, len(order))
+		for i, idx := range order {
+			changed[i] = registers[idx].Changed()
+			if v, ok := registers[idx].TryTake(); ok {
+				return v, idx, nil
+			}
+		}
+		if err := waitAny(ctx, changed); err != nil {
+			return 0, -1, err
+		}
+	}
+}
+
+// waitAny waits until any of changed is closed
+func waitAny(ctx context.Context, changed []<-chan struct{}) error {
+	done := make(chan struct{})
+	defer close(done)
+	woken := make(chan struct{}, len(changed))
+	for _, c := range changed {
+		go func(c <-chan struct{}) {
+			select {
+			case <-c:
+				woken <- struct{}{}
+			case <-done:
+			}
+		}(c)
+	}
+	select {
+	case <-woken:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package nodes
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Port requests from program nodes name the node's session and a seq. A request cut short by a
+// pause, a timeout or a lost reply is sent again with the same seq when its instruction resumes.
+// Nodes serving requests remember the last one from each session and answer it again instead of
+// serving it twice, so resumed instructions neither lose nor duplicate values.
+// Senders start a new session whenever they are reset, so only the most recently used sessions
+// are remembered.
+
+// maxReplaySessions bounds sessions each node remembers
+const maxReplaySessions = 1024
+
+// replayLog remembers last request served for each of the most recently used sessions,
+// forgetting the least recently used once it is full
+type replayLog struct {
+	mux      sync.Mutex
+	size     int
+	order    *list.List
+	sessions map[string]*list.Element
+}
+
+// replayEntry is last request served for one session
+type replayEntry struct {
+	session string
+	// lock is held while a request from session is served
+	lock  chan struct{}
+	seq   uint64
+	value int64
+}
+
+// newReplayLog creates log that has served nothing and remembers at most size sessions
+func newReplayLog(size int) *replayLog {
+	return &replayLog{
+		size:     size,
+		order:    list.New(),
+		sessions: make(map[string]*list.Element),
+	}
+}
+
+// once serves request seq from session with serve unless it was already served,
+// in which case the value it got is returned again. Requests from one session are
+// served one at a time. Requests with no session are always served.
+func (l *replayLog) once(ctx context.Context, session string, seq uint64, serve func() (int64, error)) (int64, error) {
+	if session == "" {
+		return serve()
+	}
+
+	e := l.entry(session)
+	select {
+	case e.lock <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	defer func() { <-e.lock }()
+
+	if seq == e.seq {
+		return e.value, nil
+	}
+	v, err := serve()
+	if err != nil {
+		return 0, err
+	}
+	e.seq, e.value = seq, v
+	return v, nil
+}
+
+// entry gets entry for session, creating it if needed
+func (l *replayLog) entry(session string) *replayEntry {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if e, ok := l.sessions[session]; ok {
+		l.order.MoveToFront(e)
+		return e.Value.(*replayEntry)
+	}
+	e := &replayEntry{session: session, lock: make(chan struct{}, 1)}
+	l.sessions[session] = l.order.PushFront(e)
+	for l.order.Len() > l.size {
+		last := l.order.Back()
+		l.order.Remove(last)
+		delete(l.sessions, last.Value.(*replayEntry).session)
+	}
+	return e
+}
+
+// len gets number of sessions remembered
+func (l *replayLog) len() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.order.Len()
+}
+
+// reset forgets every session
+func (l *replayLog) reset() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.order.Init()
+	l.sessions = make(map[string]*list.Element)
+}
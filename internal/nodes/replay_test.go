@@ -0,0 +1,52 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReplayLogServesRequestOnce(t *testing.T) {
+	ctx := context.Background()
+	l := newReplayLog(maxReplaySessions)
+	served := 0
+	serve := func() (int64, error) {
+		served++
+		return int64(served), nil
+	}
+
+	for _, want := range []struct {
+		seq    uint64
+		value  int64
+		served int
+	}{{1, 1, 1}, {1, 1, 1}, {2, 2, 2}, {2, 2, 2}, {3, 3, 3}} {
+		v, err := l.once(ctx, "a", want.seq, serve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want.value || served != want.served {
+			t.Errorf("once(%d) = %d after serving %d times, want %d after %d", want.seq, v, served, want.value, want.served)
+		}
+	}
+}
+
+func TestReplayLogForgetsLeastRecentlyUsedSessions(t *testing.T) {
+	ctx := context.Background()
+	l := newReplayLog(2)
+	serve := func(v int64) func() (int64, error) {
+		return func() (int64, error) { return v, nil }
+	}
+	l.once(ctx, "a", 1, serve(1))
+	l.once(ctx, "b", 1, serve(2))
+	l.once(ctx, "a", 1, serve(3))
+	l.once(ctx, "c", 1, serve(4))
+
+	if l.len() != 2 {
+		t.Errorf("len() = %d, want 2", l.len())
+	}
+	if v, _ := l.once(ctx, "a", 1, serve(5)); v != 1 {
+		t.Errorf("once() = %d for remembered session, want 1", v)
+	}
+	if v, _ := l.once(ctx, "b", 1, serve(6)); v != 6 {
+		t.Errorf("once() = %d for forgotten session, want 6", v)
+	}
+}
@@ -0,0 +1,159 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy is how calls to other nodes are retried while a node cannot be reached
+type RetryPolicy struct {
+	// Attempts is how many times a call is tried before giving up
+	Attempts int
+	// BaseDelay is the wait before the first retry. It doubles every retry up to MaxDelay.
+	// Waits are jittered by up to half.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout bounds each attempt to connect, and each call that does not wait on peers.
+	// Zero has no deadline.
+	Timeout time.Duration
+}
+
+// DefaultRetryPolicy tries calls 5 times, waiting up to 3 seconds between attempts in total
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  5,
+	BaseDelay: 200 * time.Millisecond,
+	MaxDelay:  5 * time.Second,
+	Timeout:   5 * time.Second,
+}
+
+// unreachableError is returned once retries to reach a node run out
+type unreachableError struct {
+	target string
+	err    error
+}
+
+func (e *unreachableError) Error() string {
+	return fmt.Sprintf("node %s unreachable: %s", e.target, e.err.Error())
+}
+
+func (e *unreachableError) Unwrap() error {
+	return e.err
+}
+
+// dialError is a failure to connect to a node
+type dialError struct {
+	err error
+}
+
+func (e *dialError) Error() string {
+	return fmt.Sprintf("did not connect: %s", e.err.Error())
+}
+
+func (e *dialError) Unwrap() error {
+	return e.err
+}
+
+// backoff gets wait before retry n, counting from 0
+func (rp RetryPolicy) backoff(n int) time.Duration {
+	d := rp.BaseDelay
+	for i := 0; i < n && d < rp.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > rp.MaxDelay {
+		d = rp.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// do calls f until it succeeds or fails for a reason other than target being unreachable.
+// Gives up with unreachableError once attempts run out, or with error of ctx once it is done.
+func (rp RetryPolicy) do(ctx context.Context, target string, f func() error) error {
+	var err error
+	for n := 0; n < rp.Attempts || n == 0; n++ {
+		if n > 0 {
+			t := time.NewTimer(rp.backoff(n - 1))
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+		}
+		err = f()
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return &unreachableError{target: target, err: err}
+}
+
+// retryable checks if err is from node not being reachable
+func retryable(err error) bool {
+	var de *dialError
+	return errors.As(err, &de) || status.Code(err) == codes.Unavailable
+}
+
+// dial gets pooled connection to node at targetURI, waiting at most for timeout of rp
+func (rp RetryPolicy) dial(ctx context.Context, conns *connPool, targetURI string) (*grpc.ClientConn, error) {
+	dialCtx, cancel := rp.withTimeout(ctx)
+	defer cancel()
+	conn, err := conns.get(dialCtx, targetURI)
+	if err != nil {
+		return nil, &dialError{err}
+	}
+	return conn, nil
+}
+
+// withTimeout gets ctx with deadline of rp
+func (rp RetryPolicy) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if rp.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, rp.Timeout)
+}
+
+// SetRetryPolicy sets how program node retries calls to peers. Must be called before Start.
+func (p *ProgramNode) SetRetryPolicy(rp RetryPolicy) {
+	p.retry = rp
+}
+
+// SetRetryPolicy sets how master node retries calls to nodes. Must be called before Start.
+func (m *MasterNode) SetRetryPolicy(rp RetryPolicy) {
+	m.retry = rp
+}
+
+// call makes call to node at targetURI for instruction being run, retrying while node cannot be reached.
+// Calls wait on peers so only connecting has a deadline. Must hold machineMux.
+func (p *ProgramNode) call(targetURI string, call func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	ctx := p.ctx
+	return p.retry.do(ctx, targetURI, func() error {
+		conn, err := p.retry.dial(ctx, p.conns, targetURI)
+		if err != nil {
+			return err
+		}
+		return call(ctx, conn)
+	})
+}
+
+// call makes call with deadline to node at targetURI, retrying while node cannot be reached
+func (m *MasterNode) call(ctx context.Context, targetURI string, call func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	return m.retry.do(ctx, targetURI, func() error {
+		conn, err := m.retry.dial(ctx, m.conns, targetURI)
+		if err != nil {
+			return err
+		}
+		callCtx, cancel := m.retry.withTimeout(ctx)
+		defer cancel()
+		return call(callCtx, conn)
+	})
+}
@@ -0,0 +1,125 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rp := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for n, d := range []time.Duration{10, 20, 40, 50, 50, 50} {
+		d *= time.Millisecond
+		for i := 0; i < 100; i++ {
+			if got := rp.backoff(n); got < d/2 || got > d {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", n, got, d/2, d)
+			}
+		}
+	}
+
+	// Doubling stops at MaxDelay so it never overflows
+	if got := rp.backoff(1000); got < 25*time.Millisecond || got > 50*time.Millisecond {
+		t.Errorf("backoff(1000) = %v, want between 25ms and 50ms", got)
+	}
+	if got := (RetryPolicy{MaxDelay: time.Second}).backoff(3); got != 0 {
+		t.Errorf("backoff() = %v with no BaseDelay, want 0", got)
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	dialErr := &dialError{errors.New("timed out")}
+	invalid := status.Error(codes.InvalidArgument, "bad value")
+	tests := []struct {
+		name     string
+		attempts int
+		errs     []error
+		calls    int
+		want     error
+	}{
+		{"success", 3, []error{nil}, 1, nil},
+		{"retries unavailable", 3, []error{unavailable, nil}, 2, nil},
+		{"retries dial error", 3, []error{dialErr, dialErr, nil}, 3, nil},
+		{"does not retry other errors", 3, []error{invalid}, 1, invalid},
+		{"stops retrying on other errors", 3, []error{unavailable, invalid}, 2, invalid},
+		{"gives up", 3, []error{unavailable, dialErr, unavailable}, 3, unavailable},
+		{"no attempts tries once", 0, []error{dialErr}, 1, dialErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := RetryPolicy{Attempts: tt.attempts, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+			calls := 0
+			err := rp.do(context.Background(), "peer", func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if calls != tt.calls {
+				t.Errorf("do() called f %d times, want %d", calls, tt.calls)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("do() error = %v, want %v", err, tt.want)
+			}
+
+			// Errors from node staying unreachable are wrapped, others are returned as is
+			var unreachable *unreachableError
+			if gotUnreachable, wantUnreachable := errors.As(err, &unreachable), retryable(tt.want); gotUnreachable != wantUnreachable {
+				t.Errorf("do() error = %v, want unreachableError %v", err, wantUnreachable)
+			} else if gotUnreachable && unreachable.target != "peer" {
+				t.Errorf("unreachableError target = %q, want peer", unreachable.target)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoStopsOnCancel(t *testing.T) {
+	rp := RetryPolicy{Attempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	// Cancel while waiting to retry
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- rp.do(ctx, "peer", func() error {
+			calls++
+			return status.Error(codes.Unavailable, "connection refused")
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled || calls != 1 {
+			t.Errorf("do() = %v after %d calls, want %v after 1", err, calls, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("do() kept waiting to retry after cancel")
+	}
+
+	// Error of call that ended with ctx is returned without retrying
+	ctx, cancel = context.WithCancel(context.Background())
+	calls = 0
+	err := rp.do(ctx, "peer", func() error {
+		calls++
+		cancel()
+		return status.Error(codes.Unavailable, "context canceled")
+	})
+	if status.Code(err) != codes.Unavailable || calls != 1 {
+		t.Errorf("do() = %v after %d calls, want Unavailable after 1", err, calls)
+	}
+}
+
+func TestUnreachableError(t *testing.T) {
+	cause := &dialError{errors.New("timed out")}
+	err := error(&unreachableError{target: "peer:8000", err: cause})
+	if got, want := err.Error(), "node peer:8000 unreachable: did not connect: timed out"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, %v) = false, want true", err, cause)
+	}
+}
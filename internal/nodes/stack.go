@@ -3,11 +3,14 @@ package nodes
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
+	"sync"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/metrics"
+	"github.com/jasmaa/misaka-net/internal/tis"
 	"github.com/jasmaa/misaka-net/internal/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -16,12 +19,21 @@ import (
 // StackNode is a stack node
 type StackNode struct {
 	stack *utils.IntStack
+	model tis.NumericModel
 
-	ctx       context.Context
-	cancel    context.CancelFunc
-	isRunning bool
+	// Values pushed in lockstep mode wait here until the next cycle
+	pending    []pendingValue
+	pendingMux sync.Mutex
 
-	pushSignal chan interface{}
+	life    *lifecycle
+	replays *replayLog
+
+	// pushed is closed and replaced whenever a value is pushed
+	pushed    chan struct{}
+	pushedMux sync.Mutex
+
+	metrics *metrics.Registry
+	logger  *logging.Logger
 
 	certFile, keyFile string
 
@@ -29,127 +41,177 @@ type StackNode struct {
 }
 
 // NewStackNode creates a new stack node
-func NewStackNode(certFile, keyFile string) *StackNode {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &StackNode{
-		stack:      utils.NewIntStack(),
-		ctx:        ctx,
-		cancel:     cancel,
-		pushSignal: make(chan interface{}),
-		certFile:   certFile,
-		keyFile:    keyFile,
+func NewStackNode(model tis.NumericModel, certFile, keyFile string) *StackNode {
+	s := &StackNode{
+		stack:    utils.NewIntStack(),
+		model:    model,
+		life:     newLifecycle(StateIdle),
+		replays:  newReplayLog(maxReplaySessions),
+		pushed:   make(chan struct{}),
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logging.Default(),
 	}
+	s.metrics = newStackMetrics(s)
+	return s
 }
 
 // Start starts stack node
 func (s *StackNode) Start() {
+	go serveMetrics(s.metrics, s.logger)
+
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		s.logger.Fatal("failed to listen", "err", err)
 	}
 	creds, err := credentials.NewServerTLSFromFile(s.certFile, s.keyFile)
 	if err != nil {
-		log.Fatalf("failed to get creds: %v", err)
+		s.logger.Fatal("failed to get creds", "err", err)
 	}
-	server := grpc.NewServer(grpc.Creds(creds))
+	server := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(loggingServerInterceptor(s.logger)))
 	pb.RegisterStackServer(server, s)
-	log.Printf("starting grpc server...")
+	s.logger.Info("starting grpc server", "port", grpcPort)
 	if err := server.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		s.logger.Fatal("failed to serve", "err", err)
 	}
 }
 
 // Run handles request to run stack node
 func (s *StackNode) Run(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if !s.isRunning {
-		s.isRunning = true
-		log.Printf("node was run")
+	prev, err := s.life.set(StateRunning)
+	if err != nil {
+		return nil, err
+	}
+	if prev.running() {
+		logging.FromContext(ctx, s.logger).Info("node is already running", "cmd", "run")
 	} else {
-		log.Printf("node is already running")
+		logging.FromContext(ctx, s.logger).Info("node was run", "cmd", "run")
 	}
 	return &empty.Empty{}, nil
 }
 
 // Pause handles request to pause stack node
 func (s *StackNode) Pause(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if s.isRunning {
-		s.stopNode()
-		log.Printf("node was paused")
+	if s.life.stop(StatePaused) {
+		logging.FromContext(ctx, s.logger).Info("node was paused", "cmd", "pause")
 	} else {
-		log.Printf("node is already paused")
+		logging.FromContext(ctx, s.logger).Info("node is already paused", "cmd", "pause")
 	}
 	return &empty.Empty{}, nil
 }
 
 // Reset handles request to reset stack node
 func (s *StackNode) Reset(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
-	if s.isRunning {
-		s.stopNode()
-	}
 	s.resetNode()
-	log.Printf("node was reset")
+	logging.FromContext(ctx, s.logger).Info("node was reset", "cmd", "reset")
 	return &empty.Empty{}, nil
 }
 
-// Push handles request to push incoming value onto stack node
+// Push handles request to push incoming value onto stack node.
+// Requests sent again are only pushed once.
 func (s *StackNode) Push(ctx context.Context, in *pb.ValueMessage) (*empty.Empty, error) {
-	s.stack.Push(int(in.Value))
+	v := s.model.Normalize(in.Value)
+	_, err := s.replays.once(ctx, in.Session, in.Seq, func() (int64, error) {
+		s.push(v, in.Cycle)
+		return v, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
 
-	// Signal push with non-blocking send
-	select {
-	case s.pushSignal <- nil:
-	default:
+// push pushes value onto stack, or in lockstep mode once cycle is over
+func (s *StackNode) push(v int64, cycle int64) {
+	if cycle > 0 {
+		s.pendingMux.Lock()
+		s.pending = append(s.pending, pendingValue{value: v, cycle: cycle, ok: true})
+		s.pendingMux.Unlock()
+		return
 	}
 
-	return &empty.Empty{}, nil
+	s.stack.Push(int(v))
+	s.pushedMux.Lock()
+	close(s.pushed)
+	s.pushed = make(chan struct{})
+	s.pushedMux.Unlock()
 }
 
-// Pop handles request to pop and output value from stack node
-func (s *StackNode) Pop(ctx context.Context, in *empty.Empty) (*pb.ValueMessage, error) {
-	v, err := s.waitPop()
+// Pop handles request to pop and output value from stack node.
+// Requests sent again get the value popped the first time.
+func (s *StackNode) Pop(ctx context.Context, in *pb.CycleMessage) (*pb.ValueMessage, error) {
+	v, err := s.replays.once(ctx, in.Session, in.Seq, func() (int64, error) {
+		if in.Cycle > 0 {
+			s.commitPending(in.Cycle)
+			v, err := s.stack.Pop()
+			if err != nil {
+				return 0, errWouldBlock
+			}
+			return int64(v), nil
+		}
+		v, err := s.waitPop(ctx)
+		return int64(v), err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &pb.ValueMessage{Value: int32(v)}, nil
+	return &pb.ValueMessage{Value: v, Cycle: in.Cycle}, nil
 }
 
-// stopNode stops stack node
-func (s *StackNode) stopNode() {
-	s.cancel()
-	s.isRunning = false
+// GetNodeState handles request for lifecycle state of node
+func (s *StackNode) GetNodeState(ctx context.Context, in *empty.Empty) (*pb.NodeStateMessage, error) {
+	return &pb.NodeStateMessage{State: s.life.State().String()}, nil
+}
 
-	// Re-create node context
-	nodeCtx, cancel := context.WithCancel(context.Background())
-	s.ctx = nodeCtx
-	s.cancel = cancel
+// GetStack handles request for values in stack node
+func (s *StackNode) GetStack(ctx context.Context, in *empty.Empty) (*pb.StackMessage, error) {
+	res := &pb.StackMessage{}
+	for _, v := range s.stack.Values() {
+		res.Values = append(res.Values, int64(v))
+	}
+	return res, nil
 }
 
-// resetNode resets stack node
+// resetNode stops and resets stack node
 func (s *StackNode) resetNode() {
+	// Always allowed. Ends current run so waiting pops give up.
+	s.life.set(StateIdle)
 	s.stack.Clear()
+	s.pendingMux.Lock()
+	s.pending = nil
+	s.pendingMux.Unlock()
+	s.replays.reset()
 }
 
-// waitPop waits until value can be popped from stack and returns value
-func (s *StackNode) waitPop() (int, error) {
-
-	c := make(chan int, 1)
-	go func() {
-		for {
-			v, err := s.stack.Pop()
-			if err == nil {
-				c <- v
-				return
-			}
+// commitPending pushes values from cycles before cycle onto stack
+func (s *StackNode) commitPending(cycle int64) {
+	s.pendingMux.Lock()
+	defer s.pendingMux.Unlock()
+	i := 0
+	for ; i < len(s.pending) && s.pending[i].cycle < cycle; i++ {
+		s.stack.Push(int(s.pending[i].value))
+	}
+	s.pending = s.pending[i:]
+}
 
-			// Sleep until push occurs
-			<-s.pushSignal
+// waitPop waits until value can be popped from stack and returns value.
+// Gives up once node stops or resets, or ctx of request is done.
+func (s *StackNode) waitPop(ctx context.Context) (int, error) {
+	runCtx := s.life.Context()
+	for {
+		s.pushedMux.Lock()
+		pushed := s.pushed
+		s.pushedMux.Unlock()
+		if v, err := s.stack.Pop(); err == nil {
+			return v, nil
 		}
-	}()
 
-	select {
-	case v := <-c:
-		return v, nil
-	case <-s.ctx.Done():
-		return -1, fmt.Errorf("stack pop cancelled")
+		select {
+		case <-pushed:
+		case <-runCtx.Done():
+			return -1, fmt.Errorf("stack pop cancelled")
+		case <-ctx.Done():
+			return -1, fmt.Errorf("stack pop cancelled")
+		}
 	}
 }
@@ -0,0 +1,42 @@
+package nodes
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+func TestStackPushUsesNumericModel(t *testing.T) {
+	pushed := []int64{math.MaxInt64, math.MinInt64, 1<<40 + 7, -5000, 12}
+	tests := []struct {
+		model tis.NumericModel
+		want  []int64
+	}{
+		{tis.NumericTIS, []int64{999, -999, 999, -999, 12}},
+		{tis.NumericInt32, []int64{-1, 0, 7, -5000, 12}},
+		{tis.NumericInt64, []int64{math.MaxInt64, math.MinInt64, 1<<40 + 7, -5000, 12}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.model.String(), func(t *testing.T) {
+			s := NewStackNode(tt.model, "", "")
+			for _, v := range pushed {
+				if _, err := s.Push(ctx, &pb.ValueMessage{Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			res, err := s.GetStack(ctx, &empty.Empty{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Values, tt.want) {
+				t.Errorf("stack = %v, want %v", res.Values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+)
+
+// nodeStats counts instructions run by a program node
+type nodeStats struct {
+	mux          sync.Mutex
+	instructions int64
+	cycles       int64
+	blocked      int64
+	opcodes      map[tis.Opcode]int64
+}
+
+// record counts result of running instruction with op
+func (s *nodeStats) record(op tis.Opcode, err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	switch {
+	case err == nil:
+		s.instructions++
+		if s.opcodes == nil {
+			s.opcodes = make(map[tis.Opcode]int64)
+		}
+		s.opcodes[op]++
+	case err == tis.ErrBlocked:
+		s.blocked++
+	}
+}
+
+// counts gets instructions finished and lockstep cycles blocked
+func (s *nodeStats) counts() (int64, int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.instructions, s.blocked
+}
+
+// tick counts lockstep cycle
+func (s *nodeStats) tick() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.cycles++
+}
+
+// reset clears counts
+func (s *nodeStats) reset() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.instructions = 0
+	s.cycles = 0
+	s.blocked = 0
+	s.opcodes = nil
+}
+
+// GetStats handles request for counts of instructions run since node was reset
+func (p *ProgramNode) GetStats(ctx context.Context, in *empty.Empty) (*pb.StatsMessage, error) {
+	s := &p.stats
+	s.mux.Lock()
+	res := &pb.StatsMessage{
+		Instructions: s.instructions,
+		Cycles:       s.cycles,
+		Blocked:      s.blocked,
+	}
+	res.Size, res.Used = programSize(p.machineView().prog)
+	for op, count := range s.opcodes {
+		res.Opcodes = append(res.Opcodes, &pb.OpcodeCount{Op: op.String(), Count: count})
+	}
+	s.mux.Unlock()
+
+	sort.Slice(res.Opcodes, func(i, j int) bool { return res.Opcodes[i].Op < res.Opcodes[j].Op })
+	res.WaitTime = int64(p.waits.waitTime())
+	return res, nil
+}
+
+// programSize counts instructions in program, ignoring blank lines.
+// Checks if program does anything but NOP.
+func programSize(prog *tis.Program) (int32, bool) {
+	var size int32
+	used := false
+	for _, instr := range prog.Instructions {
+		if !instr.Blank {
+			size++
+			used = used || instr.Op != tis.NOP
+		}
+	}
+	return size, used
+}
+
+// clientNodeStats structures stats of a program node sent to client
+type clientNodeStats struct {
+	Size     int              `json:"size"`
+	Used     bool             `json:"used"`
+	Executed int64            `json:"executed"`
+	Cycles   int64            `json:"cycles"`
+	Blocked  int64            `json:"blocked"`
+	WaitTime int64            `json:"waitTime"`
+	Opcodes  map[string]int64 `json:"opcodes"`
+}
+
+// clientStatsResponse structures stats of whole network sent to client.
+// Cycles, nodes and instructions are the TIS-100 score.
+type clientStatsResponse struct {
+	Cycles       int64                      `json:"cycles"`
+	Nodes        int                        `json:"nodes"`
+	Instructions int                        `json:"instructions"`
+	Executed     int64                      `json:"executed"`
+	Blocked      int64                      `json:"blocked"`
+	WaitTime     int64                      `json:"waitTime"`
+	Opcodes      map[string]int64           `json:"opcodes"`
+	PerNode      map[string]clientNodeStats `json:"perNode"`
+}
+
+// handleStats registers client endpoint for network stats
+func (m *MasterNode) handleStats() {
+	http.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			res, err := m.collectStats(r.Context())
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not get stats", "err", err)
+				http.Error(w, fmt.Sprintf("error getting stats: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(res)
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// collectStats gets stats from every program node and totals them.
+// Counts are since network was last reset.
+func (m *MasterNode) collectStats(ctx context.Context) (*clientStatsResponse, error) {
+	type result struct {
+		node  string
+		stats *pb.StatsMessage
+		err   error
+	}
+
+	var targets []string
+	for k, v := range m.nodeInfo {
+		if v.Type == "program" {
+			targets = append(targets, k)
+		}
+	}
+
+	c := make(chan result)
+	for _, targetURI := range targets {
+		go func(targetURI string) {
+			var stats *pb.StatsMessage
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				stats, err = pb.NewProgramClient(conn).GetStats(ctx, &empty.Empty{})
+				return err
+			})
+			c <- result{node: targetURI, stats: stats, err: err}
+		}(targetURI)
+	}
+
+	res := &clientStatsResponse{
+		Cycles:  atomic.LoadInt64(&m.cycle),
+		Opcodes: make(map[string]int64),
+		PerNode: make(map[string]clientNodeStats),
+	}
+	var statsErr error
+	for range targets {
+		r := <-c
+		if r.err != nil {
+			if statsErr == nil {
+				statsErr = fmt.Errorf("node %s: %s", r.node, r.err.Error())
+			}
+			continue
+		}
+		s := clientNodeStats{
+			Size:     int(r.stats.Size),
+			Used:     r.stats.Used,
+			Executed: r.stats.Instructions,
+			Cycles:   r.stats.Cycles,
+			Blocked:  r.stats.Blocked,
+			WaitTime: r.stats.WaitTime,
+			Opcodes:  make(map[string]int64),
+		}
+		for _, o := range r.stats.Opcodes {
+			s.Opcodes[o.Op] = o.Count
+		}
+		res.PerNode[r.node] = s
+		res.add(s)
+	}
+	if statsErr != nil {
+		return nil, statsErr
+	}
+	return res, nil
+}
+
+// add adds stats of program node to totals. Only used nodes count toward score.
+func (res *clientStatsResponse) add(s clientNodeStats) {
+	if s.Used {
+		res.Nodes++
+		res.Instructions += s.Size
+	}
+	res.Executed += s.Executed
+	res.Blocked += s.Blocked
+	res.WaitTime += s.WaitTime
+	for op, count := range s.Opcodes {
+		res.Opcodes[op] += count
+	}
+}
+
+// sub gets stats for what happened between before and res
+func (res *clientStatsResponse) sub(before *clientStatsResponse) *clientStatsResponse {
+	diff := &clientStatsResponse{
+		Cycles:  res.Cycles - before.Cycles,
+		Opcodes: make(map[string]int64),
+		PerNode: make(map[string]clientNodeStats),
+	}
+	for node, s := range res.PerNode {
+		b := before.PerNode[node]
+		d := clientNodeStats{
+			Size:     s.Size,
+			Used:     s.Used,
+			Executed: s.Executed - b.Executed,
+			Cycles:   s.Cycles - b.Cycles,
+			Blocked:  s.Blocked - b.Blocked,
+			WaitTime: s.WaitTime - b.WaitTime,
+			Opcodes:  make(map[string]int64),
+		}
+		for op, count := range s.Opcodes {
+			if count != b.Opcodes[op] {
+				d.Opcodes[op] = count - b.Opcodes[op]
+			}
+		}
+		diff.PerNode[node] = d
+		diff.add(d)
+	}
+	return diff
+}
@@ -0,0 +1,90 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+func TestProgramSize(t *testing.T) {
+	tests := []struct {
+		src  string
+		size int32
+		used bool
+	}{
+		{"", 0, false},
+		{"NOP\n\n# comment\nNOP", 2, false},
+		{"start:\nADD 1\n\nJMP start", 2, true}, // labels on their own line are not instructions
+	}
+	for _, tt := range tests {
+		prog, err := tis.Parse(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size, used := programSize(prog); size != tt.size || used != tt.used {
+			t.Errorf("programSize(%q) = %d, %v, want %d, %v", tt.src, size, used, tt.size, tt.used)
+		}
+	}
+}
+
+// testStats is stats of two used nodes and one that only runs NOP
+func testStats() *clientStatsResponse {
+	res := &clientStatsResponse{Cycles: 10, Opcodes: make(map[string]int64)}
+	res.PerNode = map[string]clientNodeStats{
+		"a":    {Size: 3, Used: true, Executed: 6, Cycles: 10, Blocked: 4, WaitTime: 100, Opcodes: map[string]int64{"ADD": 4, "MOV": 2}},
+		"b":    {Size: 2, Used: true, Executed: 3, Cycles: 10, Blocked: 7, WaitTime: 50, Opcodes: map[string]int64{"MOV": 3}},
+		"idle": {Size: 5, Used: false, Executed: 10, Cycles: 10, Opcodes: map[string]int64{"NOP": 10}},
+	}
+	for _, s := range res.PerNode {
+		res.add(s)
+	}
+	return res
+}
+
+func TestStatsResponseAdd(t *testing.T) {
+	res := testStats()
+
+	// Only used nodes count toward nodes and instructions
+	if res.Nodes != 2 || res.Instructions != 5 {
+		t.Errorf("Nodes, Instructions = %d, %d, want 2, 5", res.Nodes, res.Instructions)
+	}
+	if res.Executed != 19 || res.Blocked != 11 || res.WaitTime != 150 || res.Cycles != 10 {
+		t.Errorf("Executed, Blocked, WaitTime, Cycles = %d, %d, %d, %d, want 19, 11, 150, 10",
+			res.Executed, res.Blocked, res.WaitTime, res.Cycles)
+	}
+	if want := map[string]int64{"ADD": 4, "MOV": 5, "NOP": 10}; !reflect.DeepEqual(res.Opcodes, want) {
+		t.Errorf("Opcodes = %v, want %v", res.Opcodes, want)
+	}
+}
+
+func TestStatsResponseSub(t *testing.T) {
+	before := testStats()
+	after := testStats()
+	after.Cycles = 15
+	after.PerNode["a"] = clientNodeStats{Size: 3, Used: true, Executed: 9, Cycles: 15, Blocked: 6, WaitTime: 130,
+		Opcodes: map[string]int64{"ADD": 6, "MOV": 2, "SUB": 1}}
+	after.PerNode["idle"] = clientNodeStats{Size: 5, Used: false, Executed: 15, Cycles: 15, Opcodes: map[string]int64{"NOP": 15}}
+	// Node that was not there before counts from zero
+	after.PerNode["new"] = clientNodeStats{Size: 1, Used: true, Executed: 2, Cycles: 2, Opcodes: map[string]int64{"OUT": 2}}
+
+	diff := after.sub(before)
+	want := &clientStatsResponse{
+		Cycles:       5,
+		Nodes:        3,
+		Instructions: 6,
+		Executed:     3 + 5 + 2,
+		Blocked:      2,
+		WaitTime:     30,
+		Opcodes:      map[string]int64{"ADD": 2, "SUB": 1, "NOP": 5, "OUT": 2},
+		PerNode: map[string]clientNodeStats{
+			"a":    {Size: 3, Used: true, Executed: 3, Cycles: 5, Blocked: 2, WaitTime: 30, Opcodes: map[string]int64{"ADD": 2, "SUB": 1}},
+			"b":    {Size: 2, Used: true, Opcodes: map[string]int64{}},
+			"idle": {Size: 5, Used: false, Executed: 5, Cycles: 5, Opcodes: map[string]int64{"NOP": 5}},
+			"new":  {Size: 1, Used: true, Executed: 2, Cycles: 2, Opcodes: map[string]int64{"OUT": 2}},
+		},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("sub() =\n%+v\nwant\n%+v", diff, want)
+	}
+}
@@ -0,0 +1,590 @@
+package nodes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Register writes between program nodes running freely go over one Connect stream per peer.
+//
+// A receiver grants a credit for a register when the register is empty and no other sender
+// holds a credit for it, so every register has at most one credit out. A sender holding a
+// credit knows its write has room and sends it without waiting for a reply. Writes carry
+// sequence numbers. Receivers ack them and senders send unacked writes again on a new stream
+// if the old one breaks. Receivers drop writes they already put into a register.
+
+// errStreamReset ends incoming streams when node is reset. Senders drop unacked writes on it.
+var errStreamReset = status.Error(codes.Aborted, "node was reset")
+
+// errStreamClosed is returned by writes on a stream closed by reset
+var errStreamClosed = fmt.Errorf("register stream closed")
+
+// creditor grants credits for registers to incoming streams
+type creditor struct {
+	mux      sync.Mutex
+	streams  map[*inStream]bool
+	wants    []creditWant
+	reserved [4]*inStream
+	lastSeq  map[string]uint64
+}
+
+// creditWant is a request for credit waiting for a register to be free
+type creditWant struct {
+	in       *inStream
+	register int
+	any      bool
+}
+
+// creditGrant is a credit granted to stream that has not been sent yet
+type creditGrant struct {
+	in     *inStream
+	credit *pb.RegisterCredit
+}
+
+// inStream is a stream of writes from one sender
+type inStream struct {
+	ack     uint64
+	session string
+	credits chan *pb.RegisterCredit
+	acked   chan struct{}
+	done    <-chan struct{}
+	cancel  context.CancelFunc
+	reset   bool
+}
+
+// newCreditor creates creditor with no streams
+func newCreditor() *creditor {
+	return &creditor{
+		streams: make(map[*inStream]bool),
+		lastSeq: make(map[string]uint64),
+	}
+}
+
+// add starts granting credits to stream
+func (c *creditor) add(in *inStream) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.streams[in] = true
+}
+
+// remove drops stream, its waiting requests and credits it did not use
+func (c *creditor) remove(in *inStream, registers [4]*register) {
+	c.mux.Lock()
+	delete(c.streams, in)
+	wants := c.wants[:0]
+	for _, w := range c.wants {
+		if w.in != in {
+			wants = append(wants, w)
+		}
+	}
+	c.wants = wants
+	for i := range c.reserved {
+		if c.reserved[i] == in {
+			c.reserved[i] = nil
+		}
+	}
+	grants := c.grant(registers)
+	c.mux.Unlock()
+	sendCredits(grants)
+}
+
+// want queues request from stream for credit to register or any register
+func (c *creditor) want(in *inStream, register int, any bool, registers [4]*register) {
+	c.mux.Lock()
+	c.wants = append(c.wants, creditWant{in: in, register: register, any: any})
+	grants := c.grant(registers)
+	c.mux.Unlock()
+	sendCredits(grants)
+}
+
+// seen checks if write was already put into a register
+func (c *creditor) seen(session string, seq uint64) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return seq <= c.lastSeq[session]
+}
+
+// applied records write put into register and frees credit stream used for it
+func (c *creditor) applied(in *inStream, seq uint64, register int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.lastSeq[in.session] = seq
+	if c.reserved[register] == in {
+		c.reserved[register] = nil
+	}
+}
+
+// release grants credits to waiting streams once registers are taken from
+func (c *creditor) release(registers [4]*register) {
+	c.mux.Lock()
+	grants := c.grant(registers)
+	c.mux.Unlock()
+	sendCredits(grants)
+}
+
+// reset ends every incoming stream and forgets writes and credits
+func (c *creditor) reset() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for in := range c.streams {
+		in.reset = true
+		in.cancel()
+	}
+	c.streams = make(map[*inStream]bool)
+	c.wants = nil
+	c.reserved = [4]*inStream{}
+	c.lastSeq = make(map[string]uint64)
+}
+
+// wasReset checks if stream was ended by reset
+func (c *creditor) wasReset(in *inStream) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return in.reset
+}
+
+// grant reserves empty registers with no credit out for waiting streams in order
+// and returns the credits to send. Must hold mux.
+func (c *creditor) grant(registers [4]*register) []creditGrant {
+	var grants []creditGrant
+	for i, r := range registers {
+		if c.reserved[i] != nil {
+			continue
+		}
+		if _, full := r.Peek(); full {
+			continue
+		}
+		for j, w := range c.wants {
+			if w.any || w.register == i {
+				c.wants = append(c.wants[:j], c.wants[j+1:]...)
+				c.reserved[i] = w.in
+				grants = append(grants, creditGrant{in: w.in, credit: &pb.RegisterCredit{Register: int32(i), Any: w.any, Credit: true}})
+				break
+			}
+		}
+	}
+	return grants
+}
+
+// sendCredits sends granted credits to their streams. Must not hold mux.
+// Streams hold at most one credit per register so sends only wait if stream is ending.
+func sendCredits(grants []creditGrant) {
+	for _, g := range grants {
+		select {
+		case g.in.credits <- g.credit:
+		case <-g.in.done:
+		}
+	}
+}
+
+// acknowledge records seq as put into register and wakes stream sender
+func (in *inStream) acknowledge(seq uint64) {
+	atomic.StoreUint64(&in.ack, seq)
+	select {
+	case in.acked <- struct{}{}:
+	default:
+	}
+}
+
+// Connect handles stream of register writes from peer.
+// First message names sender session.
+func (p *ProgramNode) Connect(stream pb.Program_ConnectServer) error {
+	hello, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	in := &inStream{
+		session: hello.Session,
+		credits: make(chan *pb.RegisterCredit, 4),
+		acked:   make(chan struct{}, 1),
+		done:    ctx.Done(),
+		cancel:  cancel,
+	}
+	p.credits.add(in)
+	defer func() {
+		p.credits.remove(in, p.regs())
+	}()
+
+	// Stream sends must not run concurrently so credits and acks go out from one goroutine
+	sent := make(chan struct{})
+	defer func() {
+		cancel()
+		<-sent
+	}()
+	go func() {
+		defer close(sent)
+		for {
+			var msg *pb.RegisterCredit
+			select {
+			case msg = <-in.credits:
+			case <-in.acked:
+				msg = &pb.RegisterCredit{}
+			case <-ctx.Done():
+				return
+			}
+			msg.Ack = atomic.LoadUint64(&in.ack)
+			if err := stream.Send(msg); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	msgs := make(chan *pb.RegisterWrite)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg := <-msgs:
+			if err := p.receiveWrite(ctx, in, msg); err != nil {
+				if p.credits.wasReset(in) {
+					return errStreamReset
+				}
+				return err
+			}
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			if p.credits.wasReset(in) {
+				return errStreamReset
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// receiveWrite handles credit request or write from incoming stream
+func (p *ProgramNode) receiveWrite(ctx context.Context, in *inStream, msg *pb.RegisterWrite) error {
+	registers := p.regs()
+	if msg.Register < 0 || int(msg.Register) >= len(registers) {
+		return status.Error(codes.InvalidArgument, "not a valid register")
+	}
+	if msg.Want {
+		p.credits.want(in, int(msg.Register), msg.Any, registers)
+		return nil
+	}
+
+	if p.credits.seen(in.session, msg.Seq) {
+		in.acknowledge(msg.Seq)
+		return nil
+	}
+	// Writes sent again after stream broke have no credit and may wait for room
+	if err := registers[msg.Register].Put(ctx, msg.Value); err != nil {
+		return err
+	}
+	p.credits.applied(in, msg.Seq, int(msg.Register))
+	in.acknowledge(msg.Seq)
+	p.logger.Debug("received value", "register", tis.R0+tis.Register(msg.Register), "value", msg.Value, "seq", msg.Seq)
+	return nil
+}
+
+// peerStream sends register writes from this node to one peer
+type peerStream struct {
+	p       *ProgramNode
+	target  string
+	session string
+
+	// ctx is done once stream is closed
+	ctx  context.Context
+	stop context.CancelFunc
+
+	mux    sync.Mutex
+	stream pb.Program_ConnectClient
+	cancel context.CancelFunc
+	// connecting is closed once the stream being opened is open or failed
+	connecting chan struct{}
+	credits    [4]int
+	anyCredits []int
+	// wanted marks credit requests sent for R0-R3 and ANY
+	wanted  [5]bool
+	seq     uint64
+	unacked []*pb.RegisterWrite
+	closed  bool
+
+	// changed is closed and replaced whenever credits arrive or stream breaks
+	changed chan struct{}
+}
+
+// newPeerStream creates stream to target that connects on first write
+func newPeerStream(p *ProgramNode, target string) *peerStream {
+	ctx, stop := context.WithCancel(context.Background())
+	return &peerStream{
+		p:       p,
+		target:  target,
+		session: newSession(),
+		ctx:     ctx,
+		stop:    stop,
+		changed: make(chan struct{}),
+	}
+}
+
+// write waits for credit for register on peer and sends value to it.
+// Returns register value goes into.
+func (s *peerStream) write(ctx context.Context, register tis.Register, v int64) (tis.Register, error) {
+	for {
+		s.mux.Lock()
+		if s.closed {
+			s.mux.Unlock()
+			return tis.NIL, errStreamClosed
+		}
+		if err := s.connect(ctx); err != nil {
+			s.mux.Unlock()
+			return tis.NIL, err
+		}
+		if i, ok := s.takeCredit(register); ok {
+			s.seq++
+			msg := &pb.RegisterWrite{Seq: s.seq, Register: int32(i), Value: v}
+			s.unacked = append(s.unacked, msg)
+			// Broken stream is noticed by receive, which sends write again
+			s.stream.Send(msg)
+			s.mux.Unlock()
+			return tis.R0 + tis.Register(i), nil
+		}
+		s.want(register)
+		changed := s.changed
+		s.mux.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return tis.NIL, ctx.Err()
+		}
+	}
+}
+
+// takeCredit uses credit for register if stream has one. Must hold mux.
+func (s *peerStream) takeCredit(register tis.Register) (int, bool) {
+	if register == tis.ANY {
+		if len(s.anyCredits) == 0 {
+			return -1, false
+		}
+		i := s.anyCredits[0]
+		s.anyCredits = s.anyCredits[1:]
+		return i, true
+	}
+	i := register.Index()
+	if s.credits[i] == 0 {
+		return -1, false
+	}
+	s.credits[i]--
+	return i, true
+}
+
+// want asks peer for credit for register unless already asked. Must hold mux.
+func (s *peerStream) want(register tis.Register) {
+	msg := &pb.RegisterWrite{Want: true}
+	idx := len(s.wanted) - 1
+	if register == tis.ANY {
+		msg.Any = true
+	} else {
+		idx = register.Index()
+		msg.Register = int32(idx)
+	}
+	if s.wanted[idx] {
+		return
+	}
+	s.wanted[idx] = true
+	s.stream.Send(msg)
+}
+
+// connect opens stream to peer if there is none and sends unacked writes again.
+// Must hold mux, which is released while dialing. Only one dial runs at a time
+// and others wait for it.
+func (s *peerStream) connect(ctx context.Context) error {
+	for s.stream == nil {
+		if s.closed {
+			return errStreamClosed
+		}
+		if connecting := s.connecting; connecting != nil {
+			s.mux.Unlock()
+			select {
+			case <-connecting:
+			case <-ctx.Done():
+			}
+			s.mux.Lock()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		connecting := make(chan struct{})
+		s.connecting = connecting
+		s.mux.Unlock()
+		stream, cancel, err := s.dial(ctx)
+		s.mux.Lock()
+		s.connecting = nil
+		close(connecting)
+		if err != nil {
+			return err
+		}
+		if s.closed {
+			cancel()
+			return errStreamClosed
+		}
+
+		for _, msg := range s.unacked {
+			stream.Send(msg)
+		}
+		s.stream = stream
+		s.cancel = cancel
+		s.wanted = [5]bool{}
+		go s.receive(stream)
+	}
+	return nil
+}
+
+// dial opens stream to peer, giving up once ctx is done or stream is closed
+func (s *peerStream) dial(ctx context.Context) (pb.Program_ConnectClient, context.CancelFunc, error) {
+	ctx, cancel := joinContext(ctx, s.ctx)
+	defer cancel()
+	var stream pb.Program_ConnectClient
+	var streamCancel context.CancelFunc
+	err := s.p.retry.do(ctx, s.target, func() error {
+		conn, err := s.p.retry.dial(ctx, s.p.conns, s.target)
+		if err != nil {
+			return err
+		}
+		// Stream outlives pauses so it does not use node context
+		streamCtx, cancel := context.WithCancel(s.ctx)
+		stream, err = pb.NewProgramClient(conn).Connect(streamCtx)
+		if err == nil {
+			err = stream.Send(&pb.RegisterWrite{Session: s.session})
+		}
+		if err != nil {
+			cancel()
+			return err
+		}
+		streamCancel = cancel
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return stream, streamCancel, nil
+}
+
+// receive handles credits and acks from peer until stream breaks.
+// Unacked writes are sent again on a new stream unless peer was reset.
+func (s *peerStream) receive(stream pb.Program_ConnectClient) {
+	for {
+		msg, err := stream.Recv()
+
+		s.mux.Lock()
+		if s.stream != stream {
+			s.mux.Unlock()
+			return
+		}
+		if err != nil {
+			s.cancel()
+			s.stream = nil
+			s.credits = [4]int{}
+			s.anyCredits = nil
+			if status.Code(err) == codes.Aborted {
+				s.unacked = nil
+			}
+			if len(s.unacked) > 0 {
+				// Gives up once stream is closed
+				if err := s.connect(s.ctx); err != nil && err != errStreamClosed && s.ctx.Err() == nil {
+					s.p.logger.Warn("could not reconnect register stream", "target", s.target, "err", err)
+				}
+			}
+			s.notify()
+			s.mux.Unlock()
+			return
+		}
+
+		i := 0
+		for ; i < len(s.unacked) && s.unacked[i].Seq <= msg.Ack; i++ {
+		}
+		s.unacked = s.unacked[i:]
+		if msg.Credit {
+			if msg.Any {
+				s.anyCredits = append(s.anyCredits, int(msg.Register))
+				s.wanted[len(s.wanted)-1] = false
+			} else {
+				s.credits[msg.Register]++
+				s.wanted[msg.Register] = false
+			}
+			s.notify()
+		}
+		s.mux.Unlock()
+	}
+}
+
+// notify wakes writes waiting on stream. Must hold mux.
+func (s *peerStream) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// close ends stream and fails waiting writes
+func (s *peerStream) close() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.closed = true
+	s.stop()
+	if s.stream != nil {
+		s.cancel()
+		s.stream = nil
+	}
+	s.notify()
+}
+
+// newSession makes random name for requests from one sender
+func newSession() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// peerStream gets stream to target, creating it if needed
+func (p *ProgramNode) peerStream(target string) *peerStream {
+	p.peersMux.Lock()
+	defer p.peersMux.Unlock()
+	s, ok := p.peers[target]
+	if !ok {
+		s = newPeerStream(p, target)
+		p.peers[target] = s
+	}
+	return s
+}
+
+// closePeers closes streams to every peer. Writes not yet put into a register are dropped.
+func (p *ProgramNode) closePeers() {
+	p.peersMux.Lock()
+	defer p.peersMux.Unlock()
+	for _, s := range p.peers {
+		s.close()
+	}
+	p.peers = make(map[string]*peerStream)
+}
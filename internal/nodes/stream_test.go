@@ -0,0 +1,108 @@
+package nodes
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+func TestCloseStopsReconnectingStream(t *testing.T) {
+	ctx := testContext(t)
+	n := newTestNetwork(t)
+	// Keeps trying to reach peer long after test would give up
+	n.program.SetRetryPolicy(RetryPolicy{
+		Attempts:  1000,
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  50 * time.Millisecond,
+		Timeout:   500 * time.Millisecond,
+	})
+
+	s := n.program.peerStream(testPeer)
+	if _, err := s.write(ctx, tis.R0, 1); err != nil {
+		t.Fatal(err)
+	}
+	// Write peer never acked, so breaking the stream reconnects to send it again
+	s.mux.Lock()
+	s.seq++
+	s.unacked = append(s.unacked, &pb.RegisterWrite{Seq: s.seq, Register: 1, Value: 2})
+	s.mux.Unlock()
+	n.peerServer.Stop()
+
+	for connecting := false; !connecting; {
+		time.Sleep(time.Millisecond)
+		s.mux.Lock()
+		connecting = s.connecting != nil
+		s.mux.Unlock()
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		n.program.closePeers()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("closePeers() waited for stream to reconnect")
+	}
+
+	// Reconnect gives up once stream is closed
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mux.Lock()
+		connecting := s.connecting != nil
+		s.mux.Unlock()
+		if !connecting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stream kept reconnecting after it was closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := s.write(ctx, tis.R0, 3); err != errStreamClosed {
+		t.Errorf("write() error = %v on closed stream, want %v", err, errStreamClosed)
+	}
+}
+
+func TestCreditorSendsCreditsOutsideLock(t *testing.T) {
+	c := newCreditor()
+	done := make(chan struct{})
+	// Stream that is not reading credits
+	in := &inStream{session: "a", credits: make(chan *pb.RegisterCredit), done: done}
+	c.add(in)
+
+	wanted := make(chan struct{})
+	go func() {
+		c.want(in, 1, false, newRegisters())
+		close(wanted)
+	}()
+
+	// Creditor stays usable while credit waits to be sent
+	for reserved := false; !reserved; {
+		time.Sleep(time.Millisecond)
+		c.mux.Lock()
+		reserved = c.reserved[1] == in
+		c.mux.Unlock()
+	}
+	seen := make(chan struct{})
+	go func() {
+		c.seen("a", 1)
+		close(seen)
+	}()
+	select {
+	case <-seen:
+	case <-time.After(5 * time.Second):
+		t.Fatal("seen() waited for credit to be sent")
+	}
+
+	// Sending gives up once stream ends
+	close(done)
+	select {
+	case <-wanted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want() kept sending credit to ended stream")
+	}
+}
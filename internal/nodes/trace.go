@@ -0,0 +1,349 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/jasmaa/misaka-net/internal/grpc"
+	"github.com/jasmaa/misaka-net/internal/logging"
+	"github.com/jasmaa/misaka-net/internal/tis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// traceEvent is one instruction run by a program node
+type traceEvent struct {
+	step      int64
+	cycle     int64
+	start     time.Time
+	duration  time.Duration
+	ptr       int
+	line      int
+	op        string
+	operands  []string
+	acc, bak  int64
+	blockedOn string
+	done      bool
+}
+
+// tracer records instructions run by a program node in a ring buffer
+type tracer struct {
+	mux     sync.Mutex
+	events  []*traceEvent
+	next    int
+	steps   int64
+	dropped int64
+}
+
+// newTracer creates a tracer holding the last size events
+func newTracer(size int) *tracer {
+	return &tracer{events: make([]*traceEvent, 0, size)}
+}
+
+// begin records start of instruction machine is about to run
+func (t *tracer) begin(m *tis.Machine, cycle int64) *traceEvent {
+	instr := m.Instruction()
+	e := &traceEvent{
+		cycle: cycle,
+		start: time.Now(),
+		ptr:   m.Ptr,
+		line:  instr.Pos.Line,
+		op:    instr.Op.String(),
+		acc:   m.Acc,
+		bak:   m.Bak,
+	}
+	for _, arg := range instr.Args {
+		e.operands = append(e.operands, arg.String())
+	}
+	if port, ok := m.NextPort(); ok {
+		e.blockedOn = port.String()
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.steps++
+	e.step = t.steps
+	if len(t.events) < cap(t.events) {
+		t.events = append(t.events, e)
+	} else {
+		t.events[t.next] = e
+		t.dropped++
+	}
+	t.next = (t.next + 1) % cap(t.events)
+	return e
+}
+
+// end records result of instruction started by begin
+func (t *tracer) end(e *traceEvent, m *tis.Machine, err error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	e.duration = time.Since(e.start)
+	e.acc = m.Acc
+	e.bak = m.Bak
+	e.done = true
+	e.blockedOn = ""
+	if err == tis.ErrBlocked {
+		// Report port that is still missing, which may have moved on from src to dst
+		if port, ok := m.NextPort(); ok {
+			e.blockedOn = port.String()
+		}
+	}
+}
+
+// snapshot gets copy of events oldest first
+func (t *tracer) snapshot() *pb.TraceMessage {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	res := &pb.TraceMessage{Dropped: t.dropped}
+	start := 0
+	if len(t.events) == cap(t.events) {
+		start = t.next
+	}
+	for i := range t.events {
+		e := t.events[(start+i)%len(t.events)]
+		duration := e.duration
+		if !e.done {
+			duration = time.Since(e.start)
+		}
+		res.Events = append(res.Events, &pb.TraceEvent{
+			Step:      e.step,
+			Cycle:     e.cycle,
+			Time:      e.start.UnixNano(),
+			Duration:  int64(duration),
+			Ptr:       int32(e.ptr),
+			Line:      int32(e.line),
+			Op:        e.op,
+			Operands:  append([]string{}, e.operands...),
+			Acc:       e.acc,
+			Bak:       e.bak,
+			BlockedOn: e.blockedOn,
+			Done:      e.done,
+		})
+	}
+	return res
+}
+
+// EnableTrace records the last size instructions run by node
+func (p *ProgramNode) EnableTrace(size int) {
+	if size > 0 {
+		p.tracer = newTracer(size)
+	}
+}
+
+// GetTrace handles request for instructions recorded by tracer
+func (d *programDebugger) GetTrace(ctx context.Context, in *empty.Empty) (*pb.TraceMessage, error) {
+	if d.p.tracer == nil {
+		return nil, status.Error(codes.FailedPrecondition, "tracing not enabled")
+	}
+	return d.p.tracer.snapshot(), nil
+}
+
+// clientTraceEvent structures trace event in merged trace sent to client
+type clientTraceEvent struct {
+	Node      string   `json:"node"`
+	Step      int64    `json:"step"`
+	Cycle     int64    `json:"cycle"`
+	Time      int64    `json:"time"`
+	Duration  int64    `json:"duration"`
+	Ptr       int32    `json:"ptr"`
+	Line      int32    `json:"line"`
+	Op        string   `json:"op"`
+	Operands  []string `json:"operands"`
+	Acc       int64    `json:"acc"`
+	Bak       int64    `json:"bak"`
+	BlockedOn string   `json:"blockedOn,omitempty"`
+	Done      bool     `json:"done"`
+}
+
+// chromeTraceEvent is an event in Chrome trace event format
+type chromeTraceEvent struct {
+	Name  string      `json:"name"`
+	Cat   string      `json:"cat,omitempty"`
+	Phase string      `json:"ph"`
+	Time  float64     `json:"ts"`
+	Dur   float64     `json:"dur,omitempty"`
+	Pid   int         `json:"pid"`
+	Tid   int         `json:"tid"`
+	Args  interface{} `json:"args,omitempty"`
+}
+
+// handleTrace registers client endpoint for merged trace of all program nodes
+func (m *MasterNode) handleTrace() {
+	http.HandleFunc("/trace", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			format := r.FormValue("format")
+			if format == "" {
+				format = "jsonl"
+			}
+			if format != "jsonl" && format != "chrome" {
+				http.Error(w, fmt.Sprintf("'%s' not a valid trace format", format), http.StatusBadRequest)
+				return
+			}
+
+			events, err := m.collectTrace(r.Context())
+			if err != nil {
+				logging.FromContext(r.Context(), m.logger).Error("could not get trace", "err", err)
+				http.Error(w, fmt.Sprintf("error getting trace: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			switch format {
+			case "jsonl":
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				writeTraceJSONL(w, events)
+			case "chrome":
+				w.Header().Set("Content-Type", "application/json")
+				writeTraceChrome(w, events)
+			}
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// collectTrace gets trace from every program node with tracing enabled and merges them.
+// Lockstep events are ordered by cycle and the rest by time on each node's clock.
+func (m *MasterNode) collectTrace(ctx context.Context) ([]clientTraceEvent, error) {
+	type result struct {
+		node  string
+		trace *pb.TraceMessage
+		err   error
+	}
+
+	var targets []string
+	for k, v := range m.nodeInfo {
+		if v.Type == "program" {
+			targets = append(targets, k)
+		}
+	}
+	sort.Strings(targets)
+
+	c := make(chan result)
+	for _, targetURI := range targets {
+		go func(targetURI string) {
+			var trace *pb.TraceMessage
+			err := m.call(ctx, targetURI, func(ctx context.Context, conn *grpc.ClientConn) error {
+				var err error
+				trace, err = pb.NewDebugClient(conn).GetTrace(ctx, &empty.Empty{})
+				return err
+			})
+			c <- result{node: targetURI, trace: trace, err: err}
+		}(targetURI)
+	}
+
+	var events []clientTraceEvent
+	var traceErr error
+	for range targets {
+		res := <-c
+		if status.Code(res.err) == codes.FailedPrecondition {
+			// Tracing not enabled on node
+			continue
+		}
+		if res.err != nil {
+			if traceErr == nil {
+				traceErr = fmt.Errorf("node %s: %s", res.node, status.Convert(res.err).Message())
+			}
+			continue
+		}
+		if res.trace.Dropped > 0 {
+			logging.FromContext(ctx, m.logger).Warn("trace events dropped", "target", res.node, "dropped", res.trace.Dropped)
+		}
+		for _, e := range res.trace.Events {
+			events = append(events, clientTraceEvent{
+				Node:      res.node,
+				Step:      e.Step,
+				Cycle:     e.Cycle,
+				Time:      e.Time,
+				Duration:  e.Duration,
+				Ptr:       e.Ptr,
+				Line:      e.Line,
+				Op:        e.Op,
+				Operands:  append([]string{}, e.Operands...),
+				Acc:       e.Acc,
+				Bak:       e.Bak,
+				BlockedOn: e.BlockedOn,
+				Done:      e.Done,
+			})
+		}
+	}
+	if traceErr != nil {
+		return nil, traceErr
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Cycle > 0 && b.Cycle > 0 && a.Cycle != b.Cycle {
+			return a.Cycle < b.Cycle
+		}
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return a.Node < b.Node
+	})
+	return events, nil
+}
+
+// writeTraceJSONL writes one event per line
+func writeTraceJSONL(w io.Writer, events []clientTraceEvent) {
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		enc.Encode(e)
+	}
+}
+
+// writeTraceChrome writes events in Chrome trace event format with one thread per node
+func writeTraceChrome(w io.Writer, events []clientTraceEvent) {
+	tids := make(map[string]int)
+	var start int64
+	for _, e := range events {
+		if start == 0 || e.Time < start {
+			start = e.Time
+		}
+	}
+
+	res := []chromeTraceEvent{}
+	for _, e := range events {
+		tid, ok := tids[e.Node]
+		if !ok {
+			tid = len(tids) + 1
+			tids[e.Node] = tid
+			res = append(res, chromeTraceEvent{
+				Name:  "thread_name",
+				Phase: "M",
+				Pid:   1,
+				Tid:   tid,
+				Args:  map[string]string{"name": e.Node},
+			})
+		}
+
+		name := e.Op
+		if len(e.Operands) > 0 {
+			name = fmt.Sprintf("%s %s", e.Op, strings.Join(e.Operands, ", "))
+		}
+		res = append(res, chromeTraceEvent{
+			Name:  name,
+			Cat:   "instruction",
+			Phase: "X",
+			Time:  float64(e.Time-start) / 1e3,
+			Dur:   float64(e.Duration) / 1e3,
+			Pid:   1,
+			Tid:   tid,
+			Args:  e,
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"traceEvents":     res,
+		"displayTimeUnit": "ns",
+	})
+}
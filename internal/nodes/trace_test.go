@@ -0,0 +1,163 @@
+package nodes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetTraceKeepsLastEvents(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProgramNode(t)
+	d := &programDebugger{p: p}
+	if _, err := d.GetTrace(ctx, &empty.Empty{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("GetTrace() error = %v before tracing, want FailedPrecondition", err)
+	}
+
+	p.EnableTrace(3)
+	if err := p.LoadProgram("ADD 1\nADD 2\nMOV R0, ACC\nSUB 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run in lockstep so reading the empty register blocks instead of waiting
+	p.machineMux.Lock()
+	for cycle := int64(1); cycle <= 5; cycle++ {
+		p.update(ctx, cycle)
+	}
+	p.machineMux.Unlock()
+
+	type event struct {
+		step, cycle int64
+		line        int32
+		op          string
+		acc         int64
+		blockedOn   string
+	}
+	check := func(wantDropped int64, want []event) {
+		t.Helper()
+		trace, err := d.GetTrace(ctx, &empty.Empty{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trace.Dropped != wantDropped {
+			t.Errorf("Dropped = %d, want %d", trace.Dropped, wantDropped)
+		}
+		var got []event
+		for _, e := range trace.Events {
+			if !e.Done {
+				t.Errorf("step %d not done", e.Step)
+			}
+			got = append(got, event{e.Step, e.Cycle, e.Line, e.Op, e.Acc, e.BlockedOn})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("events = %+v, want %+v", got, want)
+		}
+	}
+
+	// Oldest events are overwritten and the rest come oldest first
+	check(2, []event{
+		{3, 3, 3, "MOV", 3, "R0"},
+		{4, 4, 3, "MOV", 3, "R0"},
+		{5, 5, 3, "MOV", 3, "R0"},
+	})
+
+	p.regs()[0].TryPut(7)
+	p.machineMux.Lock()
+	p.update(ctx, 6)
+	p.update(ctx, 7)
+	p.machineMux.Unlock()
+	check(4, []event{
+		{5, 5, 3, "MOV", 3, "R0"},
+		{6, 6, 3, "MOV", 7, ""},
+		{7, 7, 4, "SUB", 6, ""},
+	})
+}
+
+// testTrace has events from two nodes
+var testTrace = []clientTraceEvent{
+	{Node: "misaka1", Step: 1, Time: 1000, Duration: 2000, Line: 1, Op: "ADD", Operands: []string{"1"}, Acc: 1, Done: true},
+	{Node: "misaka2", Step: 1, Time: 1500, Duration: 500, Line: 1, Op: "MOV", Operands: []string{"R0", "ACC"}, BlockedOn: "R0"},
+	{Node: "misaka1", Step: 2, Time: 4000, Duration: 1000, Line: 2, Op: "SWP", Operands: []string{}, Done: true},
+}
+
+func TestWriteTraceJSONL(t *testing.T) {
+	var b bytes.Buffer
+	writeTraceJSONL(&b, testTrace)
+
+	var got []clientTraceEvent
+	scanner := bufio.NewScanner(&b)
+	for scanner.Scan() {
+		var e clientTraceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if !reflect.DeepEqual(got, testTrace) {
+		t.Errorf("events = %+v, want %+v", got, testTrace)
+	}
+}
+
+func TestWriteTraceChrome(t *testing.T) {
+	var b bytes.Buffer
+	writeTraceChrome(&b, testTrace)
+
+	var got struct {
+		TraceEvents []struct {
+			Name  string                 `json:"name"`
+			Cat   string                 `json:"cat"`
+			Phase string                 `json:"ph"`
+			Time  float64                `json:"ts"`
+			Dur   float64                `json:"dur"`
+			Pid   int                    `json:"pid"`
+			Tid   int                    `json:"tid"`
+			Args  map[string]interface{} `json:"args"`
+		} `json:"traceEvents"`
+		DisplayTimeUnit string `json:"displayTimeUnit"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.DisplayTimeUnit != "ns" {
+		t.Errorf("displayTimeUnit = %q", got.DisplayTimeUnit)
+	}
+
+	// Each node is named once before its first event. Times are microseconds from first event.
+	type event struct {
+		name      string
+		phase     string
+		time, dur float64
+		tid       int
+		arg       interface{}
+	}
+	want := []event{
+		{"thread_name", "M", 0, 0, 1, "misaka1"},
+		{"ADD 1", "X", 0, 2, 1, "misaka1"},
+		{"thread_name", "M", 0, 0, 2, "misaka2"},
+		{"MOV R0, ACC", "X", 0.5, 0.5, 2, "misaka2"},
+		{"SWP", "X", 3, 1, 1, "misaka1"},
+	}
+	var events []event
+	for _, e := range got.TraceEvents {
+		if e.Pid != 1 {
+			t.Errorf("%s pid = %d, want 1", e.Name, e.Pid)
+		}
+		arg := e.Args["node"]
+		if e.Phase == "M" {
+			arg = e.Args["name"]
+		} else if e.Cat != "instruction" {
+			t.Errorf("%s cat = %q, want instruction", e.Name, e.Cat)
+		}
+		events = append(events, event{e.Name, e.Phase, e.Time, e.Dur, e.Tid, arg})
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+}
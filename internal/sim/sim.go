@@ -0,0 +1,358 @@
+// Package sim runs a whole network in one process without gRPC.
+//
+// Nodes step in lockstep cycles. Every program node runs at most one
+// instruction per cycle and values written during a cycle only become
+// visible to other nodes in the next one, so runs are deterministic.
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+// ErrStalled is returned when no node can make progress
+var ErrStalled = errors.New("network stalled")
+
+// ErrCycleLimit is returned when a network is still running after the cycles it was given
+var ErrCycleLimit = errors.New("cycle limit reached")
+
+// Network is a simulated network of program and stack nodes with a master
+type Network struct {
+	model    tis.NumericModel
+	topo     tis.Topology
+	order    []string
+	programs map[string]*program
+	stacks   map[string]*stack
+
+	input  []int64
+	output []int64
+	cycle  int
+}
+
+// program is a simulated program node
+type program struct {
+	name      string
+	machine   *tis.Machine
+	registers [4]register
+	blocked   bool
+	err       error
+	// idle programs do nothing but NOP and never count as progress
+	idle bool
+}
+
+// register is a network register holding at most one value
+type register struct {
+	full    bool
+	value   int64
+	taken   bool
+	pending bool
+	next    int64
+}
+
+// stack is a simulated stack node
+type stack struct {
+	values []int64
+	pushed []int64
+}
+
+// New creates a network with nodes in topology
+func New(topo tis.Topology, model tis.NumericModel) *Network {
+	n := &Network{
+		model:    model,
+		topo:     topo,
+		programs: make(map[string]*program),
+		stacks:   make(map[string]*stack),
+	}
+	for name, nodeType := range topo {
+		switch nodeType {
+		case tis.NodeTypeProgram:
+			p := &program{name: name, idle: true}
+			p.machine = tis.NewMachine(tis.NewEmptyProgram(), model, ports{n, p})
+			n.programs[name] = p
+			n.order = append(n.order, name)
+		case tis.NodeTypeStack:
+			n.stacks[name] = &stack{}
+		}
+	}
+	sort.Strings(n.order)
+	return n
+}
+
+// Load checks program against network and loads it onto program node
+func (n *Network) Load(name string, prog *tis.Program) error {
+	p, ok := n.programs[name]
+	if !ok {
+		return fmt.Errorf("program node %s not valid on this network", name)
+	}
+	if diags := tis.Check(prog, n.topo); diags.HasErrors() {
+		return diags
+	}
+	p.machine.Load(prog)
+	p.idle = true
+	for _, instr := range prog.Instructions {
+		p.idle = p.idle && instr.Op == tis.NOP
+	}
+	p.blocked = false
+	p.err = nil
+	return nil
+}
+
+// LoadBundle checks bundle against network and loads every section
+func (n *Network) LoadBundle(b *tis.Bundle) error {
+	if diags := b.Check(n.topo); diags.HasErrors() {
+		return diags
+	}
+	for _, s := range b.Sections {
+		if err := n.Load(s.Node, s.Program); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clears all registers, stacks, input and output and restarts programs
+func (n *Network) Reset() {
+	for _, p := range n.programs {
+		p.machine.Reset()
+		p.registers = [4]register{}
+		p.blocked = false
+		p.err = nil
+	}
+	for _, s := range n.stacks {
+		s.values = nil
+		s.pushed = nil
+	}
+	n.input = nil
+	n.output = nil
+	n.cycle = 0
+}
+
+// Input queues values for master input
+func (n *Network) Input(vs ...int64) {
+	n.input = append(n.input, vs...)
+}
+
+// Output gets values written to master output so far
+func (n *Network) Output() []int64 {
+	return n.output
+}
+
+// Cycle gets number of cycles run
+func (n *Network) Cycle() int {
+	return n.cycle
+}
+
+// Machine gets machine running on program node
+func (n *Network) Machine(name string) (*tis.Machine, bool) {
+	p, ok := n.programs[name]
+	if !ok {
+		return nil, false
+	}
+	return p.machine, true
+}
+
+// Blocked checks if program node could not finish its instruction in the last cycle
+func (n *Network) Blocked(name string) bool {
+	p, ok := n.programs[name]
+	return ok && p.blocked
+}
+
+// Step runs one cycle. Returns whether any node made progress.
+// Nodes that do nothing but NOP never make progress.
+// A node that faults stops and its error is returned.
+func (n *Network) Step() (bool, error) {
+	progress := false
+	var stepErr error
+	for _, name := range n.order {
+		p := n.programs[name]
+		if p.err != nil {
+			continue
+		}
+		err := p.machine.Step()
+		p.blocked = err == tis.ErrBlocked
+		switch {
+		case err == nil:
+			progress = progress || !p.idle
+		case p.blocked:
+			// retry next cycle
+		default:
+			p.err = fmt.Errorf("node %s: %w", name, err)
+			if stepErr == nil {
+				stepErr = p.err
+			}
+		}
+	}
+
+	// Commit writes made during cycle
+	for _, p := range n.programs {
+		for i := range p.registers {
+			r := &p.registers[i]
+			if r.taken {
+				r.full = false
+				r.taken = false
+				progress = true
+			}
+			if r.pending {
+				r.full = true
+				r.value = r.next
+				r.pending = false
+				progress = true
+			}
+		}
+	}
+	for _, s := range n.stacks {
+		if len(s.pushed) > 0 {
+			s.values = append(s.values, s.pushed...)
+			s.pushed = nil
+			progress = true
+		}
+	}
+
+	n.cycle++
+	return progress, stepErr
+}
+
+// Run steps network until it stalls, a node faults or maxCycles is reached.
+// A network that stalls with every blocked node waiting on input, itself or through
+// the nodes it waits for, has finished and returns nil.
+// Any other stall returns ErrStalled naming the deadlocked nodes.
+// Running for maxCycles without stalling returns ErrCycleLimit.
+func (n *Network) Run(maxCycles int) error {
+	for i := 0; i < maxCycles; i++ {
+		progress, err := n.Step()
+		if err != nil {
+			return err
+		}
+		if !progress {
+			if deadlocked := n.Deadlocked(); len(deadlocked) > 0 {
+				return fmt.Errorf("%w: %s deadlocked", ErrStalled, strings.Join(deadlocked, ", "))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w after %d cycles", ErrCycleLimit, maxCycles)
+}
+
+// Deadlocked lists blocked program nodes that can never be unblocked,
+// following the wait-for rules of tis.FindDeadlock.
+func (n *Network) Deadlocked() []string {
+	programs := make(map[string]*tis.Program)
+	blocked := make(map[string]tis.Port)
+	for _, name := range n.order {
+		p := n.programs[name]
+		programs[name] = p.machine.Program()
+		if !p.blocked || p.err != nil {
+			continue
+		}
+		if port, ok := p.machine.NextPort(); ok {
+			blocked[name] = port
+		}
+	}
+	names, _ := tis.FindDeadlock(programs, blocked)
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// ports connects a simulated program node to the rest of the network
+type ports struct {
+	n *Network
+	p *program
+}
+
+// Read takes value from network register if it held one at start of cycle
+func (ps ports) Read(r tis.Register) (int64, error) {
+	reg := &ps.p.registers[r.Index()]
+	if !reg.full || reg.taken {
+		return 0, tis.ErrBlocked
+	}
+	reg.taken = true
+	return reg.value, nil
+}
+
+// ReadAny takes value from first network register in order holding one
+func (ps ports) ReadAny(order []tis.Register) (int64, tis.Register, error) {
+	for _, r := range order {
+		if v, err := ps.Read(r); err == nil {
+			return v, r, nil
+		}
+	}
+	return 0, tis.NIL, tis.ErrBlocked
+}
+
+// Write stages value for register on peer if register is free
+func (ps ports) Write(node string, r tis.Register, v int64) (tis.Register, error) {
+	target, ok := ps.n.programs[node]
+	if !ok {
+		return tis.NIL, fmt.Errorf("program node %s not valid on this network", node)
+	}
+	free := func(r tis.Register) bool {
+		reg := target.registers[r.Index()]
+		return !reg.full && !reg.pending
+	}
+
+	if r == tis.ANY {
+		r = tis.NIL
+		for _, candidate := range []tis.Register{tis.R0, tis.R1, tis.R2, tis.R3} {
+			if free(candidate) {
+				r = candidate
+				break
+			}
+		}
+		if r == tis.NIL {
+			return tis.NIL, tis.ErrBlocked
+		}
+	} else if !free(r) {
+		return tis.NIL, tis.ErrBlocked
+	}
+
+	reg := &target.registers[r.Index()]
+	reg.pending = true
+	reg.next = v
+	return r, nil
+}
+
+// Push stages value for stack node
+func (ps ports) Push(node string, v int64) error {
+	s, ok := ps.n.stacks[node]
+	if !ok {
+		return fmt.Errorf("stack node %s not valid on this network", node)
+	}
+	s.pushed = append(s.pushed, ps.n.model.Normalize(v))
+	return nil
+}
+
+// Pop pops value that was on stack node at start of cycle
+func (ps ports) Pop(node string) (int64, error) {
+	s, ok := ps.n.stacks[node]
+	if !ok {
+		return 0, fmt.Errorf("stack node %s not valid on this network", node)
+	}
+	if len(s.values) == 0 {
+		return 0, tis.ErrBlocked
+	}
+	v := s.values[len(s.values)-1]
+	s.values = s.values[:len(s.values)-1]
+	return v, nil
+}
+
+// In takes next value from master input
+func (ps ports) In() (int64, error) {
+	if len(ps.n.input) == 0 {
+		return 0, tis.ErrBlocked
+	}
+	v := ps.n.input[0]
+	ps.n.input = ps.n.input[1:]
+	return v, nil
+}
+
+// Out appends value to master output
+func (ps ports) Out(v int64) error {
+	ps.n.output = append(ps.n.output, v)
+	return nil
+}
@@ -0,0 +1,191 @@
+package sim
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasmaa/misaka-net/internal/tis"
+)
+
+var topo = tis.Topology{
+	"misaka1": tis.NodeTypeProgram,
+	"misaka2": tis.NodeTypeProgram,
+	"misaka3": tis.NodeTypeProgram,
+	"stack":   tis.NodeTypeStack,
+}
+
+// load creates network running bundle
+func load(t *testing.T, src string) *Network {
+	t.Helper()
+	b, err := tis.ParseBundle(src, tis.PreprocessOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := New(topo, tis.NumericTIS)
+	if err := n.LoadBundle(b); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		bundle string
+		input  []int64
+		want   []int64
+	}{
+		{
+			name: "pipeline",
+			bundle: `
+@node misaka1
+IN ACC
+MOV ACC, misaka2:R0
+@node misaka2
+MOV R0, ACC
+ADD 1
+MOV ACC, misaka3:ANY
+@node misaka3
+MOV ANY, ACC
+OUT ACC`,
+			input: []int64{1, 2, 3},
+			want:  []int64{2, 3, 4},
+		},
+		{
+			name: "stack",
+			bundle: `
+@stack stack
+@node misaka1
+IN ACC
+JEZ flush
+PUSH ACC, stack
+JMP end
+flush: MOV 1, misaka2:R0
+end: NOP
+@node misaka2
+MOV R0, NIL
+loop: POP stack, ACC
+OUT ACC
+JMP loop`,
+			input: []int64{1, 2, 3, 0},
+			want:  []int64{3, 2, 1},
+		},
+		{
+			name: "loop between nodes",
+			bundle: `
+@node misaka1
+IN ACC
+MOV ACC, misaka2:R0
+MOV R1, ACC
+OUT ACC
+@node misaka2
+MOV R0, ACC
+SAV
+ADD ACC
+MOV ACC, misaka1:R1`,
+			input: []int64{5, 7},
+			want:  []int64{10, 14},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := load(t, tt.bundle)
+			n.Input(tt.input...)
+			if err := n.Run(1000); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := n.Output(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Output() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsDeadlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		bundle     string
+		deadlocked []string
+	}{
+		{
+			name: "nodes read each other",
+			bundle: `
+@node misaka1
+MOV R0, ACC
+MOV ACC, misaka2:R0
+@node misaka2
+MOV R0, ACC
+MOV ACC, misaka1:R0`,
+			deadlocked: []string{"misaka1", "misaka2"},
+		},
+		{
+			name: "one node waits on input",
+			bundle: `
+@node misaka1
+IN ACC
+OUT ACC
+@node misaka2
+MOV R1, ACC`,
+			deadlocked: []string{"misaka2"},
+		},
+		{
+			name: "writer reads register nobody writes",
+			bundle: `
+@node misaka1
+IN ACC
+@node misaka2
+MOV R0, ACC
+MOV ACC, misaka3:R0
+@node misaka3
+MOV R0, ACC`,
+			deadlocked: []string{"misaka2", "misaka3"},
+		},
+		{
+			name: "pop from stack nobody pushes to",
+			bundle: `
+@stack stack
+@node misaka1
+IN ACC
+@node misaka2
+POP stack, ACC`,
+			deadlocked: []string{"misaka2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := load(t, tt.bundle)
+			err := n.Run(1000)
+			if !errors.Is(err, ErrStalled) {
+				t.Fatalf("Run() error = %v, want ErrStalled", err)
+			}
+			if got := n.Deadlocked(); !reflect.DeepEqual(got, tt.deadlocked) {
+				t.Errorf("Deadlocked() = %v, want %v", got, tt.deadlocked)
+			}
+			for _, name := range tt.deadlocked {
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("Run() error = %v, want it to name %s", err, name)
+				}
+			}
+		})
+	}
+}
+
+func TestRunReportsFault(t *testing.T) {
+	n := load(t, "@node misaka1\nIN ACC\nDIV ACC")
+	n.Input(0)
+	if err := n.Run(1000); err == nil || !strings.Contains(err.Error(), "misaka1") {
+		t.Errorf("Run() error = %v, want fault on misaka1", err)
+	}
+}
+
+func TestRunReportsCycleLimit(t *testing.T) {
+	n := load(t, "@node misaka1\nloop: ADD 1\nJMP loop")
+	if err := n.Run(10); !errors.Is(err, ErrCycleLimit) {
+		t.Errorf("Run() error = %v, want ErrCycleLimit", err)
+	}
+	if n.Cycle() != 10 {
+		t.Errorf("Cycle() = %d, want 10", n.Cycle())
+	}
+}
@@ -0,0 +1,244 @@
+package tis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pos is a 1-based line and column in source
+type Pos struct {
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Opcode is an instruction mnemonic
+type Opcode int
+
+// Supported opcodes
+const (
+	NOP Opcode = iota
+	MOV
+	SWP
+	SAV
+	ADD
+	SUB
+	NEG
+	JMP
+	JEZ
+	JNZ
+	JGZ
+	JLZ
+	JRO
+	PUSH
+	POP
+	IN
+	OUT
+	MUL
+	DIV
+	MOD
+	AND
+	OR
+	XOR
+	NOT
+	SHL
+	SHR
+)
+
+var opcodeNames = map[Opcode]string{
+	NOP:  "NOP",
+	MOV:  "MOV",
+	SWP:  "SWP",
+	SAV:  "SAV",
+	ADD:  "ADD",
+	SUB:  "SUB",
+	NEG:  "NEG",
+	JMP:  "JMP",
+	JEZ:  "JEZ",
+	JNZ:  "JNZ",
+	JGZ:  "JGZ",
+	JLZ:  "JLZ",
+	JRO:  "JRO",
+	PUSH: "PUSH",
+	POP:  "POP",
+	IN:   "IN",
+	OUT:  "OUT",
+	MUL:  "MUL",
+	DIV:  "DIV",
+	MOD:  "MOD",
+	AND:  "AND",
+	OR:   "OR",
+	XOR:  "XOR",
+	NOT:  "NOT",
+	SHL:  "SHL",
+	SHR:  "SHR",
+}
+
+func (op Opcode) String() string {
+	if s, ok := opcodeNames[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("Opcode(%d)", int(op))
+}
+
+// LookupOpcode finds opcode by case-insensitive mnemonic
+func LookupOpcode(s string) (Opcode, bool) {
+	s = strings.ToUpper(s)
+	for op, name := range opcodeNames {
+		if name == s {
+			return op, true
+		}
+	}
+	return NOP, false
+}
+
+// Register is a register local to a program node
+type Register int
+
+// Supported registers
+const (
+	ACC Register = iota
+	NIL
+	R0
+	R1
+	R2
+	R3
+	// ANY is whichever network register receives a value first
+	ANY
+	// LAST is the network register most recently used through ANY
+	LAST
+)
+
+var registerNames = map[Register]string{
+	ACC:  "ACC",
+	NIL:  "NIL",
+	R0:   "R0",
+	R1:   "R1",
+	R2:   "R2",
+	R3:   "R3",
+	ANY:  "ANY",
+	LAST: "LAST",
+}
+
+func (r Register) String() string {
+	if s, ok := registerNames[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("Register(%d)", int(r))
+}
+
+// LookupRegister finds register by case-insensitive name
+func LookupRegister(s string) (Register, bool) {
+	s = strings.ToUpper(s)
+	for r, name := range registerNames {
+		if name == s {
+			return r, true
+		}
+	}
+	return NIL, false
+}
+
+// IsNetwork checks if register can be written to by peers
+func (r Register) IsNetwork() bool {
+	return r >= R0 && r <= R3
+}
+
+// IsPseudoPort checks if register stands for one of the network registers
+func (r Register) IsPseudoPort() bool {
+	return r == ANY || r == LAST
+}
+
+// Index gets index of network register
+func (r Register) Index() int {
+	return int(r - R0)
+}
+
+// OperandKind is the kind of an instruction operand
+type OperandKind int
+
+// Supported operand kinds
+const (
+	// Literal is an integer value
+	Literal OperandKind = iota
+	// LocalRegister is a register on this node
+	LocalRegister
+	// RemoteRegister is a register on a peer program node
+	RemoteRegister
+	// NodeName is a peer node in the network
+	NodeName
+	// LabelRef is a jump target
+	LabelRef
+	// Constant is a #define name left unexpanded by Format
+	Constant
+)
+
+// Operand is an argument to an instruction
+type Operand struct {
+	Kind OperandKind
+	// Value is the literal value or resolved instruction index of a label
+	Value    int
+	Register Register
+	Node     string
+	Label    string
+	Pos      Pos
+}
+
+func (o Operand) String() string {
+	switch o.Kind {
+	case Literal:
+		return fmt.Sprintf("%d", o.Value)
+	case LocalRegister:
+		return o.Register.String()
+	case RemoteRegister:
+		return fmt.Sprintf("%s:%s", o.Node, o.Register)
+	case NodeName:
+		return o.Node
+	case LabelRef, Constant:
+		return o.Label
+	default:
+		return fmt.Sprintf("Operand(%d)", int(o.Kind))
+	}
+}
+
+// Instruction is a single line of a program
+type Instruction struct {
+	Op   Opcode
+	Args []Operand
+	// Blank marks a line with no instruction that runs as NOP
+	Blank bool
+
+	// Label is the label declared on this line, if any
+	Label    string
+	LabelPos Pos
+	// Comment is the comment on this line, if any
+	Comment string
+	Pos     Pos
+}
+
+func (in Instruction) String() string {
+	if len(in.Args) == 0 {
+		return in.Op.String()
+	}
+	args := make([]string, len(in.Args))
+	for i, arg := range in.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s %s", in.Op, strings.Join(args, ", "))
+}
+
+// Program is a parsed TIS program with one instruction per source line
+type Program struct {
+	Instructions []Instruction
+	Labels       map[string]int
+}
+
+// NewEmptyProgram creates a program that does nothing
+func NewEmptyProgram() *Program {
+	return &Program{
+		Instructions: []Instruction{{Op: NOP, Pos: Pos{Line: 1, Col: 1}}},
+		Labels:       make(map[string]int),
+	}
+}
@@ -0,0 +1,223 @@
+package tis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Diagnostic codes for bundles
+const (
+	CodeBadSection       = "bad-section"
+	CodeDuplicateSection = "duplicate-section"
+	CodeOutsideSection   = "outside-section"
+	CodeEmptyBundle      = "empty-bundle"
+	CodeUndeclaredStack  = "undeclared-stack"
+)
+
+var sectionRe = regexp.MustCompile(`^@(\w*)\s*(\w*)\s*(#.*)?$`)
+
+// BundleSection is the program for one node in a bundle
+type BundleSection struct {
+	Node    string
+	Line    int
+	Program *Program
+}
+
+// Bundle is a whole network described by one source file
+type Bundle struct {
+	Sections []BundleSection
+	Stacks   []string
+
+	stackLines map[string]int
+	file       string
+}
+
+// ParseBundle parses a bundle of node programs.
+//
+//	#define STEP 1            definitions before the first section are shared
+//	@stack misaka3            declares a stack node used by sections
+//	@node misaka1             starts the program for a program node
+//	IN ACC
+//	ADD STEP
+//
+// Trailing blank lines in a section are dropped.
+func ParseBundle(src string, opts PreprocessOptions) (*Bundle, error) {
+	b := &Bundle{stackLines: make(map[string]int), file: opts.File}
+	var diags Diagnostics
+	report := func(line, col, width int, code string, format string, a ...interface{}) {
+		diags = append(diags, Diagnostic{
+			File:     opts.File,
+			Line:     line,
+			Col:      col,
+			EndCol:   col + width,
+			Severity: SeverityError,
+			Code:     code,
+			Message:  fmt.Sprintf(format, a...),
+		})
+	}
+
+	// Split into prelude and sections
+	type section struct {
+		node  string
+		line  int
+		lines []string
+	}
+	var prelude []string
+	var sections []*section
+	seen := make(map[string]bool)
+	var current *section
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "@") {
+			if current != nil {
+				current.lines = append(current.lines, line)
+			} else if len(sections) == 0 {
+				prelude = append(prelude, line)
+			} else if isCode(line) {
+				report(i+1, 1, len(line), CodeOutsideSection, "instructions must be inside a @node section")
+			}
+			continue
+		}
+
+		col := strings.Index(line, "@") + 1
+		m := sectionRe.FindStringSubmatch(trimmed)
+		if m == nil || m[2] == "" || (m[1] != "node" && m[1] != "stack") {
+			report(i+1, col, len(trimmed), CodeBadSection, "'%s' not a valid section; use @node <NAME> or @stack <NAME>", trimmed)
+			current = nil
+			continue
+		}
+		if seen[m[2]] {
+			report(i+1, col, len(trimmed), CodeDuplicateSection, "node '%s' declared more than once", m[2])
+			current = nil
+			continue
+		}
+		seen[m[2]] = true
+
+		switch m[1] {
+		case "node":
+			current = &section{node: m[2], line: i + 1}
+			sections = append(sections, current)
+		case "stack":
+			b.Stacks = append(b.Stacks, m[2])
+			b.stackLines[m[2]] = i + 1
+			current = nil
+		}
+	}
+	if len(sections) == 0 && len(diags) == 0 {
+		report(1, 1, 1, CodeEmptyBundle, "bundle has no @node sections")
+	}
+
+	// Shared definitions
+	base := newPreprocessor(opts)
+	base.file(strings.Join(prelude, "\n"), opts.File, 1, 0, nil)
+	for i, line := range base.lines {
+		if isCode(line) {
+			report(base.origins[i].Line, 1, len(line), CodeOutsideSection, "instructions must be inside a @node section")
+		}
+	}
+	diags = append(diags, base.diags...)
+	base.lines, base.origins, base.diags = nil, nil, nil
+
+	// Node programs
+	for _, s := range sections {
+		for len(s.lines) > 0 && strings.TrimSpace(s.lines[len(s.lines)-1]) == "" {
+			s.lines = s.lines[:len(s.lines)-1]
+		}
+		if len(s.lines) == 0 {
+			s.lines = []string{""}
+		}
+
+		pp := base.fork()
+		pp.file(strings.Join(s.lines, "\n"), opts.File, s.line+1, 0, nil)
+		base.expansions, base.expanded = pp.expansions, pp.expanded
+		source, err := pp.source()
+		if err != nil {
+			d, _ := AsDiagnostics(err)
+			diags = append(diags, d...)
+			continue
+		}
+		prog, err := source.Parse()
+		if err != nil {
+			d, _ := AsDiagnostics(err)
+			diags = append(diags, d...)
+			continue
+		}
+		b.Sections = append(b.Sections, BundleSection{Node: s.node, Line: s.line, Program: prog})
+	}
+
+	if diags.HasErrors() {
+		diags.Sort()
+		return nil, diags
+	}
+	return b, nil
+}
+
+// Check validates bundle against network topology
+func (b *Bundle) Check(topo Topology) Diagnostics {
+	var diags Diagnostics
+	declared := func(name, want, header string, line int) {
+		d := Diagnostic{File: b.file, Line: line, Col: 1, EndCol: len(header) + len(name) + 3, Severity: SeverityError}
+		switch nodeType, ok := topo[name]; {
+		case !ok:
+			d.Code = CodeUnknownNode
+			d.Message = fmt.Sprintf("node '%s' not valid on this network", name)
+		case nodeType != want && want == NodeTypeProgram:
+			d.Code = CodeNotProgramNode
+			d.Message = fmt.Sprintf("@node section for %s node '%s'", nodeType, name)
+		case nodeType != want && want == NodeTypeStack:
+			d.Code = CodeNotStackNode
+			d.Message = fmt.Sprintf("@stack declaration for %s node '%s'", nodeType, name)
+		default:
+			return
+		}
+		diags = append(diags, d)
+	}
+
+	for _, name := range b.Stacks {
+		declared(name, NodeTypeStack, "stack", b.stackLines[name])
+	}
+	for _, s := range b.Sections {
+		declared(s.Node, NodeTypeProgram, "node", s.Line)
+		for _, d := range Check(s.Program, topo) {
+			d.File = b.file
+			diags = append(diags, d)
+		}
+		diags = append(diags, b.checkStacks(s.Program, topo)...)
+	}
+	diags.Sort()
+	return diags
+}
+
+// checkStacks checks that every stack prog uses is declared in bundle
+func (b *Bundle) checkStacks(prog *Program, topo Topology) Diagnostics {
+	var diags Diagnostics
+	for _, instr := range prog.Instructions {
+		for _, arg := range instr.Args {
+			if arg.Kind != NodeName || topo[arg.Node] != NodeTypeStack {
+				continue
+			}
+			if _, ok := b.stackLines[arg.Node]; ok {
+				continue
+			}
+			diags = append(diags, Diagnostic{
+				File:       b.file,
+				Line:       arg.Pos.Line,
+				Col:        arg.Pos.Col,
+				EndCol:     arg.Pos.Col + utf8.RuneCountInString(arg.Node),
+				Severity:   SeverityError,
+				Code:       CodeUndeclaredStack,
+				Message:    fmt.Sprintf("stack '%s' not declared in bundle", arg.Node),
+				Suggestion: fmt.Sprintf("add @stack %s", arg.Node),
+			})
+		}
+	}
+	return diags
+}
+
+// isCode checks if line has anything other than whitespace and comments
+func isCode(line string) bool {
+	tok := NewLexer(line).Next()
+	return tok.Type != TokenEOF && tok.Type != TokenComment
+}
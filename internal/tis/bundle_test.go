@@ -0,0 +1,47 @@
+package tis
+
+import "testing"
+
+func TestBundleCheckRequiresStackDeclarations(t *testing.T) {
+	topo := Topology{"misaka1": NodeTypeProgram, "misaka2": NodeTypeProgram, "misaka3": NodeTypeStack}
+	tests := []struct {
+		name  string
+		src   string
+		codes []string
+		lines []int
+	}{
+		{
+			name: "declared",
+			src:  "#define S misaka3\n@stack misaka3\n@node misaka1\nPUSH 1, S\n@node misaka2\nPOP misaka3, ACC",
+		},
+		{
+			name:  "undeclared",
+			src:   "@node misaka1\nPUSH 1, misaka3\n@node misaka2\nNOP\nPOP misaka3, ACC",
+			codes: []string{CodeUndeclaredStack, CodeUndeclaredStack},
+			lines: []int{2, 5},
+		},
+		{
+			name:  "not a stack",
+			src:   "@stack misaka2\n@node misaka1\nNOP",
+			codes: []string{CodeNotStackNode},
+			lines: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParseBundle(tt.src, PreprocessOptions{File: "net.tisb"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			diags := b.Check(topo)
+			if len(diags) != len(tt.codes) {
+				t.Fatalf("Check() = %v, want codes %v", diags, tt.codes)
+			}
+			for i, d := range diags {
+				if d.Code != tt.codes[i] || d.Line != tt.lines[i] || d.File != "net.tisb" {
+					t.Errorf("Check()[%d] = %s %s:%d, want %s net.tisb:%d", i, d.Code, d.File, d.Line, tt.codes[i], tt.lines[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package tis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Bytecode layout, all integers varint encoded:
+//
+//	magic    "MSKB"
+//	version  uvarint
+//	consts   count, then per constant a tag byte and an int or length-prefixed string
+//	labels   count, then per label a name constant and an instruction index
+//	code     count, then per instruction an opcode, operand count and operands
+//	lines    per instruction the source line, column, label column and line flags
+//
+// Comments are not kept in bytecode.
+const (
+	// BytecodeMagic prefixes every compiled program
+	BytecodeMagic = "MSKB"
+	// BytecodeVersion is the current bytecode format version
+	BytecodeVersion = 1
+)
+
+// Line flags in debug line map
+const (
+	lineBlank uint64 = 1 << iota
+)
+
+// Constant pool tags
+const (
+	constInt byte = iota
+	constString
+)
+
+// maxBytecodeCount bounds counts read from untrusted bytecode
+const maxBytecodeCount = 1 << 20
+
+// Encode compiles program into versioned bytecode
+func Encode(prog *Program) []byte {
+	e := &encoder{
+		ints:    make(map[int]uint64),
+		strings: make(map[string]uint64),
+	}
+
+	// Build code section first so constants are interned
+	var code bytes.Buffer
+	e.uvarint(&code, uint64(len(prog.Instructions)))
+	for _, instr := range prog.Instructions {
+		code.WriteByte(byte(instr.Op))
+		code.WriteByte(byte(len(instr.Args)))
+		for _, arg := range instr.Args {
+			code.WriteByte(byte(arg.Kind))
+			switch arg.Kind {
+			case Literal:
+				e.uvarint(&code, e.intConst(arg.Value))
+			case LocalRegister:
+				code.WriteByte(byte(arg.Register))
+			case RemoteRegister:
+				e.uvarint(&code, e.stringConst(arg.Node))
+				code.WriteByte(byte(arg.Register))
+			case NodeName:
+				e.uvarint(&code, e.stringConst(arg.Node))
+			case LabelRef:
+				e.uvarint(&code, e.stringConst(arg.Label))
+				e.uvarint(&code, uint64(arg.Value))
+			}
+		}
+	}
+
+	var labels bytes.Buffer
+	e.uvarint(&labels, uint64(len(prog.Labels)))
+	for i, instr := range prog.Instructions {
+		if instr.Label != "" && prog.Labels[instr.Label] == i {
+			e.uvarint(&labels, e.stringConst(instr.Label))
+			e.uvarint(&labels, uint64(i))
+		}
+	}
+
+	var lines bytes.Buffer
+	for _, instr := range prog.Instructions {
+		e.uvarint(&lines, uint64(instr.Pos.Line))
+		e.uvarint(&lines, uint64(instr.Pos.Col))
+		e.uvarint(&lines, uint64(instr.LabelPos.Col))
+		var flags uint64
+		if instr.Blank {
+			flags |= lineBlank
+		}
+		e.uvarint(&lines, flags)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(BytecodeMagic)
+	e.uvarint(&out, BytecodeVersion)
+	e.uvarint(&out, uint64(len(e.pool)))
+	for _, c := range e.pool {
+		out.WriteByte(c.tag)
+		switch c.tag {
+		case constInt:
+			var buf [binary.MaxVarintLen64]byte
+			out.Write(buf[:binary.PutVarint(buf[:], int64(c.i))])
+		case constString:
+			e.uvarint(&out, uint64(len(c.s)))
+			out.WriteString(c.s)
+		}
+	}
+	out.Write(labels.Bytes())
+	out.Write(code.Bytes())
+	out.Write(lines.Bytes())
+	return out.Bytes()
+}
+
+// Decode loads program from bytecode
+func Decode(b []byte) (*Program, error) {
+	if !bytes.HasPrefix(b, []byte(BytecodeMagic)) {
+		return nil, errors.New("bytecode: bad magic header")
+	}
+	d := &decoder{r: bytes.NewReader(b[len(BytecodeMagic):])}
+
+	if v := d.uvarint(); d.err == nil && v != BytecodeVersion {
+		return nil, fmt.Errorf("bytecode: unsupported version %v", v)
+	}
+
+	// Constant pool
+	n := d.count()
+	pool := make([]constant, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		c := constant{tag: d.byte()}
+		switch c.tag {
+		case constInt:
+			c.i = int(d.varint())
+		case constString:
+			l := d.count()
+			buf := make([]byte, l)
+			if _, err := io.ReadFull(d.r, buf); err != nil {
+				d.fail(errors.New("unexpected end of bytecode"))
+			}
+			c.s = string(buf)
+		default:
+			d.fail(fmt.Errorf("unknown constant tag %v", c.tag))
+		}
+		pool = append(pool, c)
+	}
+	str := func(idx uint64) string {
+		if idx >= uint64(len(pool)) || pool[idx].tag != constString {
+			d.fail(fmt.Errorf("bad string constant %v", idx))
+			return ""
+		}
+		return pool[idx].s
+	}
+
+	// Labels
+	prog := &Program{Labels: make(map[string]int)}
+	n = d.count()
+	for i := 0; i < n && d.err == nil; i++ {
+		name := str(d.uvarint())
+		prog.Labels[name] = int(d.uvarint())
+	}
+
+	// Code
+	n = d.count()
+	if d.err == nil && n == 0 {
+		d.fail(errors.New("program has no instructions"))
+	}
+	prog.Instructions = make([]Instruction, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		instr := Instruction{Op: Opcode(d.byte())}
+		specs, ok := instrSpecs[instr.Op]
+		if !ok {
+			d.fail(fmt.Errorf("unknown opcode %v", int(instr.Op)))
+			break
+		}
+		argc := int(d.byte())
+		if argc != len(specs) {
+			d.fail(fmt.Errorf("%s has %v operands, expected %v", instr.Op, argc, len(specs)))
+			break
+		}
+		for j := 0; j < argc && d.err == nil; j++ {
+			arg := Operand{Kind: OperandKind(d.byte())}
+			switch arg.Kind {
+			case Literal:
+				idx := d.uvarint()
+				if idx >= uint64(len(pool)) || pool[idx].tag != constInt {
+					d.fail(fmt.Errorf("bad int constant %v", idx))
+				} else {
+					arg.Value = pool[idx].i
+				}
+			case LocalRegister:
+				arg.Register = d.register()
+			case RemoteRegister:
+				arg.Node = str(d.uvarint())
+				arg.Register = d.register()
+			case NodeName:
+				arg.Node = str(d.uvarint())
+			case LabelRef:
+				arg.Label = str(d.uvarint())
+				arg.Value = int(d.uvarint())
+			default:
+				d.fail(fmt.Errorf("unknown operand kind %v", int(arg.Kind)))
+			}
+			if d.err == nil && !specs[j].accepts(arg) {
+				d.fail(fmt.Errorf("'%s' not a valid %s for %s", arg, specNames[specs[j]], instr.Op))
+			}
+			instr.Args = append(instr.Args, arg)
+		}
+		prog.Instructions = append(prog.Instructions, instr)
+	}
+	for label, i := range prog.Labels {
+		if d.err == nil && (i < 0 || i >= len(prog.Instructions)) {
+			d.fail(fmt.Errorf("label '%s' out of range", label))
+		} else if d.err == nil {
+			prog.Instructions[i].Label = label
+		}
+	}
+	for i := range prog.Instructions {
+		for _, arg := range prog.Instructions[i].Args {
+			if d.err == nil && arg.Kind == LabelRef && (arg.Value < 0 || arg.Value >= len(prog.Instructions)) {
+				d.fail(fmt.Errorf("jump target %v out of range", arg.Value))
+			}
+		}
+	}
+
+	// Debug line map
+	for i := range prog.Instructions {
+		if d.err != nil {
+			break
+		}
+		line := int(d.uvarint())
+		prog.Instructions[i].Pos = Pos{Line: line, Col: int(d.uvarint())}
+		if col := int(d.uvarint()); prog.Instructions[i].Label != "" {
+			prog.Instructions[i].LabelPos = Pos{Line: line, Col: col}
+		}
+		flags := d.uvarint()
+		prog.Instructions[i].Blank = flags&lineBlank != 0 && prog.Instructions[i].Op == NOP
+	}
+
+	if d.err == nil && d.r.Len() > 0 {
+		d.fail(errors.New("trailing data"))
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("bytecode: %s", d.err.Error())
+	}
+	return prog, nil
+}
+
+// constant is an entry in the constant pool
+type constant struct {
+	tag byte
+	i   int
+	s   string
+}
+
+// encoder interns constants while encoding
+type encoder struct {
+	pool    []constant
+	ints    map[int]uint64
+	strings map[string]uint64
+}
+
+func (e *encoder) intConst(v int) uint64 {
+	if idx, ok := e.ints[v]; ok {
+		return idx
+	}
+	idx := uint64(len(e.pool))
+	e.pool = append(e.pool, constant{tag: constInt, i: v})
+	e.ints[v] = idx
+	return idx
+}
+
+func (e *encoder) stringConst(s string) uint64 {
+	if idx, ok := e.strings[s]; ok {
+		return idx
+	}
+	idx := uint64(len(e.pool))
+	e.pool = append(e.pool, constant{tag: constString, s: s})
+	e.strings[s] = idx
+	return idx
+}
+
+func (e *encoder) uvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+// decoder reads bytecode and remembers the first error
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	if err != nil {
+		d.fail(errors.New("unexpected end of bytecode"))
+	}
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.fail(errors.New("unexpected end of bytecode"))
+	}
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	if err != nil {
+		d.fail(errors.New("unexpected end of bytecode"))
+	}
+	return v
+}
+
+func (d *decoder) count() int {
+	v := d.uvarint()
+	if v > maxBytecodeCount || v > uint64(d.r.Len()+1) {
+		d.fail(fmt.Errorf("count %v too large", v))
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) register() Register {
+	r := Register(d.byte())
+	if _, ok := registerNames[r]; !ok {
+		d.fail(fmt.Errorf("unknown register %v", int(r)))
+	}
+	return r
+}
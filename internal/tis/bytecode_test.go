@@ -0,0 +1,70 @@
+package tis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBytecodeRoundTrip(t *testing.T) {
+	src := "START: MOV R0, ACC # read\n\nADD 5\nJGZ START\nMOV ACC, misaka2:ANY\nPUSH ACC, stack\nPOP stack, NIL\nOUT LAST"
+	prog, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(Encode(prog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range prog.Instructions {
+		// Comments are not kept in bytecode
+		prog.Instructions[i].Comment = ""
+		for j := range prog.Instructions[i].Args {
+			prog.Instructions[i].Args[j].Pos = Pos{}
+		}
+	}
+	if !reflect.DeepEqual(got, prog) {
+		t.Errorf("Decode(Encode(prog)) = %+v, want %+v", got, prog)
+	}
+}
+
+func TestDecodeRejectsBadOperandKinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		instr Instruction
+	}{
+		{"literal dst", Instruction{Op: MOV, Args: []Operand{{Kind: Literal, Value: 1}, {Kind: Literal, Value: 2}}}},
+		{"network register local dst", Instruction{Op: IN, Args: []Operand{{Kind: LocalRegister, Register: R0}}}},
+		{"remote register src", Instruction{Op: ADD, Args: []Operand{{Kind: RemoteRegister, Node: "a", Register: R0}}}},
+		{"remote LAST dst", Instruction{Op: MOV, Args: []Operand{{Kind: Literal}, {Kind: RemoteRegister, Node: "a", Register: LAST}}}},
+		{"register node", Instruction{Op: PUSH, Args: []Operand{{Kind: Literal}, {Kind: LocalRegister, Register: ACC}}}},
+		{"literal label", Instruction{Op: JMP, Args: []Operand{{Kind: Literal}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog := &Program{Instructions: []Instruction{tt.instr}, Labels: map[string]int{}}
+			if _, err := Decode(Encode(prog)); err == nil || !strings.Contains(err.Error(), "not a valid") {
+				t.Errorf("Decode() error = %v, want operand error", err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsBadHeader(t *testing.T) {
+	prog, err := Parse("NOP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Encode(prog)
+	if _, err := Decode(append([]byte("XXXX"), b[4:]...)); err == nil {
+		t.Error("Decode() accepted bad magic")
+	}
+	bad := append([]byte{}, b...)
+	bad[len(BytecodeMagic)] = BytecodeVersion + 1
+	if _, err := Decode(bad); err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Errorf("Decode() error = %v, want unsupported version", err)
+	}
+	if _, err := Decode(b[:len(b)-1]); err == nil {
+		t.Error("Decode() accepted truncated bytecode")
+	}
+}
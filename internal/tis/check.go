@@ -0,0 +1,77 @@
+package tis
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Node types understood by the checker
+const (
+	NodeTypeProgram = "program"
+	NodeTypeStack   = "stack"
+)
+
+// Diagnostic codes for semantic checks
+const (
+	CodeUnknownNode    = "unknown-node"
+	CodeNotProgramNode = "not-program-node"
+	CodeNotStackNode   = "not-stack-node"
+)
+
+// Topology maps node names in a network to their node types
+type Topology map[string]string
+
+// Check validates node references in program against network topology
+func Check(prog *Program, topo Topology) Diagnostics {
+	names := make([]string, 0, len(topo))
+	for name := range topo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diags Diagnostics
+	for _, instr := range prog.Instructions {
+		for _, arg := range instr.Args {
+			var want string
+			switch {
+			case arg.Kind == RemoteRegister:
+				want = NodeTypeProgram
+			case arg.Kind == NodeName && (instr.Op == PUSH || instr.Op == POP):
+				want = NodeTypeStack
+			default:
+				continue
+			}
+
+			d := Diagnostic{
+				Line:     arg.Pos.Line,
+				Col:      arg.Pos.Col,
+				EndCol:   arg.Pos.Col + utf8.RuneCountInString(arg.Node),
+				Severity: SeverityError,
+			}
+			nodeType, ok := topo[arg.Node]
+			switch {
+			case !ok:
+				d.Code = CodeUnknownNode
+				d.Message = fmt.Sprintf("node '%s' not valid on this network", arg.Node)
+				d.Suggestion = suggest(arg.Node, names)
+			case nodeType != want && want == NodeTypeProgram:
+				d.Code = CodeNotProgramNode
+				d.Message = fmt.Sprintf("%s cannot write to registers on %s node '%s'", instr.Op, nodeType, arg.Node)
+				if nodeType == NodeTypeStack {
+					d.Suggestion = fmt.Sprintf("use PUSH <VAL/SRC>, %s", arg.Node)
+				}
+			case nodeType != want && want == NodeTypeStack:
+				d.Code = CodeNotStackNode
+				d.Message = fmt.Sprintf("%s needs a stack node but '%s' is a %s node", instr.Op, arg.Node, nodeType)
+				if nodeType == NodeTypeProgram && instr.Op == PUSH {
+					d.Suggestion = fmt.Sprintf("use MOV <VAL/SRC>, %s:R0", arg.Node)
+				}
+			default:
+				continue
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
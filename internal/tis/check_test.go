@@ -0,0 +1,76 @@
+package tis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	topo := Topology{"misaka1": NodeTypeProgram, "misaka2": NodeTypeProgram, "stack": NodeTypeStack}
+	tests := []struct {
+		name string
+		src  string
+		want Diagnostics
+	}{
+		{
+			name: "valid",
+			src:  "MOV ACC, misaka2:R0\nMOV 1, misaka1:ANY\nPUSH 1, stack\nPOP stack, ACC",
+		},
+		{
+			name: "unknown node",
+			src:  "NOP\nMOV ACC, misaka3:R0",
+			want: Diagnostics{{Line: 2, Col: 10, EndCol: 17, Code: CodeUnknownNode,
+				Message: "node 'misaka3' not valid on this network", Suggestion: "did you mean misaka1?"}},
+		},
+		{
+			name: "unknown stack",
+			src:  "POP stakc, ACC",
+			want: Diagnostics{{Line: 1, Col: 5, EndCol: 10, Code: CodeUnknownNode,
+				Message: "node 'stakc' not valid on this network", Suggestion: "did you mean stack?"}},
+		},
+		{
+			name: "not program node",
+			src:  "MOV ACC, stack:R1",
+			want: Diagnostics{{Line: 1, Col: 10, EndCol: 15, Code: CodeNotProgramNode,
+				Message: "MOV cannot write to registers on stack node 'stack'", Suggestion: "use PUSH <VAL/SRC>, stack"}},
+		},
+		{
+			name: "push to program node",
+			src:  "PUSH ACC, misaka2",
+			want: Diagnostics{{Line: 1, Col: 11, EndCol: 18, Code: CodeNotStackNode,
+				Message: "PUSH needs a stack node but 'misaka2' is a program node", Suggestion: "use MOV <VAL/SRC>, misaka2:R0"}},
+		},
+		{
+			name: "pop from program node",
+			src:  "POP misaka1, NIL",
+			want: Diagnostics{{Line: 1, Col: 5, EndCol: 12, Code: CodeNotStackNode,
+				Message: "POP needs a stack node but 'misaka1' is a program node"}},
+		},
+		{
+			name: "every problem",
+			src:  "MOV 1, nowhere:R0\nPUSH 1, misaka1\nMOV 2, stack:R2",
+			want: Diagnostics{
+				{Line: 1, Col: 8, EndCol: 15, Code: CodeUnknownNode, Message: "node 'nowhere' not valid on this network"},
+				{Line: 2, Col: 9, EndCol: 16, Code: CodeNotStackNode,
+					Message: "PUSH needs a stack node but 'misaka1' is a program node", Suggestion: "use MOV <VAL/SRC>, misaka1:R0"},
+				{Line: 3, Col: 8, EndCol: 13, Code: CodeNotProgramNode,
+					Message: "MOV cannot write to registers on stack node 'stack'", Suggestion: "use PUSH <VAL/SRC>, stack"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Check(prog, topo)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if got.HasErrors() != (len(tt.want) > 0) {
+				t.Errorf("HasErrors() = %v", got.HasErrors())
+			}
+		})
+	}
+}
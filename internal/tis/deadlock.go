@@ -0,0 +1,90 @@
+package tis
+
+import "sort"
+
+// FindDeadlock finds blocked program nodes that can never be unblocked.
+// blocked has the port each blocked node waits on. Returns sorted deadlocked nodes
+// and the nodes each blocked node waits for.
+//
+// A node reading a register waits for any node whose program writes to it,
+// a node writing to a peer waits for that peer and a node popping an empty stack waits
+// for any node whose program pushes to it. A node waiting on input or output can be
+// unblocked from outside the network and is never deadlocked. A blocked node is
+// deadlocked if every node it waits for is deadlocked too.
+func FindDeadlock(programs map[string]*Program, blocked map[string]Port) ([]string, map[string][]string) {
+	// Find who writes to each register and pushes to each stack
+	writers := make(map[string]map[Register][]string)
+	pushers := make(map[string][]string)
+	for name, prog := range programs {
+		for _, instr := range prog.Instructions {
+			switch {
+			case instr.Op == MOV && instr.Args[1].Kind == RemoteRegister:
+				dst := instr.Args[1]
+				if writers[dst.Node] == nil {
+					writers[dst.Node] = make(map[Register][]string)
+				}
+				writers[dst.Node][dst.Register] = append(writers[dst.Node][dst.Register], name)
+			case instr.Op == PUSH:
+				pushers[instr.Args[1].Node] = append(pushers[instr.Args[1].Node], name)
+			}
+		}
+	}
+
+	waitingFor := make(map[string][]string)
+	deadlocked := make(map[string]bool)
+	for name, port := range blocked {
+		var waits []string
+		switch port.Kind {
+		case ReadPort:
+			regs := []Register{port.Register, ANY}
+			if port.Register == ANY {
+				regs = []Register{R0, R1, R2, R3, ANY}
+			}
+			for _, r := range regs {
+				waits = append(waits, writers[name][r]...)
+			}
+		case WritePort:
+			waits = []string{port.Node}
+		case PopPort:
+			waits = append(waits, pushers[port.Node]...)
+		default:
+			waitingFor[name] = []string{}
+			continue
+		}
+		waitingFor[name] = uniqueStrings(waits)
+		deadlocked[name] = true
+	}
+
+	// Drop nodes that can still be unblocked until only deadlocked nodes are left
+	for changed := true; changed; {
+		changed = false
+		for name := range deadlocked {
+			for _, other := range waitingFor[name] {
+				if !deadlocked[other] {
+					delete(deadlocked, name)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range deadlocked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, waitingFor
+}
+
+// uniqueStrings sorts and removes duplicates from s
+func uniqueStrings(s []string) []string {
+	sort.Strings(s)
+	res := []string{}
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
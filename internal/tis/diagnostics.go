@@ -0,0 +1,116 @@
+package tis
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is how serious a diagnostic is
+type Severity int
+
+// Supported severities
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// MarshalJSON encodes severity as its name
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Diagnostic error codes
+const (
+	CodeIllegalCharacter   = "illegal-character"
+	CodeUnknownInstruction = "unknown-instruction"
+	CodeInvalidOperand     = "invalid-operand"
+	CodeMissingOperand     = "missing-operand"
+	CodeExpectedComma      = "expected-comma"
+	CodeUnexpectedToken    = "unexpected-token"
+	CodeInvalidNumber      = "invalid-number"
+	CodeDuplicateLabel     = "duplicate-label"
+	CodeUndeclaredLabel    = "undeclared-label"
+)
+
+// Diagnostic is a problem found in source.
+// Lines and columns are 1-based and span columns [Col, EndCol).
+type Diagnostic struct {
+	File       string   `json:"file,omitempty"`
+	Line       int      `json:"line"`
+	Col        int      `json:"col"`
+	EndCol     int      `json:"endCol"`
+	Severity   Severity `json:"severity"`
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+func (d Diagnostic) Error() string {
+	s := fmt.Sprintf("line %v, col %v, %s", d.Line, d.Col, d.Message)
+	if d.File != "" {
+		s = fmt.Sprintf("%s: %s", d.File, s)
+	}
+	if d.Suggestion != "" {
+		s = fmt.Sprintf("%s (%s)", s, d.Suggestion)
+	}
+	return s
+}
+
+// Diagnostics is a list of problems found in source
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	msgs := make([]string, len(ds))
+	for i, d := range ds {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// HasErrors checks if any diagnostic is an error
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort orders diagnostics by position
+func (ds Diagnostics) Sort() {
+	sort.SliceStable(ds, func(i, j int) bool {
+		if ds[i].File != ds[j].File {
+			return ds[i].File < ds[j].File
+		}
+		if ds[i].Line != ds[j].Line {
+			return ds[i].Line < ds[j].Line
+		}
+		return ds[i].Col < ds[j].Col
+	})
+}
+
+// AsDiagnostics extracts diagnostics from an error returned by this package
+func AsDiagnostics(err error) (Diagnostics, bool) {
+	switch e := err.(type) {
+	case Diagnostics:
+		return e, true
+	case Diagnostic:
+		return Diagnostics{e}, true
+	default:
+		return nil, false
+	}
+}
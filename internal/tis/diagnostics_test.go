@@ -0,0 +1,72 @@
+package tis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReportsEveryDiagnostic(t *testing.T) {
+	src := "MVO 1, ACC\nstart: MOV 1 ACC\n  MOV 1, misaka2:R9\nJMP strat\nIN R1\nSUB $\nstart: NOP"
+	_, err := Parse(src)
+	got, ok := AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("Parse() error = %v, want Diagnostics", err)
+	}
+	want := Diagnostics{
+		{Line: 1, Col: 1, EndCol: 4, Code: CodeUnknownInstruction,
+			Message: "'MVO' not a valid instruction", Suggestion: "did you mean MOV?"},
+		{Line: 2, Col: 14, EndCol: 17, Code: CodeExpectedComma,
+			Message: "expected ',' after operand 1 of MOV", Suggestion: "insert ',' between operands"},
+		{Line: 3, Col: 18, EndCol: 20, Code: CodeInvalidOperand,
+			Message: "'R9' not a valid network register", Suggestion: "did you mean R0?"},
+		{Line: 4, Col: 5, EndCol: 10, Code: CodeUndeclaredLabel,
+			Message: "label 'STRAT' was not declared", Suggestion: "did you mean START?"},
+		{Line: 5, Col: 4, EndCol: 6, Code: CodeInvalidOperand,
+			Message: "'R1' not a valid <DST> for IN", Suggestion: "network registers are read-only on this node; use ACC or NIL"},
+		{Line: 6, Col: 5, EndCol: 6, Code: CodeIllegalCharacter,
+			Message: "illegal character '$'"},
+		{Line: 7, Col: 1, EndCol: 6, Code: CodeDuplicateLabel,
+			Message: "cannot repeat label 'START'"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiagnosticSuggestions(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"ad 1", "did you mean ADD?"},
+		{"MOV AC, NIL", "did you mean ACC?"},
+		{"MOV 1, R0", "write to a peer with <NODE>:R0"},
+		{"MOV 1, 5", "a literal cannot be a destination"},
+		{"MOV 1, misaka2:LAST", "write to the last peer register with LAST"},
+		{"ADD 1, 2", "ADD takes 1 operands"},
+		{"l: JMP k", "did you mean L?"},
+		{"XYZZY", ""},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		diags, ok := AsDiagnostics(err)
+		if !ok || len(diags) != 1 {
+			t.Errorf("Parse(%q) error = %v, want one diagnostic", tt.src, err)
+			continue
+		}
+		if diags[0].Suggestion != tt.want {
+			t.Errorf("Parse(%q) suggestion = %q, want %q", tt.src, diags[0].Suggestion, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticError(t *testing.T) {
+	d := Diagnostic{File: "lib.tis", Line: 2, Col: 3, EndCol: 5, Message: "'AD' not a valid instruction", Suggestion: "did you mean ADD?"}
+	if got, want := d.Error(), "lib.tis: line 2, col 3, 'AD' not a valid instruction (did you mean ADD?)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	ds := Diagnostics{{Line: 1, Col: 1, Message: "a"}, {Line: 2, Col: 4, Message: "b"}}
+	if got, want := ds.Error(), "line 1, col 1, a; line 2, col 4, b"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package tis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Disassemble converts program back into canonical source.
+//
+// Mnemonics and registers are upper-cased, operands are separated by ", ",
+// and instructions are indented past the longest label so they line up.
+func Disassemble(prog *Program) string {
+	labelWidth := 0
+	for _, instr := range prog.Instructions {
+		if instr.Label != "" && len(instr.Label)+2 > labelWidth {
+			labelWidth = len(instr.Label) + 2
+		}
+	}
+
+	lines := make([]string, len(prog.Instructions))
+	for i, instr := range prog.Instructions {
+		lines[i] = disassembleLine(instr, labelWidth)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// disassembleLine converts instruction into a line of canonical source
+func disassembleLine(instr Instruction, labelWidth int) string {
+	var b strings.Builder
+
+	if instr.Label != "" {
+		fmt.Fprintf(&b, "%-*s", labelWidth, instr.Label+":")
+	} else if !instr.Blank || instr.Comment != "" {
+		b.WriteString(strings.Repeat(" ", labelWidth))
+	}
+
+	if !instr.Blank {
+		b.WriteString(instr.String())
+	}
+
+	if instr.Comment != "" {
+		if !instr.Blank {
+			b.WriteString(" ")
+		}
+		b.WriteString(canonicalComment(instr.Comment))
+	}
+
+	return strings.TrimRight(b.String(), " ")
+}
+
+// canonicalComment puts a single space after the comment marker
+func canonicalComment(comment string) string {
+	text := strings.TrimSpace(strings.TrimPrefix(comment, "#"))
+	if text == "" {
+		return "#"
+	}
+	return "# " + text
+}
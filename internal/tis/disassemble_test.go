@@ -0,0 +1,67 @@
+package tis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// normalized clears source positions and comment spacing, which change when source is reformatted
+func normalized(prog *Program) *Program {
+	for i := range prog.Instructions {
+		instr := &prog.Instructions[i]
+		instr.Pos = Pos{}
+		instr.LabelPos = Pos{}
+		if instr.Comment != "" {
+			instr.Comment = canonicalComment(instr.Comment)
+		}
+		for j := range instr.Args {
+			instr.Args[j].Pos = Pos{}
+		}
+	}
+	return prog
+}
+
+func TestDisassembleRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"no operands", "nop\nswp\nsav\nneg\nnot", "NOP\nSWP\nSAV\nNEG\nNOT"},
+		{"mov literals", "MOV 1, ACC\nMOV -999,NIL", "MOV 1, ACC\nMOV -999, NIL"},
+		{"mov registers", "mov acc, nil\nMOV NIL, ACC\nMOV R0, ACC\nMOV R1, ACC\nMOV R2, ACC\nMOV R3, ACC",
+			"MOV ACC, NIL\nMOV NIL, ACC\nMOV R0, ACC\nMOV R1, ACC\nMOV R2, ACC\nMOV R3, ACC"},
+		{"mov pseudo-ports", "MOV ANY, ACC\nMOV LAST, LAST\nMOV ACC, misaka2:ANY", "MOV ANY, ACC\nMOV LAST, LAST\nMOV ACC, misaka2:ANY"},
+		{"mov remote", "MOV ACC, misaka2:R0\nMOV 3, misaka3:r3", "MOV ACC, misaka2:R0\nMOV 3, misaka3:R3"},
+		{"arithmetic", "ADD 1\nSUB R0\nMUL ACC\nDIV -2\nMOD ANY\nJRO LAST",
+			"ADD 1\nSUB R0\nMUL ACC\nDIV -2\nMOD ANY\nJRO LAST"},
+		{"bitwise", "AND 1\nOR R1\nXOR NIL\nSHL 2\nSHR ACC", "AND 1\nOR R1\nXOR NIL\nSHL 2\nSHR ACC"},
+		{"jumps", "l: JMP l\nJEZ L\nJNZ l\nJGZ l\nJLZ l", "L: JMP L\n   JEZ L\n   JNZ L\n   JGZ L\n   JLZ L"},
+		{"stack", "PUSH ACC, stack\nPUSH 5,stack\nPOP stack, ACC\nPOP stack, NIL", "PUSH ACC, stack\nPUSH 5, stack\nPOP stack, ACC\nPOP stack, NIL"},
+		{"master", "IN ACC\nIN NIL\nOUT R2\nOUT 7", "IN ACC\nIN NIL\nOUT R2\nOUT 7"},
+		{"labels and comments", "start:\n  mov 1, acc   #one\n\n#  note\nlong_label: add 1\nJMP start",
+			"START:\n            MOV 1, ACC # one\n\n            # note\nLONG_LABEL: ADD 1\n            JMP START"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Disassemble(prog)
+			if got != tt.want {
+				t.Errorf("Disassemble() = %q, want %q", got, tt.want)
+			}
+			again, err := Parse(got)
+			if err != nil {
+				t.Fatalf("Parse(Disassemble()) error = %v", err)
+			}
+			if !reflect.DeepEqual(normalized(again), normalized(prog)) {
+				t.Errorf("Parse(Disassemble()) = %+v, want %+v", again, prog)
+			}
+			if Disassemble(again) != got {
+				t.Errorf("Disassemble() is not stable: %q", Disassemble(again))
+			}
+		})
+	}
+}
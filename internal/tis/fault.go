@@ -0,0 +1,20 @@
+package tis
+
+import "fmt"
+
+// Fault is a runtime error raised by an instruction.
+// A faulted node stops instead of retrying the instruction.
+type Fault struct {
+	Pos     Pos
+	Op      Opcode
+	Message string
+}
+
+// NewFault creates a fault raised by instr
+func NewFault(instr Instruction, format string, a ...interface{}) *Fault {
+	return &Fault{Pos: instr.Pos, Op: instr.Op, Message: fmt.Sprintf(format, a...)}
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("line %v, col %v, %s fault: %s", f.Pos.Line, f.Pos.Col, f.Op, f.Message)
+}
@@ -0,0 +1,81 @@
+package tis
+
+import "strings"
+
+// Format parses source and returns it in canonical form.
+//
+// Preprocessor directives, macro bodies and macro invocations are kept
+// verbatim and constants are left unexpanded, so only instruction lines
+// are rewritten. Lines are never added or removed.
+func Format(src string) (string, error) {
+	lines := strings.Split(src, "\n")
+	kept := make([]bool, len(lines))
+	constants := make(map[string]bool)
+	macros := make(map[string]bool)
+	includes := false
+
+	// Find directives and macro bodies
+	inMacro := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inMacro {
+			kept[i] = true
+			inMacro = !endRe.MatchString(trimmed)
+			continue
+		}
+		if !isDirective(trimmed) {
+			continue
+		}
+		kept[i] = true
+		if m := defineRe.FindStringSubmatch(trimmed); m != nil {
+			constants[m[1]] = true
+		} else if m := macroRe.FindStringSubmatch(trimmed); m != nil {
+			macros[strings.ToUpper(m[1])] = true
+			inMacro = true
+		} else if includeRe.MatchString(trimmed) {
+			includes = true
+		}
+	}
+
+	// Find macro invocations. Macros from included files are unknown here
+	// so any line not starting with an instruction is taken as one.
+	partial := false
+	for i, line := range lines {
+		if kept[i] {
+			partial = true
+			continue
+		}
+		_, name, _, _, ok := splitInvocation(line)
+		if !ok {
+			continue
+		}
+		_, isOp := LookupOpcode(name)
+		if macros[strings.ToUpper(name)] || constants[name] || (includes && !isOp) {
+			kept[i] = true
+			partial = true
+		}
+	}
+
+	// Format the remaining lines
+	blanked := make([]string, len(lines))
+	for i, line := range lines {
+		if !kept[i] {
+			blanked[i] = line
+		}
+	}
+	p := newParser(strings.Join(blanked, "\n"))
+	p.constants = constants
+	p.partial = partial
+	prog, err := p.parse()
+	if err != nil {
+		return "", err
+	}
+
+	formatted := strings.Split(Disassemble(prog), "\n")
+	for i, line := range lines {
+		if kept[i] {
+			formatted[i] = line
+		}
+	}
+	return strings.Join(formatted, "\n"), nil
+}
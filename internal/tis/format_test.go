@@ -0,0 +1,98 @@
+package tis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "instructions",
+			src:  "start:mov 1,acc\n  add   r0 #sum\njmp start",
+			want: "START: MOV 1, ACC\n       ADD R0 # sum\n       JMP START",
+		},
+		{
+			name: "include",
+			src:  "#include \"lib.tis\"\n   mov acc,nil\nlib_op 1, 2\njmp lib_label",
+			want: "#include \"lib.tis\"\nMOV ACC, NIL\nlib_op 1, 2\nJMP LIB_LABEL",
+		},
+		{
+			name: "define",
+			src:  "#define N 5\n#define OUT right\nmov  N,acc\npush acc,OUT\nmov acc, OUT:r0",
+			want: "#define N 5\n#define OUT right\nMOV N, ACC\nPUSH ACC, OUT\nMOV ACC, OUT:R0",
+		},
+		{
+			name: "macro",
+			src:  "#macro twice(x)\n  add x\n  add x\n#endmacro\nloop: twice 3\n  jmp loop",
+			want: "#macro twice(x)\n  add x\n  add x\n#endmacro\nloop: twice 3\nJMP LOOP",
+		},
+		{
+			name: "comments that look like directives",
+			src:  "#include lib.tis\n#macro for later\nmov 1,acc",
+			want: "# include lib.tis\n# macro for later\nMOV 1, ACC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.src)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+			again, err := Format(got)
+			if err != nil || again != got {
+				t.Errorf("Format() not idempotent: %q, %v", again, err)
+			}
+			if strings.Count(got, "\n") != strings.Count(tt.src, "\n") {
+				t.Errorf("Format() changed line count")
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	srcs := []string{
+		"mov 1,acc\nmov acc,nil\nMOV r0, misaka2:r1",
+		"\n\n  # only comments\n\n",
+		"a:\nb:jez a\njmp  b",
+		"loop:\n  in acc\n  jlz neg #negative\n  out acc\n  jmp loop\nneg: neg\n  out acc # flipped\n  jro -6",
+		"push 3, stack\npop stack,nil\n  sav\nswp\nsub R3",
+	}
+	for _, src := range srcs {
+		got, err := Format(src)
+		if err != nil {
+			t.Fatalf("Format(%q) error = %v", src, err)
+		}
+		prog, err := Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, err := Parse(got)
+		if err != nil {
+			t.Fatalf("Parse(Format(%q)) error = %v", src, err)
+		}
+		if !reflect.DeepEqual(normalized(formatted), normalized(prog)) {
+			t.Errorf("Parse(Format(%q)) = %+v, want %+v", src, formatted, prog)
+		}
+		again, err := Format(got)
+		if err != nil || again != got {
+			t.Errorf("Format(%q) not idempotent: %q, %v", src, again, err)
+		}
+	}
+}
+
+func TestFormatReportsErrors(t *testing.T) {
+	for _, src := range []string{"MOV 1", "FOO 1", "#define N 1\nFOO N", "JMP nowhere"} {
+		if _, err := Format(src); err == nil {
+			t.Errorf("Format(%q) error = nil", src)
+		}
+	}
+}
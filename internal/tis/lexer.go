@@ -0,0 +1,134 @@
+package tis
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// TokenType is the type of a lexed token
+type TokenType int
+
+const (
+	// TokenEOF marks end of source
+	TokenEOF TokenType = iota
+	// TokenNewline marks end of a line
+	TokenNewline
+	// TokenIdent is a word such as a mnemonic, register, node or label
+	TokenIdent
+	// TokenNumber is an integer literal
+	TokenNumber
+	// TokenComma separates operands
+	TokenComma
+	// TokenColon ends a label or splits a network register
+	TokenColon
+	// TokenComment is a comment running to end of line
+	TokenComment
+	// TokenIllegal is an unrecognized character
+	TokenIllegal
+)
+
+var tokenTypeNames = map[TokenType]string{
+	TokenEOF:     "end of file",
+	TokenNewline: "end of line",
+	TokenIdent:   "identifier",
+	TokenNumber:  "number",
+	TokenComma:   "','",
+	TokenColon:   "':'",
+	TokenComment: "comment",
+	TokenIllegal: "illegal character",
+}
+
+func (t TokenType) String() string {
+	if s, ok := tokenTypeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
+// Token is a lexed token and its position in source
+type Token struct {
+	Type TokenType
+	Text string
+	Pos  Pos
+}
+
+// Lexer splits TIS source into tokens
+type Lexer struct {
+	src  []rune
+	off  int
+	line int
+	col  int
+}
+
+// NewLexer creates a new lexer over source
+func NewLexer(src string) *Lexer {
+	return &Lexer{src: []rune(src), line: 1, col: 1}
+}
+
+// Next returns the next token in source
+func (l *Lexer) Next() Token {
+	// Skip whitespace other than newlines
+	for l.off < len(l.src) {
+		if r := l.src[l.off]; r == '\n' || !unicode.IsSpace(r) {
+			break
+		}
+		l.advance()
+	}
+
+	pos := Pos{Line: l.line, Col: l.col}
+	if l.off >= len(l.src) {
+		return Token{Type: TokenEOF, Pos: pos}
+	}
+
+	start := l.off
+	r := l.src[l.off]
+	switch {
+	case r == '\n':
+		l.advance()
+		return Token{Type: TokenNewline, Text: "\n", Pos: pos}
+	case r == ',':
+		l.advance()
+		return Token{Type: TokenComma, Text: ",", Pos: pos}
+	case r == ':':
+		l.advance()
+		return Token{Type: TokenColon, Text: ":", Pos: pos}
+	case r == '#':
+		for l.off < len(l.src) && l.src[l.off] != '\n' {
+			l.advance()
+		}
+		return Token{Type: TokenComment, Text: string(l.src[start:l.off]), Pos: pos}
+	case isDigit(r) || (r == '-' && l.off+1 < len(l.src) && isDigit(l.src[l.off+1])):
+		l.advance()
+		for l.off < len(l.src) && isDigit(l.src[l.off]) {
+			l.advance()
+		}
+		return Token{Type: TokenNumber, Text: string(l.src[start:l.off]), Pos: pos}
+	case isWordChar(r):
+		for l.off < len(l.src) && isWordChar(l.src[l.off]) {
+			l.advance()
+		}
+		return Token{Type: TokenIdent, Text: string(l.src[start:l.off]), Pos: pos}
+	default:
+		l.advance()
+		return Token{Type: TokenIllegal, Text: string(r), Pos: pos}
+	}
+}
+
+// advance moves lexer forward by one rune
+func (l *Lexer) advance() {
+	if l.src[l.off] == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	l.off++
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || isDigit(r) || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,98 @@
+package tis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Token
+	}{
+		{
+			name: "instruction",
+			src:  "MOV 10, ACC",
+			want: []Token{
+				{TokenIdent, "MOV", Pos{1, 1}},
+				{TokenNumber, "10", Pos{1, 5}},
+				{TokenComma, ",", Pos{1, 7}},
+				{TokenIdent, "ACC", Pos{1, 9}},
+				{TokenEOF, "", Pos{1, 12}},
+			},
+		},
+		{
+			name: "negative number",
+			src:  "ADD -5",
+			want: []Token{
+				{TokenIdent, "ADD", Pos{1, 1}},
+				{TokenNumber, "-5", Pos{1, 5}},
+				{TokenEOF, "", Pos{1, 7}},
+			},
+		},
+		{
+			name: "label and network register",
+			src:  "start: mov acc, misaka_2:r1",
+			want: []Token{
+				{TokenIdent, "start", Pos{1, 1}},
+				{TokenColon, ":", Pos{1, 6}},
+				{TokenIdent, "mov", Pos{1, 8}},
+				{TokenIdent, "acc", Pos{1, 12}},
+				{TokenComma, ",", Pos{1, 15}},
+				{TokenIdent, "misaka_2", Pos{1, 17}},
+				{TokenColon, ":", Pos{1, 25}},
+				{TokenIdent, "r1", Pos{1, 26}},
+				{TokenEOF, "", Pos{1, 28}},
+			},
+		},
+		{
+			name: "comment runs to end of line",
+			src:  "NOP # a, b: c\n#only",
+			want: []Token{
+				{TokenIdent, "NOP", Pos{1, 1}},
+				{TokenComment, "# a, b: c", Pos{1, 5}},
+				{TokenNewline, "\n", Pos{1, 14}},
+				{TokenComment, "#only", Pos{2, 1}},
+				{TokenEOF, "", Pos{2, 6}},
+			},
+		},
+		{
+			name: "blank lines",
+			src:  "\n  \t\nSWP",
+			want: []Token{
+				{TokenNewline, "\n", Pos{1, 1}},
+				{TokenNewline, "\n", Pos{2, 4}},
+				{TokenIdent, "SWP", Pos{3, 1}},
+				{TokenEOF, "", Pos{3, 4}},
+			},
+		},
+		{
+			name: "illegal characters",
+			src:  "ADD $1-",
+			want: []Token{
+				{TokenIdent, "ADD", Pos{1, 1}},
+				{TokenIllegal, "$", Pos{1, 5}},
+				{TokenNumber, "1", Pos{1, 6}},
+				{TokenIllegal, "-", Pos{1, 7}},
+				{TokenEOF, "", Pos{1, 8}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer(tt.src)
+			var got []Token
+			for {
+				tok := l.Next()
+				got = append(got, tok)
+				if tok.Type == TokenEOF {
+					break
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokens = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
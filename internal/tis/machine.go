@@ -0,0 +1,465 @@
+package tis
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jasmaa/misaka-net/internal/utils"
+)
+
+// ErrBlocked is returned by ports that cannot complete without waiting.
+// The instruction that hit it is retried on the next step.
+var ErrBlocked = errors.New("blocked")
+
+// Ports connects a machine to the rest of the network.
+// Ports either wait until they can complete or return ErrBlocked.
+type Ports interface {
+	// Read takes a value from network register R0-R3
+	Read(r Register) (int64, error)
+	// ReadAny takes a value from the first network register in order that has one
+	ReadAny(order []Register) (int64, Register, error)
+	// Write puts a value into network register on node and returns the register used.
+	// Register may be ANY.
+	Write(node string, r Register, v int64) (Register, error)
+	// Push pushes a value onto stack node
+	Push(node string, v int64) error
+	// Pop pops a value from stack node
+	Pop(node string) (int64, error)
+	// In takes a value from master input
+	In() (int64, error)
+	// Out puts a value into master output
+	Out(v int64) error
+}
+
+// networkRegisters lists registers read by ANY
+var networkRegisters = []Register{R0, R1, R2, R3}
+
+// Machine interprets a program one instruction at a time
+type Machine struct {
+	Acc int64
+	Bak int64
+	Ptr int
+
+	// LastIn and LastOut are the ports LAST reads from and writes to
+	LastIn  Operand
+	LastOut Operand
+
+	prog  *Program
+	model NumericModel
+	ports Ports
+
+	// Source value read by an instruction that has not finished yet
+	held bool
+	hold int64
+}
+
+// nilPort is what LAST refers to before ANY has been used
+var nilPort = Operand{Kind: LocalRegister, Register: NIL}
+
+// NewMachine creates a machine running prog
+func NewMachine(prog *Program, model NumericModel, ports Ports) *Machine {
+	m := &Machine{prog: prog, model: model, ports: ports}
+	m.Reset()
+	return m
+}
+
+// Program gets loaded program
+func (m *Machine) Program() *Program {
+	return m.prog
+}
+
+// Model gets numeric model
+func (m *Machine) Model() NumericModel {
+	return m.model
+}
+
+// Load resets machine and loads prog
+func (m *Machine) Load(prog *Program) {
+	m.prog = prog
+	m.Reset()
+}
+
+// Reset clears registers and moves back to first instruction
+func (m *Machine) Reset() {
+	m.Acc = 0
+	m.Bak = 0
+	m.Ptr = 0
+	m.LastIn = nilPort
+	m.LastOut = nilPort
+	m.held = false
+	m.hold = 0
+}
+
+// Instruction gets instruction that will run on next step
+func (m *Machine) Instruction() Instruction {
+	return m.prog.Instructions[m.Ptr]
+}
+
+// PortKind is the kind of network port an instruction can wait on
+type PortKind int
+
+// Supported port kinds
+const (
+	// ReadPort is a network register on this node. Register may be ANY.
+	ReadPort PortKind = iota
+	// WritePort is a network register on a peer program node. Register may be ANY.
+	WritePort
+	// PushPort is a stack node being pushed to
+	PushPort
+	// PopPort is a stack node being popped from
+	PopPort
+	// InPort is master input
+	InPort
+	// OutPort is master output
+	OutPort
+)
+
+// Port is a network port an instruction can wait on
+type Port struct {
+	Kind     PortKind
+	Node     string
+	Register Register
+}
+
+func (p Port) String() string {
+	switch p.Kind {
+	case ReadPort:
+		return p.Register.String()
+	case WritePort:
+		return fmt.Sprintf("%s:%s", p.Node, p.Register)
+	case PushPort:
+		return fmt.Sprintf("push %s", p.Node)
+	case PopPort:
+		return fmt.Sprintf("pop %s", p.Node)
+	case InPort:
+		return "IN"
+	case OutPort:
+		return "OUT"
+	default:
+		return fmt.Sprintf("Port(%d)", int(p.Kind))
+	}
+}
+
+// NextPort gets the network port current instruction needs next.
+// Returns false if the rest of the instruction does not touch the network.
+func (m *Machine) NextPort() (Port, bool) {
+	instr := m.Instruction()
+	switch instr.Op {
+	case POP:
+		return Port{Kind: PopPort, Node: instr.Args[0].Node}, true
+	case IN:
+		return Port{Kind: InPort}, true
+	case NOP, SWP, SAV, NEG, NOT, JMP, JEZ, JNZ, JGZ, JLZ:
+		return Port{}, false
+	}
+
+	if !m.held && len(instr.Args) > 0 {
+		src := instr.Args[0]
+		if src.Kind == LocalRegister && src.Register == LAST {
+			src = m.LastIn
+		}
+		if src.Kind == LocalRegister && (src.Register.IsNetwork() || src.Register == ANY) {
+			return Port{Kind: ReadPort, Register: src.Register}, true
+		}
+	}
+
+	switch instr.Op {
+	case MOV:
+		dst := instr.Args[1]
+		if dst.Kind == LocalRegister && dst.Register == LAST {
+			dst = m.LastOut
+		}
+		if dst.Kind == RemoteRegister {
+			return Port{Kind: WritePort, Node: dst.Node, Register: dst.Register}, true
+		}
+	case PUSH:
+		return Port{Kind: PushPort, Node: instr.Args[1].Node}, true
+	case OUT:
+		return Port{Kind: OutPort}, true
+	}
+	return Port{}, false
+}
+
+// Step runs current instruction.
+// If a port fails, the instruction is left unfinished and runs again on next step
+// without reading its source twice.
+func (m *Machine) Step() error {
+	instr := m.prog.Instructions[m.Ptr]
+
+	switch instr.Op {
+	case NOP:
+		// no-op
+	case MOV:
+		// Moves value from src to dst
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		err = m.dst(v, instr.Args[1])
+		if err != nil {
+			return err
+		}
+	case SWP:
+		// Swaps ACC and BAK
+		m.Acc, m.Bak = m.Bak, m.Acc
+	case SAV:
+		// Saves ACC to BAK
+		m.Bak = m.Acc
+	case ADD:
+		// Adds value in src to ACC
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Add(m.Acc, v)
+	case SUB:
+		// Subs value in src from ACC
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Sub(m.Acc, v)
+	case NEG:
+		// Negates ACC
+		m.Acc = m.model.Neg(m.Acc)
+	case MUL:
+		// Multiplies ACC by value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Mul(m.Acc, v)
+	case DIV:
+		// Divides ACC by value in src, truncating toward zero
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			return NewFault(instr, "division by zero")
+		}
+		m.Acc = m.model.Div(m.Acc, v)
+	case MOD:
+		// Sets ACC to remainder of ACC divided by value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			return NewFault(instr, "division by zero")
+		}
+		m.Acc = m.model.Mod(m.Acc, v)
+	case AND:
+		// Bitwise ANDs ACC with value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Normalize(m.Acc & v)
+	case OR:
+		// Bitwise ORs ACC with value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Normalize(m.Acc | v)
+	case XOR:
+		// Bitwise XORs ACC with value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		m.Acc = m.model.Normalize(m.Acc ^ v)
+	case NOT:
+		// Bitwise inverts ACC
+		m.Acc = m.model.Normalize(^m.Acc)
+	case SHL:
+		// Shifts ACC left by value in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return NewFault(instr, "negative shift %v", v)
+		}
+		m.Acc = m.model.Shl(m.Acc, v)
+	case SHR:
+		// Shifts ACC right by value in src, keeping sign
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return NewFault(instr, "negative shift %v", v)
+		}
+		m.Acc = m.model.Shr(m.Acc, v)
+	case JMP:
+		// Jumps unconditionally to label
+		m.jump(instr.Args[0].Value)
+		return nil
+	case JEZ:
+		// Jump if ACC equals zero
+		if m.Acc == 0 {
+			m.jump(instr.Args[0].Value)
+			return nil
+		}
+	case JNZ:
+		// Jump if ACC not zero
+		if m.Acc != 0 {
+			m.jump(instr.Args[0].Value)
+			return nil
+		}
+	case JGZ:
+		// Jump if ACC greater than zero
+		if m.Acc > 0 {
+			m.jump(instr.Args[0].Value)
+			return nil
+		}
+	case JLZ:
+		// Jump if ACC less than zero
+		if m.Acc < 0 {
+			m.jump(instr.Args[0].Value)
+			return nil
+		}
+	case JRO:
+		// Jumps by offset in src
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		n := len(m.prog.Instructions)
+		m.jump(utils.IntClamp(m.Ptr+int(utils.Int64Clamp(v, int64(-n), int64(n))), 0, n-1))
+		return nil
+	case PUSH:
+		// Pushes value from src across network
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		err = m.ports.Push(instr.Args[1].Node, v)
+		if err != nil {
+			return err
+		}
+	case POP:
+		// Pops value from stack node into dst
+		v, err := m.ports.Pop(instr.Args[0].Node)
+		if err != nil {
+			return err
+		}
+		err = m.dst(m.model.Normalize(v), instr.Args[1])
+		if err != nil {
+			return err
+		}
+	case IN:
+		// Moves value from master input into dst
+		v, err := m.ports.In()
+		if err != nil {
+			return err
+		}
+		err = m.dst(m.model.Normalize(v), instr.Args[0])
+		if err != nil {
+			return err
+		}
+	case OUT:
+		// Moves value from src into master output
+		v, err := m.src(instr.Args[0])
+		if err != nil {
+			return err
+		}
+		err = m.ports.Out(v)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("'%v' not a valid instruction", instr)
+	}
+
+	// Move instruction pointer
+	m.jump((m.Ptr + 1) % len(m.prog.Instructions))
+	return nil
+}
+
+// jump finishes current instruction and moves to instruction at ptr
+func (m *Machine) jump(ptr int) {
+	m.held = false
+	m.Ptr = ptr
+}
+
+// src gets value from literal or src register.
+// Values read from the network are held until the instruction finishes.
+func (m *Machine) src(src Operand) (int64, error) {
+	if m.held {
+		return m.hold, nil
+	}
+
+	switch src.Kind {
+	case Literal:
+		return m.model.Normalize(int64(src.Value)), nil
+	case LocalRegister:
+		switch src.Register {
+		case ACC:
+			return m.Acc, nil
+		case NIL:
+			return 0, nil
+		case R0, R1, R2, R3:
+			v, err := m.ports.Read(src.Register)
+			if err != nil {
+				return 0, err
+			}
+			return m.holdValue(v), nil
+		case ANY:
+			// Start after register read last so busy peers cannot starve the others
+			start := 0
+			if m.LastIn.Register.IsNetwork() {
+				start = m.LastIn.Register.Index() + 1
+			}
+			order := make([]Register, len(networkRegisters))
+			for i := range order {
+				order[i] = networkRegisters[(start+i)%len(networkRegisters)]
+			}
+			v, r, err := m.ports.ReadAny(order)
+			if err != nil {
+				return 0, err
+			}
+			m.LastIn = Operand{Kind: LocalRegister, Register: r}
+			return m.holdValue(v), nil
+		case LAST:
+			return m.src(m.LastIn)
+		}
+	}
+	return 0, fmt.Errorf("'%s' not a valid src", src)
+}
+
+// holdValue keeps value read from network until instruction finishes
+func (m *Machine) holdValue(v int64) int64 {
+	m.held = true
+	m.hold = m.model.Normalize(v)
+	return m.hold
+}
+
+// dst puts value into local or network dst register
+func (m *Machine) dst(v int64, dst Operand) error {
+	switch dst.Kind {
+	case LocalRegister:
+		switch dst.Register {
+		case ACC:
+			m.Acc = v
+			return nil
+		case NIL:
+			// no-op
+			return nil
+		case LAST:
+			return m.dst(v, m.LastOut)
+		}
+	case RemoteRegister:
+		r, err := m.ports.Write(dst.Node, dst.Register, v)
+		if err != nil {
+			return err
+		}
+		if dst.Register == ANY {
+			m.LastOut = Operand{Kind: RemoteRegister, Node: dst.Node, Register: r}
+		}
+		return nil
+	}
+	return fmt.Errorf("'%s' not a valid dst", dst)
+}
@@ -0,0 +1,276 @@
+package tis
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// anyPorts has values waiting in the network registers marked full
+type anyPorts struct {
+	full  map[Register]bool
+	reads map[Register]int
+}
+
+func (p *anyPorts) Read(r Register) (int64, error) { return 0, ErrBlocked }
+
+func (p *anyPorts) ReadAny(order []Register) (int64, Register, error) {
+	for _, r := range order {
+		if p.full[r] {
+			p.reads[r]++
+			return int64(r), r, nil
+		}
+	}
+	return 0, NIL, ErrBlocked
+}
+
+func (p *anyPorts) Write(node string, r Register, v int64) (Register, error) { return r, nil }
+func (p *anyPorts) Push(node string, v int64) error                          { return nil }
+func (p *anyPorts) Pop(node string) (int64, error)                           { return 0, nil }
+func (p *anyPorts) In() (int64, error)                                       { return 0, nil }
+func (p *anyPorts) Out(v int64) error                                        { return nil }
+
+func TestReadAnyIsRoundRobin(t *testing.T) {
+	tests := []struct {
+		name string
+		full []Register
+	}{
+		{"all", []Register{R0, R1, R2, R3}},
+		{"some", []Register{R1, R3}},
+		{"one", []Register{R2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := Parse("MOV ANY, ACC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ports := &anyPorts{full: make(map[Register]bool), reads: make(map[Register]int)}
+			for _, r := range tt.full {
+				ports.full[r] = true
+			}
+			m := NewMachine(prog, NumericInt64, ports)
+
+			// Registers that always have a value are read in turn
+			steps := 100 * len(tt.full)
+			var last Register = NIL
+			for i := 0; i < steps; i++ {
+				if err := m.Step(); err != nil {
+					t.Fatal(err)
+				}
+				r := m.LastIn.Register
+				if r == last && len(tt.full) > 1 {
+					t.Fatalf("step %d read %s twice in a row", i, r)
+				}
+				last = r
+			}
+			for _, r := range tt.full {
+				if ports.reads[r] != 100 {
+					t.Errorf("%s read %d times, want 100", r, ports.reads[r])
+				}
+			}
+		})
+	}
+}
+
+// valuePorts gives value to every read and records values sent
+type valuePorts struct {
+	value int64
+	sent  []int64
+}
+
+func (p *valuePorts) Read(r Register) (int64, error) { return p.value, nil }
+
+func (p *valuePorts) ReadAny(order []Register) (int64, Register, error) {
+	return p.value, order[0], nil
+}
+
+func (p *valuePorts) Write(node string, r Register, v int64) (Register, error) {
+	p.sent = append(p.sent, v)
+	return r, nil
+}
+
+func (p *valuePorts) Push(node string, v int64) error {
+	p.sent = append(p.sent, v)
+	return nil
+}
+
+func (p *valuePorts) Pop(node string) (int64, error) { return p.value, nil }
+func (p *valuePorts) In() (int64, error)             { return p.value, nil }
+
+func (p *valuePorts) Out(v int64) error {
+	p.sent = append(p.sent, v)
+	return nil
+}
+
+// runMachine steps through every instruction of src once
+func runMachine(t *testing.T, src string, model NumericModel, ports Ports) (*Machine, error) {
+	t.Helper()
+	prog, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMachine(prog, model, ports)
+	for range prog.Instructions {
+		if err := m.Step(); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func TestMachineArithmeticAndBitwise(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		port int64
+		want int64
+	}{
+		{"mul", "MOV 6, ACC\nMUL 7", 0, 42},
+		{"mul negative", "MOV -3, ACC\nMUL 5", 0, -15},
+		{"mul register", "MOV 4, ACC\nMUL R0", -2, -8},
+		{"div", "MOV 7, ACC\nDIV 2", 0, 3},
+		{"div truncates toward zero", "MOV -7, ACC\nDIV 2", 0, -3},
+		{"div register", "MOV 9, ACC\nDIV R2", 3, 3},
+		{"mod", "MOV 7, ACC\nMOD 3", 0, 1},
+		{"mod keeps sign of acc", "MOV -7, ACC\nMOD 3", 0, -1},
+		{"mod negative divisor", "MOV 7, ACC\nMOD -3", 0, 1},
+		{"and", "MOV 12, ACC\nAND 10", 0, 8},
+		{"and negative", "MOV -1, ACC\nAND 6", 0, 6},
+		{"or", "MOV 12, ACC\nOR 10", 0, 14},
+		{"xor", "MOV 12, ACC\nXOR 10", 0, 6},
+		{"xor register", "MOV 5, ACC\nXOR ANY", 5, 0},
+		{"not zero", "NOT", 0, -1},
+		{"not", "MOV 5, ACC\nNOT", 0, -6},
+		{"shl", "MOV 3, ACC\nSHL 4", 0, 48},
+		{"shl by zero", "MOV 3, ACC\nSHL 0", 0, 3},
+		{"shr", "MOV 48, ACC\nSHR 4", 0, 3},
+		{"shr keeps sign", "MOV -16, ACC\nSHR 2", 0, -4},
+		{"shr past width", "MOV -16, ACC\nSHR 70", 0, -1},
+		{"shr register", "MOV 64, ACC\nSHR R1", 6, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := runMachine(t, tt.src, NumericInt64, &valuePorts{value: tt.port})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Acc != tt.want {
+				t.Errorf("ACC = %d, want %d", m.Acc, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineFaults(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		port int64
+		want Fault
+	}{
+		{"div by zero", "MOV 5, ACC\nDIV 0", 0, Fault{Pos: Pos{2, 1}, Op: DIV, Message: "division by zero"}},
+		{"div by zero register", "MOV 5, ACC\n  DIV R0", 0, Fault{Pos: Pos{2, 3}, Op: DIV, Message: "division by zero"}},
+		{"mod by zero", "MOV 5, ACC\nMOD 0", 0, Fault{Pos: Pos{2, 1}, Op: MOD, Message: "division by zero"}},
+		{"mod by zero register", "MOV 5, ACC\nMOD ANY", 0, Fault{Pos: Pos{2, 1}, Op: MOD, Message: "division by zero"}},
+		{"shl negative", "MOV 5, ACC\nSHL -1", 0, Fault{Pos: Pos{2, 1}, Op: SHL, Message: "negative shift -1"}},
+		{"shr negative", "MOV 5, ACC\nSHR -2", 0, Fault{Pos: Pos{2, 1}, Op: SHR, Message: "negative shift -2"}},
+		{"shr negative register", "MOV 5, ACC\nSHR R3", -3, Fault{Pos: Pos{2, 1}, Op: SHR, Message: "negative shift -3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := runMachine(t, tt.src, NumericInt64, &valuePorts{value: tt.port})
+			var fault *Fault
+			if !errors.As(err, &fault) {
+				t.Fatalf("Step() error = %v, want fault", err)
+			}
+			if *fault != tt.want {
+				t.Errorf("fault = %+v, want %+v", *fault, tt.want)
+			}
+
+			// Faulting instruction does not finish
+			if m.Ptr != 1 || m.Acc != 5 {
+				t.Errorf("Ptr, ACC = %d, %d, want 1, 5", m.Ptr, m.Acc)
+			}
+		})
+	}
+}
+
+func TestFaultError(t *testing.T) {
+	f := NewFault(Instruction{Op: DIV, Pos: Pos{3, 5}}, "division by %s", "zero")
+	if got, want := f.Error(), "line 3, col 5, DIV fault: division by zero"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestMachineNumericBoundaries(t *testing.T) {
+	// Values come from the network as 64-bit integers whatever the model
+	values := []struct {
+		value             int64
+		tis, int32, int64 int64
+	}{
+		{math.MaxInt64, 999, -1, math.MaxInt64},
+		{math.MinInt64, -999, 0, math.MinInt64},
+		{1<<40 + 7, 999, 7, 1<<40 + 7},
+		{-5000, -999, -5000, -5000},
+	}
+	// Programs either keep the value in ACC or send it
+	progs := []struct {
+		name string
+		src  string
+		sent bool
+	}{
+		{"in", "IN ACC", false},
+		{"pop", "POP stack, ACC", false},
+		{"read", "MOV R0, ACC", false},
+		{"read any", "MOV ANY, ACC", false},
+		{"send", "MOV R1, misaka2:R0", true},
+		{"push", "PUSH R2, stack", true},
+		{"out", "OUT R3", true},
+	}
+	for _, prog := range progs {
+		for _, v := range values {
+			want := map[NumericModel]int64{NumericTIS: v.tis, NumericInt32: v.int32, NumericInt64: v.int64}
+			for _, model := range []NumericModel{NumericTIS, NumericInt32, NumericInt64} {
+				ports := &valuePorts{value: v.value}
+				m, err := runMachine(t, prog.src, model, ports)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := m.Acc
+				if prog.sent {
+					if len(ports.sent) != 1 {
+						t.Fatalf("%s: sent %v, want one value", prog.name, ports.sent)
+					}
+					got = ports.sent[0]
+				}
+				if got != want[model] {
+					t.Errorf("%s %d with %v model = %d, want %d", prog.name, v.value, model, got, want[model])
+				}
+			}
+		}
+	}
+}
+
+func TestMachineLiteralsUseNumericModel(t *testing.T) {
+	tests := []struct {
+		model NumericModel
+		want  []int64
+	}{
+		{NumericTIS, []int64{999, 999, 999}},
+		{NumericInt32, []int64{5000, -1, 5000}},
+		{NumericInt64, []int64{5000, 1<<32 - 1, 5000}},
+	}
+	for _, tt := range tests {
+		ports := &valuePorts{}
+		if _, err := runMachine(t, "MOV 5000, misaka2:R0\nPUSH 4294967295, stack\nOUT 5000", tt.model, ports); err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range tt.want {
+			if ports.sent[i] != want {
+				t.Errorf("%v model sent %v, want %v", tt.model, ports.sent, tt.want)
+				break
+			}
+		}
+	}
+}
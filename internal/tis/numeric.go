@@ -0,0 +1,143 @@
+package tis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// NumericModel is how a node stores values and does arithmetic
+type NumericModel int
+
+// Supported numeric models
+const (
+	// NumericTIS clamps values to -999..999 like TIS-100
+	NumericTIS NumericModel = iota
+	// NumericInt32 wraps values around like 32-bit integers
+	NumericInt32
+	// NumericInt64 saturates values at the 64-bit integer limits
+	NumericInt64
+)
+
+// TIS-100 value range
+const (
+	TISMin = -999
+	TISMax = 999
+)
+
+var numericModelNames = map[NumericModel]string{
+	NumericTIS:   "tis",
+	NumericInt32: "int32",
+	NumericInt64: "int64",
+}
+
+func (m NumericModel) String() string {
+	if s, ok := numericModelNames[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("NumericModel(%d)", int(m))
+}
+
+// ParseNumericModel finds numeric model by case-insensitive name.
+// An empty name is the TIS-100 model.
+func ParseNumericModel(s string) (NumericModel, error) {
+	if s == "" {
+		return NumericTIS, nil
+	}
+	s = strings.ToLower(s)
+	for m, name := range numericModelNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return NumericTIS, fmt.Errorf("'%s' not a valid numeric model", s)
+}
+
+// Normalize brings v into range of model
+func (m NumericModel) Normalize(v int64) int64 {
+	switch m {
+	case NumericTIS:
+		if v < TISMin {
+			return TISMin
+		}
+		if v > TISMax {
+			return TISMax
+		}
+	case NumericInt32:
+		return int64(int32(v))
+	}
+	return v
+}
+
+// Add adds a and b
+func (m NumericModel) Add(a, b int64) int64 {
+	s := a + b
+	if m != NumericInt32 && (a > 0 && b > 0 && s < 0 || a < 0 && b < 0 && s >= 0) {
+		return m.Normalize(saturate(a))
+	}
+	return m.Normalize(s)
+}
+
+// Sub subtracts b from a
+func (m NumericModel) Sub(a, b int64) int64 {
+	d := a - b
+	if m != NumericInt32 && (a >= 0 && b < 0 && d < 0 || a < 0 && b > 0 && d >= 0) {
+		return m.Normalize(saturate(a))
+	}
+	return m.Normalize(d)
+}
+
+// Neg negates a
+func (m NumericModel) Neg(a int64) int64 {
+	return m.Sub(0, a)
+}
+
+// Mul multiplies a by b
+func (m NumericModel) Mul(a, b int64) int64 {
+	p := a * b
+	if m != NumericInt32 && a != 0 && (p/a != b || a == -1 && b == math.MinInt64) {
+		return m.Normalize(saturate(a ^ b))
+	}
+	return m.Normalize(p)
+}
+
+// Div divides a by b, truncating toward zero. b must not be 0.
+func (m NumericModel) Div(a, b int64) int64 {
+	if m != NumericInt32 && a == math.MinInt64 && b == -1 {
+		return m.Normalize(math.MaxInt64)
+	}
+	return m.Normalize(a / b)
+}
+
+// Mod gets remainder of a divided by b with the sign of a. b must not be 0.
+func (m NumericModel) Mod(a, b int64) int64 {
+	return m.Normalize(a % b)
+}
+
+// Shl shifts a left by n bits. n must not be negative.
+func (m NumericModel) Shl(a, n int64) int64 {
+	if a == 0 {
+		return 0
+	}
+	if n >= 64 || a<<uint(n)>>uint(n) != a {
+		if m == NumericInt32 {
+			// Low bits are all shifted out
+			return m.Normalize(a << uint(n))
+		}
+		return m.Normalize(saturate(a))
+	}
+	return m.Normalize(a << uint(n))
+}
+
+// Shr shifts a right by n bits, keeping sign. n must not be negative.
+func (m NumericModel) Shr(a, n int64) int64 {
+	return m.Normalize(a >> uint(n))
+}
+
+// saturate gets the limit in direction of sign of v
+func saturate(v int64) int64 {
+	if v < 0 {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
@@ -0,0 +1,91 @@
+package tis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseNumericModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    NumericModel
+		wantErr bool
+	}{
+		{"", NumericTIS, false},
+		{"tis", NumericTIS, false},
+		{"Int32", NumericInt32, false},
+		{"INT64", NumericInt64, false},
+		{"float", NumericTIS, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNumericModel(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseNumericModel(%q) = %v, %v", tt.name, got, err)
+		}
+	}
+	if got := NumericModel(7).String(); got != "NumericModel(7)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestNumericModels(t *testing.T) {
+	// Each case gives the result under the tis, int32 and int64 models
+	tests := []struct {
+		name              string
+		op                func(m NumericModel) int64
+		tis, int32, int64 int64
+	}{
+		{"normalize in range", func(m NumericModel) int64 { return m.Normalize(-999) }, -999, -999, -999},
+		{"normalize above", func(m NumericModel) int64 { return m.Normalize(1000) }, 999, 1000, 1000},
+		{"normalize below", func(m NumericModel) int64 { return m.Normalize(-5000) }, -999, -5000, -5000},
+		{"normalize past 32 bits", func(m NumericModel) int64 { return m.Normalize(1<<32 + 5) }, 999, 5, 1<<32 + 5},
+		{"normalize min", func(m NumericModel) int64 { return m.Normalize(math.MinInt64) }, -999, 0, math.MinInt64},
+
+		{"add", func(m NumericModel) int64 { return m.Add(900, 99) }, 999, 999, 999},
+		{"add past tis", func(m NumericModel) int64 { return m.Add(900, 200) }, 999, 1100, 1100},
+		{"add past int32", func(m NumericModel) int64 { return m.Add(math.MaxInt32, 1) }, 999, math.MinInt32, math.MaxInt32 + 1},
+		{"add past int64", func(m NumericModel) int64 { return m.Add(math.MaxInt64, 1) }, 999, 0, math.MaxInt64},
+		{"add below int64", func(m NumericModel) int64 { return m.Add(math.MinInt64, -1) }, -999, -1, math.MinInt64},
+
+		{"sub past tis", func(m NumericModel) int64 { return m.Sub(-900, 200) }, -999, -1100, -1100},
+		{"sub below int32", func(m NumericModel) int64 { return m.Sub(math.MinInt32, 1) }, -999, math.MaxInt32, math.MinInt32 - 1},
+		{"sub below int64", func(m NumericModel) int64 { return m.Sub(math.MinInt64, 1) }, -999, -1, math.MinInt64},
+		{"sub past int64", func(m NumericModel) int64 { return m.Sub(0, math.MinInt64) }, 999, 0, math.MaxInt64},
+
+		{"neg", func(m NumericModel) int64 { return m.Neg(-999) }, 999, 999, 999},
+		{"neg int32 min", func(m NumericModel) int64 { return m.Neg(math.MinInt32) }, 999, math.MinInt32, -math.MinInt32},
+		{"neg int64 min", func(m NumericModel) int64 { return m.Neg(math.MinInt64) }, 999, 0, math.MaxInt64},
+
+		{"mul past tis", func(m NumericModel) int64 { return m.Mul(50, -50) }, -999, -2500, -2500},
+		{"mul past int32", func(m NumericModel) int64 { return m.Mul(1<<16, 1<<16) }, 999, 0, 1 << 32},
+		{"mul past int64", func(m NumericModel) int64 { return m.Mul(math.MaxInt64, 2) }, 999, -2, math.MaxInt64},
+		{"mul below int64", func(m NumericModel) int64 { return m.Mul(-1<<62, 4) }, -999, 0, math.MinInt64},
+		{"mul int64 min by -1", func(m NumericModel) int64 { return m.Mul(math.MinInt64, -1) }, 999, 0, math.MaxInt64},
+
+		{"div", func(m NumericModel) int64 { return m.Div(-7, 2) }, -3, -3, -3},
+		{"div int32 min by -1", func(m NumericModel) int64 { return m.Div(math.MinInt32, -1) }, 999, math.MinInt32, -math.MinInt32},
+		{"div int64 min by -1", func(m NumericModel) int64 { return m.Div(math.MinInt64, -1) }, 999, 0, math.MaxInt64},
+
+		{"mod", func(m NumericModel) int64 { return m.Mod(-7, 3) }, -1, -1, -1},
+		{"mod int64 min by -1", func(m NumericModel) int64 { return m.Mod(math.MinInt64, -1) }, 0, 0, 0},
+
+		{"shl past tis", func(m NumericModel) int64 { return m.Shl(1, 10) }, 999, 1024, 1024},
+		{"shl into int32 sign", func(m NumericModel) int64 { return m.Shl(1, 31) }, 999, math.MinInt32, 1 << 31},
+		{"shl past int64", func(m NumericModel) int64 { return m.Shl(3, 63) }, 999, 0, math.MaxInt64},
+		{"shl negative past width", func(m NumericModel) int64 { return m.Shl(-1, 64) }, -999, 0, math.MinInt64},
+		{"shl zero", func(m NumericModel) int64 { return m.Shl(0, 100) }, 0, 0, 0},
+
+		{"shr", func(m NumericModel) int64 { return m.Shr(-999, 1) }, -500, -500, -500},
+		{"shr int64 min", func(m NumericModel) int64 { return m.Shr(math.MinInt64, 63) }, -1, -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := map[NumericModel]int64{NumericTIS: tt.tis, NumericInt32: tt.int32, NumericInt64: tt.int64}
+			for _, m := range []NumericModel{NumericTIS, NumericInt32, NumericInt64} {
+				if got := tt.op(m); got != want[m] {
+					t.Errorf("%v: got %d, want %d", m, got, want[m])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package tis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jasmaa/misaka-net/internal/utils"
+)
+
+// operandSpec describes what an operand slot accepts
+type operandSpec int
+
+const (
+	// specSrc accepts a literal or a local register
+	specSrc operandSpec = iota
+	// specLocalDst accepts ACC or NIL
+	specLocalDst
+	// specDst accepts ACC, NIL, LAST or a network register
+	specDst
+	// specNode accepts a node name
+	specNode
+	// specLabel accepts a label
+	specLabel
+)
+
+var specNames = map[operandSpec]string{
+	specSrc:      "<VAL/SRC>",
+	specLocalDst: "<DST>",
+	specDst:      "<DST>",
+	specNode:     "<NODE>",
+	specLabel:    "<LABEL>",
+}
+
+// accepts checks if operand parsed from source could fill slot
+func (s operandSpec) accepts(arg Operand) bool {
+	switch s {
+	case specSrc:
+		return arg.Kind == Literal || arg.Kind == LocalRegister
+	case specLocalDst:
+		return arg.Kind == LocalRegister && (arg.Register == ACC || arg.Register == NIL)
+	case specDst:
+		switch arg.Kind {
+		case LocalRegister:
+			return arg.Register == ACC || arg.Register == NIL || arg.Register == LAST
+		case RemoteRegister:
+			return arg.Register.IsNetwork() || arg.Register == ANY
+		}
+	case specNode:
+		return arg.Kind == NodeName
+	case specLabel:
+		return arg.Kind == LabelRef
+	}
+	return false
+}
+
+// instrSpecs maps opcodes to their operand slots
+var instrSpecs = map[Opcode][]operandSpec{
+	NOP:  {},
+	MOV:  {specSrc, specDst},
+	SWP:  {},
+	SAV:  {},
+	ADD:  {specSrc},
+	SUB:  {specSrc},
+	NEG:  {},
+	JMP:  {specLabel},
+	JEZ:  {specLabel},
+	JNZ:  {specLabel},
+	JGZ:  {specLabel},
+	JLZ:  {specLabel},
+	JRO:  {specSrc},
+	PUSH: {specSrc, specNode},
+	POP:  {specNode, specLocalDst},
+	IN:   {specLocalDst},
+	OUT:  {specSrc},
+	MUL:  {specSrc},
+	DIV:  {specSrc},
+	MOD:  {specSrc},
+	AND:  {specSrc},
+	OR:   {specSrc},
+	XOR:  {specSrc},
+	NOT:  {},
+	SHL:  {specSrc},
+	SHR:  {specSrc},
+}
+
+// Parse parses TIS source into a program.
+// Returned errors are Diagnostics listing every problem found.
+func Parse(src string) (*Program, error) {
+	return newParser(src).parse()
+}
+
+// newParser creates a parser at the start of src
+func newParser(src string) *parser {
+	p := &parser{lexer: NewLexer(src)}
+	p.next()
+	return p
+}
+
+// parse parses every line of source
+func (p *parser) parse() (*Program, error) {
+	prog := &Program{Labels: make(map[string]int)}
+	for {
+		instr := p.parseLine()
+		if instr.Label != "" {
+			if _, ok := prog.Labels[instr.Label]; ok {
+				p.report(instr.LabelPos, len(instr.Label), CodeDuplicateLabel, "",
+					"cannot repeat label '%s'", instr.Label)
+			} else {
+				prog.Labels[instr.Label] = len(prog.Instructions)
+			}
+		}
+		prog.Instructions = append(prog.Instructions, instr)
+
+		if p.tok.Type == TokenEOF {
+			break
+		}
+		p.next()
+	}
+
+	// Resolve labels
+	labels := make([]string, 0, len(prog.Labels))
+	for label := range prog.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for i := range prog.Instructions {
+		for j := range prog.Instructions[i].Args {
+			arg := &prog.Instructions[i].Args[j]
+			if arg.Kind != LabelRef {
+				continue
+			}
+			target, ok := prog.Labels[arg.Label]
+			if !ok && p.partial {
+				continue
+			}
+			if !ok {
+				p.report(arg.Pos, len(arg.Label), CodeUndeclaredLabel, suggest(arg.Label, labels),
+					"label '%s' was not declared", arg.Label)
+				continue
+			}
+			arg.Value = target
+		}
+	}
+
+	if p.diags.HasErrors() {
+		p.diags.Sort()
+		return nil, p.diags
+	}
+	return prog, nil
+}
+
+// parser holds state for parsing a token stream
+type parser struct {
+	lexer *Lexer
+	tok   Token
+	peek  *Token
+	diags Diagnostics
+
+	// constants are #define names parsed as Constant operands
+	constants map[string]bool
+	// partial allows labels declared outside the parsed lines
+	partial bool
+}
+
+// next advances to the next token
+func (p *parser) next() {
+	if p.peek != nil {
+		p.tok = *p.peek
+		p.peek = nil
+		return
+	}
+	p.tok = p.lexer.Next()
+}
+
+// lookahead returns the token after the current one
+func (p *parser) lookahead() Token {
+	if p.peek == nil {
+		t := p.lexer.Next()
+		p.peek = &t
+	}
+	return *p.peek
+}
+
+// atLineEnd checks if current token ends a line
+func (p *parser) atLineEnd() bool {
+	return p.tok.Type == TokenNewline || p.tok.Type == TokenEOF
+}
+
+// skipLine discards tokens up to line end
+func (p *parser) skipLine() {
+	for !p.atLineEnd() {
+		p.next()
+	}
+}
+
+// parseLine parses a single line and leaves the parser at its line end.
+// Lines with errors are reported and parsed as NOP.
+func (p *parser) parseLine() Instruction {
+	instr := Instruction{Op: NOP, Pos: p.tok.Pos, Blank: true}
+
+	// <Label>:
+	if p.tok.Type == TokenIdent && p.lookahead().Type == TokenColon {
+		instr.Label = strings.ToUpper(p.tok.Text)
+		instr.LabelPos = p.tok.Pos
+		p.next()
+		p.next()
+	}
+
+	// <Mnemonic> <Operands>
+	if p.tok.Type == TokenIdent {
+		op, ok := LookupOpcode(p.tok.Text)
+		if !ok {
+			p.reportToken(p.tok, CodeUnknownInstruction, suggest(strings.ToUpper(p.tok.Text), opcodeMnemonics()),
+				"'%s' not a valid instruction", p.tok.Text)
+			p.skipLine()
+			return Instruction{Op: NOP, Pos: instr.Pos, Label: instr.Label, LabelPos: instr.LabelPos}
+		}
+		instr.Op = op
+		instr.Pos = p.tok.Pos
+		instr.Blank = false
+		p.next()
+
+		specs := instrSpecs[op]
+		for i, spec := range specs {
+			if i > 0 {
+				if p.tok.Type != TokenComma {
+					if p.atLineEnd() {
+						p.reportToken(p.tok, CodeMissingOperand, "",
+							"%s expects %d operands", op, len(specs))
+					} else {
+						p.reportToken(p.tok, CodeExpectedComma, "insert ',' between operands",
+							"expected ',' after operand %d of %s", i, op)
+					}
+					p.skipLine()
+					return Instruction{Op: NOP, Pos: instr.Pos, Label: instr.Label, LabelPos: instr.LabelPos}
+				}
+				p.next()
+			}
+			arg, ok := p.parseOperand(op, spec)
+			if !ok {
+				p.skipLine()
+				return Instruction{Op: NOP, Pos: instr.Pos, Label: instr.Label, LabelPos: instr.LabelPos}
+			}
+			instr.Args = append(instr.Args, arg)
+		}
+	}
+
+	// #<Comment>
+	if p.tok.Type == TokenComment {
+		instr.Comment = p.tok.Text
+		p.next()
+	}
+
+	if !p.atLineEnd() {
+		switch p.tok.Type {
+		case TokenIllegal:
+			p.reportToken(p.tok, CodeIllegalCharacter, "", "illegal character '%s'", p.tok.Text)
+		case TokenComma:
+			p.reportToken(p.tok, CodeUnexpectedToken, fmt.Sprintf("%s takes %d operands", instr.Op, len(instrSpecs[instr.Op])),
+				"unexpected '%s'", p.tok.Text)
+		default:
+			p.reportToken(p.tok, CodeUnexpectedToken, "", "unexpected '%s'", p.tok.Text)
+		}
+		p.skipLine()
+		return Instruction{Op: NOP, Pos: instr.Pos, Label: instr.Label, LabelPos: instr.LabelPos, Comment: instr.Comment}
+	}
+	return instr
+}
+
+// parseOperand parses an operand accepted by spec
+func (p *parser) parseOperand(op Opcode, spec operandSpec) (Operand, bool) {
+	tok := p.tok
+	arg := Operand{Pos: tok.Pos}
+
+	switch tok.Type {
+	case TokenNumber:
+		if spec != specSrc {
+			break
+		}
+		v, err := strconv.Atoi(tok.Text)
+		if err != nil {
+			p.reportToken(tok, CodeInvalidNumber, "", "'%s' not a valid number", tok.Text)
+			return arg, false
+		}
+		arg.Kind = Literal
+		arg.Value = v
+		p.next()
+		return arg, true
+	case TokenIdent:
+		if p.constants[tok.Text] && p.lookahead().Type != TokenColon {
+			arg.Kind = Constant
+			arg.Label = tok.Text
+			p.next()
+			return arg, true
+		}
+		switch spec {
+		case specNode:
+			arg.Kind = NodeName
+			arg.Node = tok.Text
+			p.next()
+			return arg, true
+		case specLabel:
+			arg.Kind = LabelRef
+			arg.Label = strings.ToUpper(tok.Text)
+			p.next()
+			return arg, true
+		}
+
+		// <NODE>:<REGISTER>
+		if p.lookahead().Type == TokenColon {
+			if spec != specDst {
+				break
+			}
+			p.next()
+			p.next()
+			r, ok := LookupRegister(p.tok.Text)
+			if p.tok.Type != TokenIdent || !ok || !(r.IsNetwork() || r == ANY) {
+				suggestion := suggest(strings.ToUpper(p.tok.Text), append(networkRegisterNames(), ANY.String()))
+				if ok && r == LAST {
+					suggestion = "write to the last peer register with LAST"
+				}
+				p.reportToken(p.tok, CodeInvalidOperand, suggestion, "'%s' not a valid network register", p.tok.Text)
+				return arg, false
+			}
+			arg.Kind = RemoteRegister
+			arg.Node = tok.Text
+			arg.Register = r
+			p.next()
+			return arg, true
+		}
+
+		r, ok := LookupRegister(tok.Text)
+		if ok && (spec == specSrc || r == ACC || r == NIL || (spec == specDst && r == LAST)) {
+			arg.Kind = LocalRegister
+			arg.Register = r
+			p.next()
+			return arg, true
+		}
+	}
+
+	if p.atLineEnd() {
+		p.reportToken(tok, CodeMissingOperand, "", "%s expects %s", op, specNames[spec])
+		return arg, false
+	}
+	if tok.Type == TokenIllegal {
+		p.reportToken(tok, CodeIllegalCharacter, "", "illegal character '%s'", tok.Text)
+		return arg, false
+	}
+	p.reportToken(tok, CodeInvalidOperand, p.suggestOperand(tok, spec),
+		"'%s' not a valid %s for %s", tok.Text, specNames[spec], op)
+	return arg, false
+}
+
+// suggestOperand suggests a fix for an invalid operand
+func (p *parser) suggestOperand(tok Token, spec operandSpec) string {
+	switch spec {
+	case specSrc:
+		if tok.Type == TokenIdent {
+			return suggest(strings.ToUpper(tok.Text), []string{"ACC", "NIL", "R0", "R1", "R2", "R3", "ANY", "LAST"})
+		}
+	case specLocalDst:
+		if r, ok := LookupRegister(tok.Text); ok && (r.IsNetwork() || r.IsPseudoPort()) {
+			return "network registers are read-only on this node; use ACC or NIL"
+		}
+		return suggest(strings.ToUpper(tok.Text), []string{"ACC", "NIL"})
+	case specDst:
+		if r, ok := LookupRegister(tok.Text); ok && (r.IsNetwork() || r == ANY) {
+			return fmt.Sprintf("write to a peer with <NODE>:%s", r)
+		}
+		if tok.Type == TokenNumber {
+			return "a literal cannot be a destination"
+		}
+		return suggest(strings.ToUpper(tok.Text), []string{"ACC", "NIL", "LAST"})
+	case specNode:
+		return "expected the name of a node"
+	case specLabel:
+		return "expected the name of a label"
+	}
+	return ""
+}
+
+// report records an error diagnostic spanning width columns from pos
+func (p *parser) report(pos Pos, width int, code, suggestion string, format string, a ...interface{}) {
+	p.diags = append(p.diags, Diagnostic{
+		Line:       pos.Line,
+		Col:        pos.Col,
+		EndCol:     pos.Col + utils.IntMax(width, 1),
+		Severity:   SeverityError,
+		Code:       code,
+		Message:    fmt.Sprintf(format, a...),
+		Suggestion: suggestion,
+	})
+}
+
+// reportToken records an error diagnostic spanning tok
+func (p *parser) reportToken(tok Token, code, suggestion string, format string, a ...interface{}) {
+	p.report(tok.Pos, utf8.RuneCountInString(tok.Text), code, suggestion, format, a...)
+}
+
+// suggest creates a did-you-mean suggestion for s
+func suggest(s string, candidates []string) string {
+	if c, ok := utils.ClosestString(s, candidates, 2); ok && c != s {
+		return fmt.Sprintf("did you mean %s?", c)
+	}
+	return ""
+}
+
+// opcodeMnemonics lists all mnemonics in opcode order
+func opcodeMnemonics() []string {
+	names := make([]string, len(opcodeNames))
+	for op, name := range opcodeNames {
+		names[op] = name
+	}
+	return names
+}
+
+// networkRegisterNames lists network register names
+func networkRegisterNames() []string {
+	return []string{R0.String(), R1.String(), R2.String(), R3.String()}
+}
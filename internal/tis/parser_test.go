@@ -0,0 +1,123 @@
+package tis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withoutPos clears source positions so instructions can be compared by content
+func withoutPos(instrs []Instruction) []Instruction {
+	for i := range instrs {
+		instrs[i].Pos = Pos{}
+		instrs[i].LabelPos = Pos{}
+		for j := range instrs[i].Args {
+			instrs[i].Args[j].Pos = Pos{}
+		}
+	}
+	return instrs
+}
+
+func TestParseOperands(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Operand
+	}{
+		{"literal", "MOV 42, ACC", []Operand{{Kind: Literal, Value: 42}, {Kind: LocalRegister, Register: ACC}}},
+		{"negative literal", "ADD -7", []Operand{{Kind: Literal, Value: -7}}},
+		{"local registers", "mov r3, nil", []Operand{{Kind: LocalRegister, Register: R3}, {Kind: LocalRegister, Register: NIL}}},
+		{"pseudo-ports", "MOV ANY, LAST", []Operand{{Kind: LocalRegister, Register: ANY}, {Kind: LocalRegister, Register: LAST}}},
+		{"remote register", "MOV ACC, misaka2:r1", []Operand{{Kind: LocalRegister, Register: ACC}, {Kind: RemoteRegister, Node: "misaka2", Register: R1}}},
+		{"remote ANY", "MOV 1, misaka2:ANY", []Operand{{Kind: Literal, Value: 1}, {Kind: RemoteRegister, Node: "misaka2", Register: ANY}}},
+		{"node", "PUSH ACC, stack", []Operand{{Kind: LocalRegister, Register: ACC}, {Kind: NodeName, Node: "stack"}}},
+		{"node and local dst", "POP stack, NIL", []Operand{{Kind: NodeName, Node: "stack"}, {Kind: LocalRegister, Register: NIL}}},
+		{"label", "l: JMP l", []Operand{{Kind: LabelRef, Label: "L", Value: 0}}},
+		{"none", "SWP", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := withoutPos(prog.Instructions)[0].Args
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabelsAndComments(t *testing.T) {
+	src := "start:\n  ADD 1 # one\n\nLoop: JGZ START #back\n# note\nJMP loop"
+	prog, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Instruction{
+		{Op: NOP, Blank: true, Label: "START"},
+		{Op: ADD, Args: []Operand{{Kind: Literal, Value: 1}}, Comment: "# one"},
+		{Op: NOP, Blank: true},
+		{Op: JGZ, Args: []Operand{{Kind: LabelRef, Label: "START", Value: 0}}, Label: "LOOP", Comment: "#back"},
+		{Op: NOP, Blank: true, Comment: "# note"},
+		{Op: JMP, Args: []Operand{{Kind: LabelRef, Label: "LOOP", Value: 3}}},
+	}
+	if got := withoutPos(prog.Instructions); !reflect.DeepEqual(got, want) {
+		t.Errorf("Instructions = %+v, want %+v", got, want)
+	}
+	if wantLabels := map[string]int{"START": 0, "LOOP": 3}; !reflect.DeepEqual(prog.Labels, wantLabels) {
+		t.Errorf("Labels = %v, want %v", prog.Labels, wantLabels)
+	}
+}
+
+func TestParseInstructionPositions(t *testing.T) {
+	prog, err := Parse("  l:  MOV 1,  misaka2:R0\nNOP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	instr := prog.Instructions[0]
+	if instr.LabelPos != (Pos{1, 3}) || instr.Pos != (Pos{1, 7}) {
+		t.Errorf("LabelPos, Pos = %v, %v, want 1:3, 1:7", instr.LabelPos, instr.Pos)
+	}
+	if instr.Args[0].Pos != (Pos{1, 11}) || instr.Args[1].Pos != (Pos{1, 15}) {
+		t.Errorf("operand positions = %v, %v, want 1:11, 1:15", instr.Args[0].Pos, instr.Args[1].Pos)
+	}
+	if got := prog.Instructions[1].Pos; got != (Pos{2, 1}) {
+		t.Errorf("second line Pos = %v, want 2:1", got)
+	}
+}
+
+func TestParseRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unknown instruction", "FOO 1", "'FOO' not a valid instruction"},
+		{"missing operand", "ADD", "ADD expects <VAL/SRC>"},
+		{"missing second operand", "MOV 1", "MOV expects 2 operands"},
+		{"missing comma", "MOV 1 ACC", "expected ',' after operand 1 of MOV"},
+		{"extra operand", "ADD 1, 2", "unexpected ','"},
+		{"literal destination", "MOV 1, 2", "'2' not a valid <DST> for MOV"},
+		{"network register destination", "IN R0", "'R0' not a valid <DST> for IN"},
+		{"remote source", "ADD misaka2:R0", "'misaka2' not a valid <VAL/SRC> for ADD"},
+		{"bad network register", "MOV 1, misaka2:ACC", "'ACC' not a valid network register"},
+		{"number too large", "ADD 99999999999999999999", "not a valid number"},
+		{"illegal character", "ADD 1 $", "illegal character '$'"},
+		{"undeclared label", "JMP nowhere", "label 'NOWHERE' was not declared"},
+		{"duplicate label", "a: NOP\nA: NOP", "cannot repeat label 'A'"},
+		{"trailing token", "NEG ACC", "unexpected 'ACC'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, err := Parse(tt.src)
+			if err == nil {
+				t.Fatalf("Parse() = %+v, want error", prog)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package tis
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Diagnostic codes for preprocessing
+const (
+	CodeBadDirective      = "bad-directive"
+	CodeDuplicateMacro    = "duplicate-macro"
+	CodeUnterminatedMacro = "unterminated-macro"
+	CodeMacroArguments    = "macro-arguments"
+	CodeMacroRecursion    = "macro-recursion"
+	CodeExpansionLimit    = "expansion-limit"
+	CodeIncludeFailed     = "include-failed"
+	CodeIncludeCycle      = "include-cycle"
+)
+
+// maxExpansionDepth bounds nested macro expansion and includes
+const maxExpansionDepth = 16
+
+// maxExpandedLines bounds the lines produced by macro expansion
+const maxExpandedLines = 1 << 16
+
+var (
+	defineRe  = regexp.MustCompile(`^#define\s+(\w+)(?:\s+([^#]*?))?\s*(#.*)?$`)
+	macroRe   = regexp.MustCompile(`^#macro\s+(\w+)\s*(?:\(\s*([\w\s,]*?)\s*\))?\s*(#.*)?$`)
+	endRe     = regexp.MustCompile(`^#endmacro\s*(#.*)?$`)
+	includeRe = regexp.MustCompile(`^#include\s+"([^"]+)"\s*(#.*)?$`)
+)
+
+// isDirective checks if trimmed line is a directive.
+// Other lines starting with # are comments.
+func isDirective(trimmed string) bool {
+	return defineRe.MatchString(trimmed) || macroRe.MatchString(trimmed) ||
+		endRe.MatchString(trimmed) || includeRe.MatchString(trimmed)
+}
+
+// IncludeFunc reads the source of an included file
+type IncludeFunc func(name string) (string, error)
+
+// DirIncluder reads included files relative to dir.
+// Absolute paths and paths that leave dir are rejected.
+func DirIncluder(dir string) IncludeFunc {
+	return func(name string) (string, error) {
+		rel := filepath.FromSlash(name)
+		if filepath.IsAbs(rel) || strings.HasPrefix(name, "/") || filepath.VolumeName(rel) != "" {
+			return "", errors.New("absolute paths are not allowed")
+		}
+		rel = filepath.Clean(rel)
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", errors.New("path leaves include directory")
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// PreprocessOptions configures preprocessing
+type PreprocessOptions struct {
+	// File names the source in diagnostics
+	File string
+	// Include reads included files. Includes fail if nil.
+	Include IncludeFunc
+}
+
+// Origin is the original file and line a preprocessed line came from
+type Origin struct {
+	File string
+	Line int
+	// Top is the line in the top-level source, which is the #include line for included files
+	Top int
+}
+
+// Source is preprocessed source with a map back to original lines
+type Source struct {
+	Text    string
+	Origins []Origin
+}
+
+// MapDiagnostics maps diagnostics on preprocessed source back to original lines
+func (s *Source) MapDiagnostics(diags Diagnostics) Diagnostics {
+	mapped := make(Diagnostics, len(diags))
+	for i, d := range diags {
+		if d.Line >= 1 && d.Line <= len(s.Origins) {
+			o := s.Origins[d.Line-1]
+			d.File = o.File
+			d.Line = o.Line
+		}
+		mapped[i] = d
+	}
+	return mapped
+}
+
+// Preprocess expands constants, macros and includes in source.
+//
+//	#define NAME VALUE        replaces identifier NAME with VALUE
+//	#macro NAME(P1, P2)       starts a macro with parameters P1 and P2
+//	#endmacro                 ends a macro
+//	#include "FILE"           inserts the preprocessed contents of FILE
+//	NAME A1, A2               expands macro NAME with arguments A1 and A2
+//
+// Labels declared inside a macro body are renamed on each expansion so a
+// macro can be used many times in one program.
+func Preprocess(src string, opts PreprocessOptions) (*Source, error) {
+	pp := newPreprocessor(opts)
+	pp.file(src, opts.File, 1, 0, nil)
+	return pp.source()
+}
+
+// Assemble preprocesses and parses source.
+// Diagnostics refer to lines in the original source.
+func Assemble(src string, opts PreprocessOptions) (*Program, error) {
+	source, err := Preprocess(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	return source.Parse()
+}
+
+// Parse parses preprocessed source.
+// Diagnostics refer to lines in the original source and instruction
+// positions to lines in the top-level source.
+func (s *Source) Parse() (*Program, error) {
+	prog, err := Parse(s.Text)
+	if err != nil {
+		if diags, ok := AsDiagnostics(err); ok {
+			return nil, s.MapDiagnostics(diags)
+		}
+		return nil, err
+	}
+	for i := range prog.Instructions {
+		instr := &prog.Instructions[i]
+		instr.Pos = s.mapPos(instr.Pos)
+		if instr.Label != "" {
+			instr.LabelPos = s.mapPos(instr.LabelPos)
+		}
+		for j := range instr.Args {
+			instr.Args[j].Pos = s.mapPos(instr.Args[j].Pos)
+		}
+	}
+	return prog, nil
+}
+
+// mapPos maps position in preprocessed source to line in top-level source
+func (s *Source) mapPos(pos Pos) Pos {
+	if pos.Line >= 1 && pos.Line <= len(s.Origins) {
+		pos.Line = s.Origins[pos.Line-1].Top
+	}
+	return pos
+}
+
+// macro is a parameterized sequence of lines
+type macro struct {
+	name   string
+	params []string
+	body   []string
+	origin Origin
+}
+
+// invocation is a macro being expanded and the span of the top-level line that uses it
+type invocation struct {
+	macro      *macro
+	col, width int
+}
+
+// preprocessor holds state for preprocessing
+type preprocessor struct {
+	opts       PreprocessOptions
+	defines    map[string]string
+	macros     map[string]*macro
+	expansions int
+	expanded   int
+
+	lines   []string
+	origins []Origin
+	diags   Diagnostics
+}
+
+// newPreprocessor creates a preprocessor with no definitions
+func newPreprocessor(opts PreprocessOptions) *preprocessor {
+	return &preprocessor{
+		opts:    opts,
+		defines: make(map[string]string),
+		macros:  make(map[string]*macro),
+	}
+}
+
+// fork creates a preprocessor sharing definitions made so far but no output
+func (pp *preprocessor) fork() *preprocessor {
+	f := newPreprocessor(pp.opts)
+	for k, v := range pp.defines {
+		f.defines[k] = v
+	}
+	for k, v := range pp.macros {
+		f.macros[k] = v
+	}
+	f.expansions = pp.expansions
+	f.expanded = pp.expanded
+	return f
+}
+
+// source gets preprocessed output or diagnostics
+func (pp *preprocessor) source() (*Source, error) {
+	if pp.diags.HasErrors() {
+		pp.diags.Sort()
+		return nil, pp.diags
+	}
+	return &Source{Text: strings.Join(pp.lines, "\n"), Origins: pp.origins}, nil
+}
+
+// file preprocesses the lines of a single file starting at firstLine.
+// Lines of included files all come from top, the top-level #include line.
+func (pp *preprocessor) file(src, name string, firstLine, top int, stack []string) {
+	var current *macro
+	for i, line := range strings.Split(src, "\n") {
+		origin := Origin{File: name, Line: firstLine + i, Top: top}
+		if top == 0 {
+			origin.Top = origin.Line
+		}
+		trimmed := strings.TrimSpace(line)
+		col := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+
+		if current != nil {
+			if endRe.MatchString(trimmed) {
+				pp.macros[strings.ToUpper(current.name)] = current
+				current = nil
+			} else if isDirective(trimmed) {
+				pp.report(origin, col, len(trimmed), CodeBadDirective, "directives cannot be used inside macro '%s'", current.name)
+			} else {
+				current.body = append(current.body, line)
+			}
+			continue
+		}
+
+		if m := defineRe.FindStringSubmatch(trimmed); m != nil {
+			pp.defines[m[1]] = pp.substitute(strings.TrimSpace(m[2]), pp.defines)
+		} else if m := macroRe.FindStringSubmatch(trimmed); m != nil {
+			if _, ok := pp.macros[strings.ToUpper(m[1])]; ok {
+				pp.report(origin, col, len(trimmed), CodeDuplicateMacro, "cannot redefine macro '%s'", m[1])
+			}
+			current = &macro{name: m[1], params: splitArgs(m[2]), origin: origin}
+		} else if endRe.MatchString(trimmed) {
+			pp.report(origin, col, len(trimmed), CodeBadDirective, "#endmacro without #macro")
+		} else if m := includeRe.FindStringSubmatch(trimmed); m != nil {
+			pp.include(m[1], origin, col, len(trimmed), stack)
+		} else {
+			pp.expandLine(line, origin, nil)
+		}
+	}
+
+	if current != nil {
+		pp.report(current.origin, 1, 1, CodeUnterminatedMacro, "macro '%s' has no #endmacro", current.name)
+	}
+}
+
+// include preprocesses an included file in place
+func (pp *preprocessor) include(name string, origin Origin, col, width int, stack []string) {
+	for _, s := range stack {
+		if s == name {
+			pp.report(origin, col, width, CodeIncludeCycle, "'%s' includes itself", name)
+			return
+		}
+	}
+	if len(stack) >= maxExpansionDepth {
+		pp.report(origin, col, width, CodeIncludeCycle, "includes nested too deeply")
+		return
+	}
+	if pp.opts.Include == nil {
+		pp.report(origin, col, width, CodeIncludeFailed, "cannot include '%s': includes are not enabled", name)
+		return
+	}
+	src, err := pp.opts.Include(name)
+	if err != nil {
+		pp.report(origin, col, width, CodeIncludeFailed, "cannot include '%s': %s", name, err.Error())
+		return
+	}
+	pp.file(strings.TrimSuffix(src, "\n"), name, 1, origin.Top, append(stack, name))
+}
+
+// expandLine substitutes constants in line and expands it if it invokes a macro.
+// stack holds the macros being expanded. It reports false if expansion failed.
+func (pp *preprocessor) expandLine(line string, origin Origin, stack []invocation) bool {
+	line = pp.substitute(line, pp.defines)
+
+	label, name, args, namePos, ok := splitInvocation(line)
+	if !ok {
+		pp.emit(line, origin)
+		return true
+	}
+	m, ok := pp.macros[strings.ToUpper(name)]
+	if !ok {
+		pp.emit(line, origin)
+		return true
+	}
+
+	// Errors inside a macro are reported on the macro used by the top-level line
+	col, width := namePos.Col, len(name)
+	if len(stack) > 0 {
+		col, width = stack[0].col, stack[0].width
+	}
+
+	for i, outer := range stack {
+		if outer.macro == m {
+			cycle := make([]string, 0, len(stack)-i+1)
+			for _, s := range stack[i:] {
+				cycle = append(cycle, s.macro.name)
+			}
+			pp.report(origin, col, width, CodeMacroRecursion,
+				"macro '%s' expands itself (%s -> %s)", m.name, strings.Join(cycle, " -> "), m.name)
+			return false
+		}
+	}
+	if len(stack) >= maxExpansionDepth {
+		pp.report(origin, col, width, CodeMacroRecursion, "macro '%s' nested too deeply", m.name)
+		return false
+	}
+	if len(args) != len(m.params) {
+		pp.report(origin, col, width, CodeMacroArguments,
+			"macro '%s' takes %d arguments but got %d", m.name, len(m.params), len(args))
+		return false
+	}
+	if pp.expanded+len(m.body) > maxExpandedLines {
+		// Report only the first expansion over the limit
+		if pp.expanded <= maxExpandedLines {
+			pp.report(origin, col, width, CodeExpansionLimit,
+				"macro expansion produces more than %d lines", maxExpandedLines)
+			pp.expanded = maxExpandedLines + 1
+		}
+		return false
+	}
+	pp.expanded += len(m.body)
+
+	// Bind parameters and rename local labels
+	pp.expansions++
+	bindings := make(map[string]string)
+	for i, param := range m.params {
+		bindings[param] = args[i]
+	}
+	locals := make(map[string]string)
+	for _, bodyLine := range m.body {
+		lex := NewLexer(bodyLine)
+		if tok := lex.Next(); tok.Type == TokenIdent && lex.Next().Type == TokenColon {
+			locals[strings.ToUpper(tok.Text)] = fmt.Sprintf("__%s_%d_%s", strings.ToUpper(m.name), pp.expansions, strings.ToUpper(tok.Text))
+		}
+	}
+
+	for i, bodyLine := range m.body {
+		expanded := pp.substituteFunc(bodyLine, func(tok Token) (string, bool) {
+			if v, ok := bindings[tok.Text]; ok {
+				return v, true
+			}
+			v, ok := locals[strings.ToUpper(tok.Text)]
+			return v, ok
+		})
+		if i == 0 && label != "" {
+			if hasLabel(expanded) {
+				pp.emit(label+":", origin)
+			} else {
+				expanded = label + ": " + strings.TrimLeft(expanded, " \t")
+			}
+		}
+		if !pp.expandLine(expanded, origin, append(stack, invocation{m, col, width})) {
+			return false
+		}
+	}
+	if len(m.body) == 0 && label != "" {
+		pp.emit(label+":", origin)
+	}
+	return true
+}
+
+// substitute replaces identifiers in line using defs
+func (pp *preprocessor) substitute(line string, defs map[string]string) string {
+	return pp.substituteFunc(line, func(tok Token) (string, bool) {
+		v, ok := defs[tok.Text]
+		return v, ok
+	})
+}
+
+// substituteFunc replaces identifiers outside comments in line
+func (pp *preprocessor) substituteFunc(line string, repl func(Token) (string, bool)) string {
+	runes := []rune(line)
+	var b strings.Builder
+	last := 0
+	lex := NewLexer(line)
+	for tok := lex.Next(); tok.Type != TokenEOF && tok.Type != TokenComment; tok = lex.Next() {
+		if tok.Type != TokenIdent {
+			continue
+		}
+		v, ok := repl(tok)
+		if !ok {
+			continue
+		}
+		start := tok.Pos.Col - 1
+		b.WriteString(string(runes[last:start]))
+		b.WriteString(v)
+		last = start + utf8.RuneCountInString(tok.Text)
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String()
+}
+
+// emit appends a preprocessed line
+func (pp *preprocessor) emit(line string, origin Origin) {
+	pp.lines = append(pp.lines, line)
+	pp.origins = append(pp.origins, origin)
+}
+
+// report records an error diagnostic at origin
+func (pp *preprocessor) report(origin Origin, col, width int, code string, format string, a ...interface{}) {
+	pp.diags = append(pp.diags, Diagnostic{
+		File:     origin.File,
+		Line:     origin.Line,
+		Col:      col,
+		EndCol:   col + width,
+		Severity: SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// splitInvocation splits line into optional label, first word and comma separated arguments
+func splitInvocation(line string) (label, name string, args []string, namePos Pos, ok bool) {
+	lex := NewLexer(line)
+	tok := lex.Next()
+	if tok.Type != TokenIdent {
+		return "", "", nil, Pos{}, false
+	}
+	next := lex.Next()
+	if next.Type == TokenColon {
+		label = tok.Text
+		tok = lex.Next()
+		if tok.Type != TokenIdent {
+			return label, "", nil, Pos{}, false
+		}
+		next = lex.Next()
+	}
+
+	// Arguments run from after name to comment or end of line
+	runes := []rune(line)
+	start := tok.Pos.Col - 1 + utf8.RuneCountInString(tok.Text)
+	end := len(runes)
+	for t := next; t.Type != TokenEOF; t = lex.Next() {
+		if t.Type == TokenComment {
+			end = t.Pos.Col - 1
+			break
+		}
+	}
+	return label, tok.Text, splitArgs(string(runes[start:end])), tok.Pos, true
+}
+
+// hasLabel checks if line declares a label
+func hasLabel(line string) bool {
+	lex := NewLexer(line)
+	return lex.Next().Type == TokenIdent && lex.Next().Type == TokenColon
+}
+
+// splitArgs splits comma separated arguments
+func splitArgs(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
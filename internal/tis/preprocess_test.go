@@ -0,0 +1,221 @@
+package tis
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirIncluder(t *testing.T) {
+	root, err := ioutil.TempDir("", "tis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "include")
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "lib", "a.tis"), []byte("NOP"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	include := DirIncluder(dir)
+	for _, name := range []string{"lib/a.tis", "lib/../lib/a.tis", "./lib/a.tis"} {
+		if src, err := include(name); err != nil || src != "NOP" {
+			t.Errorf("include(%q) = %q, %v", name, src, err)
+		}
+	}
+	for _, name := range []string{"../secret", "lib/../../secret", "..", filepath.Join(root, "secret"), "/etc/passwd"} {
+		if src, err := include(name); err == nil {
+			t.Errorf("include(%q) = %q, want error", name, src)
+		}
+	}
+}
+
+func TestAssembleRejectsEscapingInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = Assemble("#include \"../../etc/passwd\"\nNOP", PreprocessOptions{Include: DirIncluder(dir)})
+	diags, ok := AsDiagnostics(err)
+	if !ok || len(diags) != 1 || diags[0].Code != CodeIncludeFailed || !strings.Contains(diags[0].Message, "leaves include directory") {
+		t.Errorf("Assemble() error = %v, want include-failed diagnostic", err)
+	}
+}
+
+func TestAssembleMapsPositionsToSourceLines(t *testing.T) {
+	include := func(name string) (string, error) { return "NOP\nNOP", nil }
+	src := "#define N 1\n#macro twice\nADD N\nADD N\n#endmacro\nstart: twice\n#include \"lib.tis\"\nJMP start"
+	prog, err := Assemble(src, PreprocessOptions{Include: include})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{6, 6, 7, 7, 8}
+	decoded, err := Decode(Encode(prog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*Program{prog, decoded} {
+		var got []int
+		for _, instr := range p.Instructions {
+			got = append(got, instr.Pos.Line)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("instruction lines = %v, want %v", got, want)
+		}
+	}
+	if prog.Instructions[0].LabelPos.Line != 6 || prog.Instructions[4].Args[0].Pos.Line != 8 {
+		t.Errorf("label or operand position not mapped: %v, %v", prog.Instructions[0].LabelPos, prog.Instructions[4].Args[0].Pos)
+	}
+}
+
+func TestPreprocessReportsRecursion(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Diagnostic
+	}{
+		{
+			name: "direct",
+			src:  "#macro loop\nNOP\nloop\n#endmacro\nNOP\n  loop",
+			want: Diagnostic{Line: 6, Col: 3, EndCol: 7, Code: CodeMacroRecursion,
+				Message: "macro 'loop' expands itself (loop -> loop)"},
+		},
+		{
+			name: "indirect",
+			src:  "#macro ping\npong\n#endmacro\n#macro pong\nping\nping\n#endmacro\nping",
+			want: Diagnostic{Line: 8, Col: 1, EndCol: 5, Code: CodeMacroRecursion,
+				Message: "macro 'ping' expands itself (ping -> pong -> ping)"},
+		},
+		{
+			name: "inner cycle",
+			src:  "#macro outer\ninner\n#endmacro\n#macro inner\nINNER\n#endmacro\nl: outer",
+			want: Diagnostic{Line: 7, Col: 4, EndCol: 9, Code: CodeMacroRecursion,
+				Message: "macro 'inner' expands itself (inner -> inner)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Preprocess(tt.src, PreprocessOptions{})
+			diags, ok := AsDiagnostics(err)
+			if !ok || !reflect.DeepEqual(diags, Diagnostics{tt.want}) {
+				t.Errorf("Preprocess() error = %+v, want %+v", err, tt.want)
+			}
+		})
+	}
+}
+
+// fanOut defines macros m0..mn where each expands to four of the one before
+func fanOut(n int) string {
+	var b strings.Builder
+	b.WriteString("#macro m0\nNOP\n#endmacro\n")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "#macro m%d\n", i)
+		for j := 0; j < 4; j++ {
+			fmt.Fprintf(&b, "m%d\n", i-1)
+		}
+		b.WriteString("#endmacro\n")
+	}
+	return b.String()
+}
+
+func TestPreprocessLimitsExpandedLines(t *testing.T) {
+	src := fanOut(12) + "NOP\nm12\nm12"
+	_, err := Preprocess(src, PreprocessOptions{})
+	diags, ok := AsDiagnostics(err)
+	want := Diagnostics{{Line: 77, Col: 1, EndCol: 4, Code: CodeExpansionLimit,
+		Message: fmt.Sprintf("macro expansion produces more than %d lines", maxExpandedLines)}}
+	if !ok || !reflect.DeepEqual(diags, want) {
+		t.Errorf("Preprocess() error = %+v, want %+v", err, want)
+	}
+
+	// Below the limit
+	if _, err := Preprocess(fanOut(6)+"m6\nm6", PreprocessOptions{}); err != nil {
+		t.Errorf("Preprocess() error = %v", err)
+	}
+}
+
+func TestParseBundleLimitsExpandedLinesAcrossSections(t *testing.T) {
+	// Each section is below the limit but the bundle is not
+	src := fanOut(7) + "@node a\nm7\n@node b\nm7\n@node c\nm7\n@node d\nm7\n@node e\nm7"
+	_, err := ParseBundle(src, PreprocessOptions{})
+	diags, ok := AsDiagnostics(err)
+	if !ok || len(diags) != 1 || diags[0].Code != CodeExpansionLimit {
+		t.Errorf("ParseBundle() error = %v, want expansion-limit diagnostic", err)
+	}
+}
+
+func TestPreprocessDirectives(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+		diag Diagnostic
+	}{
+		{
+			name: "comments that look like directives",
+			src:  "#include lib.tis\n#define\n#macro adds two numbers\n  #includes\n#endmacros\nNOP",
+			want: "#include lib.tis\n#define\n#macro adds two numbers\n  #includes\n#endmacros\nNOP",
+		},
+		{
+			name: "comments in macro",
+			src:  "#macro m\n#include lib.tis\nNOP\n#endmacro\nm",
+			want: "#include lib.tis\nNOP",
+		},
+		{
+			name: "define in macro",
+			src:  "#macro m\n  #define N 1\nADD N\n#endmacro\nm",
+			diag: Diagnostic{Line: 2, Col: 3, EndCol: 14, Code: CodeBadDirective,
+				Message: "directives cannot be used inside macro 'm'"},
+		},
+		{
+			name: "include in macro",
+			src:  "#macro m\n#include \"lib.tis\"\n#endmacro",
+			diag: Diagnostic{Line: 2, Col: 1, EndCol: 19, Code: CodeBadDirective,
+				Message: "directives cannot be used inside macro 'm'"},
+		},
+		{
+			name: "macro in macro",
+			src:  "#macro m\n#macro n(x)\n#endmacro",
+			diag: Diagnostic{Line: 2, Col: 1, EndCol: 12, Code: CodeBadDirective,
+				Message: "directives cannot be used inside macro 'm'"},
+		},
+		{
+			name: "endmacro without macro",
+			src:  "NOP\n#endmacro",
+			diag: Diagnostic{Line: 2, Col: 1, EndCol: 10, Code: CodeBadDirective,
+				Message: "#endmacro without #macro"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			include := func(name string) (string, error) { return "NOP", nil }
+			source, err := Preprocess(tt.src, PreprocessOptions{Include: include})
+			if tt.diag.Code != "" {
+				diags, ok := AsDiagnostics(err)
+				if !ok || !reflect.DeepEqual(diags, Diagnostics{tt.diag}) {
+					t.Errorf("Preprocess() error = %+v, want %+v", err, tt.diag)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source.Text != tt.want {
+				t.Errorf("Text = %q, want %q", source.Text, tt.want)
+			}
+		})
+	}
+}
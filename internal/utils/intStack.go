@@ -25,10 +25,10 @@ func (s *IntStack) Push(v int) {
 
 // Pop pops value at head of stack
 func (s *IntStack) Pop() (int, error) {
-	if l := len(s.stack); l > 0 {
-		s.mux.Lock()
-		defer s.mux.Unlock()
+	s.mux.Lock()
+	defer s.mux.Unlock()
 
+	if l := len(s.stack); l > 0 {
 		v := s.stack[l-1]
 		s.stack = s.stack[:l-1]
 		return v, nil
@@ -43,3 +43,17 @@ func (s *IntStack) Clear() {
 	defer s.mux.Unlock()
 	s.stack = make([]int, 0)
 }
+
+// Values gets copy of values in stack from bottom to top
+func (s *IntStack) Values() []int {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return append([]int{}, s.stack...)
+}
+
+// Len gets number of values in stack
+func (s *IntStack) Len() int {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return len(s.stack)
+}
@@ -20,3 +20,14 @@ func IntMin(a, b int) int {
 func IntClamp(v, a, b int) int {
 	return IntMax(a, IntMin(v, b))
 }
+
+// Int64Clamp clamps v between a and b inclusive
+func Int64Clamp(v, a, b int64) int64 {
+	if v < a {
+		return a
+	}
+	if v > b {
+		return b
+	}
+	return v
+}
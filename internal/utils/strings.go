@@ -0,0 +1,39 @@
+package utils
+
+// EditDistance finds number of insertions, deletions, substitutions and
+// adjacent transpositions needed to turn a into b
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = IntMin(IntMin(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = IntMin(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// ClosestString finds candidate nearest to s within max edits
+func ClosestString(s string, candidates []string, max int) (string, bool) {
+	best, bestDist := "", max+1
+	for _, c := range candidates {
+		if d := EditDistance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, bestDist <= max
+}